@@ -22,6 +22,7 @@ import (
 
 	"k8s.io/kubeadm/kinder/pkg/build/alter"
 	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/extract"
 )
 
 type flagpole struct {
@@ -35,6 +36,7 @@ type flagpole struct {
 	Kubelet                 string
 	PrePullAdditionalImages bool
 	Path                    []string
+	Arch                    string
 }
 
 // NewCommand returns a new cobra.Command for building the node image
@@ -100,6 +102,11 @@ func NewCommand() *cobra.Command {
 		nil,
 		"sourcePath:destPath pairs; copies file/dir at sourcePath on the host to destPath inside the image, destPath has to be absolute",
 	)
+	cmd.Flags().StringVar(
+		&flags.Arch, "arch",
+		extract.DefaultArch,
+		"architecture of the artifacts to be retrieved when using a version/build-label as a source, e.g. amd64, arm64, ppc64le or s390x",
+	)
 	return cmd
 }
 
@@ -118,6 +125,7 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
 		// bits options
 		alter.WithImageNamePrefix(flags.ImageNamePrefix),
 		alter.WithPath(flags.Path),
+		alter.WithArch(flags.Arch),
 	)
 	if err != nil {
 		return errors.Wrap(err, "error creating alter context")
@@ -0,0 +1,37 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"github.com/spf13/cobra"
+
+	"k8s.io/kubeadm/kinder/cmd/kinder/cache/prune"
+)
+
+// NewCommand returns a new cobra.Command for managing the local artifact cache
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "cache",
+		Short: "Manages the local artifact cache",
+		Long: "The local artifact cache stores Kubernetes artifacts downloaded by 'kinder get artifacts' and " +
+			"'kinder build node-image-variant', so they are not downloaded again.",
+	}
+	// add subcommands
+	cmd.AddCommand(prune.NewCommand())
+	return cmd
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prune
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"k8s.io/kubeadm/kinder/pkg/extract"
+)
+
+type flagpole struct {
+	CacheDir  string
+	OlderThan time.Duration
+	All       bool
+}
+
+// NewCommand returns a new cobra.Command for pruning the local artifact cache
+func NewCommand() *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "prune",
+		Short: "Removes unused artifacts from the local artifact cache",
+		Long: "Removes from the local artifact cache the artifacts not used and the labels not resolved " +
+			"since the given time, or all the cache content",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(flags, cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&flags.CacheDir,
+		"cache-dir", extract.DefaultCacheDir(),
+		"the folder of the local artifact cache",
+	)
+	cmd.Flags().DurationVar(
+		&flags.OlderThan,
+		"older-than", 7*24*time.Hour,
+		"remove artifacts not used since the given time",
+	)
+	cmd.Flags().BoolVar(
+		&flags.All,
+		"all", false,
+		"remove all the artifacts in the local artifact cache",
+	)
+
+	return cmd
+}
+
+func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
+	olderThan := flags.OlderThan
+	if flags.All {
+		olderThan = 0
+	}
+
+	removed, err := extract.PruneCache(flags.CacheDir, olderThan)
+	if err != nil {
+		return errors.Wrap(err, "failed to prune the local artifact cache")
+	}
+
+	for _, r := range removed {
+		log.Infof("Removed %s", r)
+	}
+	log.Infof("Local artifact cache pruned, %d items removed", len(removed))
+	return nil
+}
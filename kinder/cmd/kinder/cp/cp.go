@@ -34,7 +34,8 @@ func NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Args: cobra.ExactArgs(2),
 		Use: "cp [flags] [NODE_NAME|NODE_SELECTOR:]SRC_PATH DEST_PATH |-\n" +
-			"  kinder cp [flags] SRC_PATH [NODE_NAME|NODE_SELECTOR:]DEST_PATH\n\n" +
+			"  kinder cp [flags] SRC_PATH [NODE_NAME|NODE_SELECTOR:]DEST_PATH\n" +
+			"  kinder cp [flags] NODE_NAME|NODE_SELECTOR:SRC_PATH NODE_NAME|NODE_SELECTOR:DEST_PATH\n\n" +
 			"Args:\n" +
 			"  NODE_NAME is the container name without the cluster name prefix\n" +
 			"  NODE_SELECTOR can be one of:\n" +
@@ -45,7 +46,7 @@ func NewCommand() *cobra.Command {
 			"    @w* 	all the worker nodes\n" +
 			"    @lb 	the external load balancer\n" +
 			"    @etcd 	the external etcd",
-		Short: "Copy files/folders between a node and the local filesystem",
+		Short: "Copy files/folders between a node and the local filesystem or between nodes",
 		Long:  "kinder cp is a \"topology aware\" wrapper on docker cp",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runE(flags, cmd, args)
@@ -17,10 +17,17 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"k8s.io/kubeadm/kinder/pkg/cluster/config"
 	"k8s.io/kubeadm/kinder/pkg/cluster/manager"
+	"k8s.io/kubeadm/kinder/pkg/cluster/manager/actions"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/constants"
 )
 
@@ -31,13 +38,24 @@ const (
 
 type flagpole struct {
 	Name                 string
+	Config               string
 	ImageName            string
+	ControlPlaneImage    string
+	WorkerImage          string
 	Workers              int
 	ControlPlanes        int
 	Retain               bool
 	ExternalEtcd         bool
 	ExternalLoadBalancer bool
 	Volumes              []string
+	IPFamily             string
+	Subnet               string
+	KubeadmConfigVersion string
+	FeatureGate          string
+	EncryptionAlgorithm  string
+	CopyCerts            string
+	Discovery            string
+	PatchesDir           string
 }
 
 // NewCommand returns a new cobra.Command for cluster creation
@@ -58,6 +76,11 @@ func NewCommand() *cobra.Command {
 		"name", constants.DefaultClusterName,
 		"cluster name",
 	)
+	cmd.Flags().StringVar(
+		&flags.Config,
+		"config", "",
+		"path to a kinder cluster config file describing the cluster topology",
+	)
 	cmd.Flags().IntVar(
 		&flags.ControlPlanes,
 		controlPlaneNodesFlagName, 1,
@@ -71,7 +94,17 @@ func NewCommand() *cobra.Command {
 	cmd.Flags().StringVar(
 		&flags.ImageName,
 		"image", "",
-		"node docker image to use for booting the cluster",
+		"node docker image to use for booting the cluster; when using --config, this is the image for nodes without an explicit image",
+	)
+	cmd.Flags().StringVar(
+		&flags.ControlPlaneImage,
+		"control-plane-image", "",
+		"node docker image to use for control-plane nodes; if set, it takes precedence on --image",
+	)
+	cmd.Flags().StringVar(
+		&flags.WorkerImage,
+		"worker-image", "",
+		"node docker image to use for worker nodes; if set, it takes precedence on --image",
 	)
 	cmd.Flags().BoolVar(
 		&flags.Retain,
@@ -93,8 +126,46 @@ func NewCommand() *cobra.Command {
 		"volume", nil,
 		"mount a volume on node containers",
 	)
-
-	cmd.MarkFlagRequired("image")
+	cmd.Flags().StringVar(
+		&flags.IPFamily,
+		"ip-family", string(status.IPv4Family),
+		"IP family of the cluster; use one of [ipv4, ipv6, dual]",
+	)
+	cmd.Flags().StringVar(
+		&flags.Subnet,
+		"subnet", "",
+		"subnet of the docker network dedicated to the cluster; if not set, the subnet is chosen by docker",
+	)
+	cmd.Flags().StringVar(
+		&flags.KubeadmConfigVersion,
+		"kubeadm-config-version", "",
+		"the kubeadm config version to be used by kinder do, if not set when running actions",
+	)
+	cmd.Flags().StringVar(
+		&flags.FeatureGate,
+		"kubeadm-feature-gate", "",
+		"a single kubeadm feature-gate to be used by kinder do, if not set when running actions",
+	)
+	cmd.Flags().StringVar(
+		&flags.EncryptionAlgorithm,
+		"kubeadm-encryption-algorithm", "",
+		"the encryption algorithm to be used by kinder do, if not set when running actions",
+	)
+	cmd.Flags().StringVar(
+		&flags.CopyCerts,
+		"copy-certs", "",
+		fmt.Sprintf("mode to copy certs to be used by kinder do, if not set when running actions; use one of %s", actions.KnownCopyCertsMode()),
+	)
+	cmd.Flags().StringVar(
+		&flags.Discovery,
+		"discovery-mode", "",
+		fmt.Sprintf("the discovery mode to be used by kinder do, if not set when running actions; use one of %s", actions.KnownDiscoveryMode()),
+	)
+	cmd.Flags().StringVar(
+		&flags.PatchesDir,
+		"patches", "",
+		"the patches directory to be used by kinder do, if not set when running actions",
+	)
 
 	return cmd
 }
@@ -106,16 +177,73 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
 		return errors.Errorf("flags --%s and --%s should not be a negative number", controlPlaneNodesFlagName, workerNodesFlagName)
 	}
 
-	// get a kinder cluster manager
-	if err = manager.CreateCluster(
-		flags.Name,
+	ipFamily := status.ClusterIPFamily(flags.IPFamily)
+	switch ipFamily {
+	case status.IPv4Family, status.IPv6Family, status.DualStackFamily:
+	default:
+		return errors.Errorf("invalid --ip-family %q. Use one of [%s, %s, %s]", flags.IPFamily, status.IPv4Family, status.IPv6Family, status.DualStackFamily)
+	}
+
+	kubeadmSettings := status.KubeadmSettings{
+		ConfigVersion:       flags.KubeadmConfigVersion,
+		FeatureGate:         flags.FeatureGate,
+		EncryptionAlgorithm: flags.EncryptionAlgorithm,
+		CopyCertsMode:       strings.ToLower(flags.CopyCerts),
+		DiscoveryMode:       strings.ToLower(flags.Discovery),
+	}
+	if kubeadmSettings.CopyCertsMode != "" {
+		if err := actions.ValidateCopyCertsMode(actions.CopyCertsMode(kubeadmSettings.CopyCertsMode)); err != nil {
+			return err
+		}
+	}
+	if kubeadmSettings.DiscoveryMode != "" {
+		if err := actions.ValidateDiscoveryMode(actions.DiscoveryMode(kubeadmSettings.DiscoveryMode)); err != nil {
+			return err
+		}
+	}
+	if flags.PatchesDir != "" {
+		// the patches dir is stored as an absolute path, so it can be used from any folder
+		if kubeadmSettings.PatchesDir, err = filepath.Abs(flags.PatchesDir); err != nil {
+			return errors.Wrapf(err, "failed to resolve the patches dir %s", flags.PatchesDir)
+		}
+	}
+
+	options := []manager.CreateOption{
 		manager.ControlPlanes(flags.ControlPlanes),
 		manager.Workers(flags.Workers),
 		manager.Image(flags.ImageName),
+		manager.ControlPlaneImage(flags.ControlPlaneImage),
+		manager.WorkerImage(flags.WorkerImage),
 		manager.ExternalLoadBalancer(flags.ExternalLoadBalancer),
 		manager.ExternalEtcd(flags.ExternalEtcd),
 		manager.Retain(flags.Retain),
 		manager.Volumes(flags.Volumes),
+		manager.IPFamily(ipFamily),
+		manager.Subnet(flags.Subnet),
+		manager.Kubeadm(kubeadmSettings),
+	}
+
+	if flags.Config != "" {
+		if cmd.Flags().Changed(controlPlaneNodesFlagName) || cmd.Flags().Changed(workerNodesFlagName) {
+			return errors.Errorf("flags --%s and --%s can't be used together with --config", controlPlaneNodesFlagName, workerNodesFlagName)
+		}
+
+		cfg, err := config.Load(flags.Config)
+		if err != nil {
+			return err
+		}
+		options = append(options, manager.Config(cfg))
+
+		// the --image flag, if set, takes precedence on the default image defined in the config
+		if flags.ImageName != "" {
+			options = append(options, manager.Image(flags.ImageName))
+		}
+	}
+
+	// get a kinder cluster manager
+	if err = manager.CreateCluster(
+		flags.Name,
+		options...,
 	); err != nil {
 		return errors.Wrap(err, "failed to create cluster")
 	}
@@ -20,6 +20,7 @@ import (
 	"github.com/spf13/cobra"
 
 	createcluster "k8s.io/kubeadm/kinder/cmd/kinder/create/cluster"
+	createnode "k8s.io/kubeadm/kinder/cmd/kinder/create/node"
 )
 
 // NewCommand returns a new cobra.Command for cluster creation
@@ -27,9 +28,10 @@ func NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Args:  cobra.NoArgs,
 		Use:   "create",
-		Short: "Creates a cluster",
-		Long:  "Creates a local Kubernetes cluster",
+		Short: "Creates one of [cluster, node]",
+		Long:  "Creates a local Kubernetes cluster or adds nodes to an existing cluster",
 	}
 	cmd.AddCommand(createcluster.NewCommand())
+	cmd.AddCommand(createnode.NewCommand())
 	return cmd
 }
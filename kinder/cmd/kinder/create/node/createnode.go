@@ -0,0 +1,109 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/manager"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+type flagpole struct {
+	Name      string
+	ImageName string
+	Role      string
+	Count     int
+	Retain    bool
+	Volumes   []string
+}
+
+// NewCommand returns a new cobra.Command for adding nodes to an existing cluster
+func NewCommand() *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "node",
+		Short: "Adds nodes to an existing local Kubernetes cluster",
+		Long: "Adds Docker container 'nodes' to an existing local Kubernetes cluster.\n" +
+			"New nodes can be joined to the Kubernetes cluster using 'kinder do kubeadm-join --only-node'",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(flags, cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&flags.Name,
+		"name", constants.DefaultClusterName,
+		"cluster name",
+	)
+	cmd.Flags().StringVar(
+		&flags.Role,
+		"role", constants.WorkerNodeRoleValue,
+		"the role of the nodes to add; if adding control-plane nodes to a cluster without an external load balancer, the load balancer is added as well",
+	)
+	cmd.Flags().IntVar(
+		&flags.Count,
+		"count", 1,
+		"number of nodes to add",
+	)
+	cmd.Flags().StringVar(
+		&flags.ImageName,
+		"image", "",
+		"node docker image to use for the new nodes; if not set, the image of the existing nodes is used",
+	)
+	cmd.Flags().BoolVar(
+		&flags.Retain,
+		"retain", false,
+		"retain nodes for debugging when node creation fails",
+	)
+	cmd.Flags().StringSliceVar(
+		&flags.Volumes,
+		"volume", nil,
+		"mount a volume on node containers",
+	)
+
+	return cmd
+}
+
+func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
+	if flags.Count < 1 {
+		return errors.New("flag --count should be a positive number")
+	}
+
+	options := []manager.CreateOption{
+		manager.Image(flags.ImageName),
+		manager.Retain(flags.Retain),
+		manager.Volumes(flags.Volumes),
+	}
+
+	switch flags.Role {
+	case constants.ControlPlaneNodeRoleValue:
+		options = append(options, manager.ControlPlanes(flags.Count))
+	case constants.WorkerNodeRoleValue:
+		options = append(options, manager.Workers(flags.Count))
+	default:
+		return errors.Errorf("invalid role %q. Use one of [%s, %s]", flags.Role, constants.ControlPlaneNodeRoleValue, constants.WorkerNodeRoleValue)
+	}
+
+	if err := manager.AddNodes(flags.Name, options...); err != nil {
+		return errors.Wrap(err, "failed to add nodes")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/manager"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+type flagpole struct {
+	Name string
+	All  bool
+}
+
+// NewCommand returns a new cobra.Command for cluster deletion
+func NewCommand() *cobra.Command {
+	flags := &flagpole{}
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "cluster",
+		Short: "Deletes a local Kubernetes cluster",
+		Long:  "Deletes all the 'node' containers of a local Kubernetes cluster and removes its kubeconfig file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runE(flags, cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&flags.Name,
+		"name", constants.DefaultClusterName,
+		"cluster name",
+	)
+	cmd.Flags().BoolVar(
+		&flags.All,
+		"all", false,
+		"delete all the existing clusters",
+	)
+
+	return cmd
+}
+
+func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
+	if flags.All {
+		if err := manager.DeleteAllClusters(); err != nil {
+			return errors.Wrap(err, "failed to delete clusters")
+		}
+		return nil
+	}
+
+	if err := manager.DeleteCluster(flags.Name); err != nil {
+		return errors.Wrap(err, "failed to delete cluster")
+	}
+
+	return nil
+}
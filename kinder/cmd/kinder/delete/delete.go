@@ -0,0 +1,37 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delete
+
+import (
+	"github.com/spf13/cobra"
+
+	deletecluster "k8s.io/kubeadm/kinder/cmd/kinder/delete/cluster"
+	deletenodes "k8s.io/kubeadm/kinder/cmd/kinder/delete/nodes"
+)
+
+// NewCommand returns a new cobra.Command for cluster deletion
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "delete",
+		Short: "Deletes one of [cluster, nodes]",
+		Long:  "Deletes a local Kubernetes cluster or some of its nodes",
+	}
+	cmd.AddCommand(deletecluster.NewCommand())
+	cmd.AddCommand(deletenodes.NewCommand())
+	return cmd
+}
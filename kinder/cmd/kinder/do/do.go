@@ -27,6 +27,7 @@ import (
 	K8sVersion "k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/kubeadm/kinder/pkg/cluster/manager"
 	"k8s.io/kubeadm/kinder/pkg/cluster/manager/actions"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/constants"
 )
 
@@ -45,6 +46,11 @@ type flagpole struct {
 	KubeadmConfigVersion  string
 	FeatureGate           string
 	EncryptionAlgorithm   string
+	Certificate           string
+	ClockOffset           time.Duration
+	Drain                 bool
+	DrainTimeout          time.Duration
+	InjectUpgradeFailure  bool
 }
 
 // NewCommand returns a new cobra.Command for exec
@@ -134,6 +140,31 @@ func NewCommand() *cobra.Command {
 		"kubeadm-encryption-algorithm", "",
 		"the encryption algorithm used by kubeadm for private keys in the cluster",
 	)
+	cmd.Flags().StringVar(
+		&flags.Certificate,
+		"certificate", "all",
+		"the certificate to be renewed by kubeadm-certs-renew, e.g. apiserver or admin.conf; use all for renewing all the certificates",
+	)
+	cmd.Flags().DurationVar(
+		&flags.ClockOffset,
+		"clock-offset", 0,
+		"the offset simulating a node clock moved forward in kubeadm-certs-renew, e.g. 8700h; before renewal, certificates are re-issued to expire as if the node clock was moved forward by this offset (requires kubeadm v1.31 or higher)",
+	)
+	cmd.Flags().BoolVar(
+		&flags.Drain,
+		"drain", false,
+		"cordon and drain nodes before upgrading them in kubeadm-upgrade, and uncordon them after",
+	)
+	cmd.Flags().DurationVar(
+		&flags.DrainTimeout,
+		"drain-timeout", time.Duration(5*time.Minute),
+		"the timeout for draining nodes in kubeadm-upgrade and kubeadm-remove-node",
+	)
+	cmd.Flags().BoolVar(
+		&flags.InjectUpgradeFailure,
+		"inject-upgrade-failure", false,
+		"in kubeadm-upgrade, break the kube-apiserver with a patch and verify that kubeadm rolls back control-plane nodes before upgrading them",
+	)
 	return cmd
 }
 
@@ -147,6 +178,15 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) (err error) {
 		}
 	}
 
+	// get a kinder cluster manager
+	o, err := manager.NewClusterManager(flags.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a kinder cluster manager for %s", flags.Name)
+	}
+
+	// use the settings chosen at create time for the flags not explicitly set
+	applyClusterSettings(cmd, flags, o.Cluster.Settings)
+
 	discovery := actions.DiscoveryMode(strings.ToLower(flags.Discovery))
 	if err := actions.ValidateDiscoveryMode(discovery); err != nil {
 		return err
@@ -157,12 +197,6 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	// get a kinder cluster manager
-	o, err := manager.NewClusterManager(flags.Name)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create a kinder cluster manager for %s", flags.Name)
-	}
-
 	// eventually, instruct the cluster manager to run only commands on one node
 	if flags.OnlyNode != "" {
 		if err := o.OnlyNode(flags.OnlyNode); err != nil {
@@ -191,6 +225,11 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) (err error) {
 		actions.KubeadmConfigVersion(flags.KubeadmConfigVersion),
 		actions.FeatureGate(flags.FeatureGate),
 		actions.EncryptionAlgorithm(flags.EncryptionAlgorithm),
+		actions.Certificate(flags.Certificate),
+		actions.ClockOffset(flags.ClockOffset),
+		actions.Drain(flags.Drain),
+		actions.DrainTimeout(flags.DrainTimeout),
+		actions.InjectUpgradeFailure(flags.InjectUpgradeFailure),
 	)
 	if err != nil {
 		return errors.Wrapf(err, "failed to exec action %s", action)
@@ -198,3 +237,23 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) (err error) {
 
 	return nil
 }
+
+// applyClusterSettings sets the flags not explicitly set using the kubeadm settings stored in the cluster at create time
+func applyClusterSettings(cmd *cobra.Command, flags *flagpole, settings *status.ClusterSettings) {
+	if settings == nil {
+		return
+	}
+
+	setIfNotChanged := func(name string, target *string, value string) {
+		if value != "" && !cmd.Flags().Changed(name) {
+			*target = value
+		}
+	}
+
+	setIfNotChanged("kubeadm-config-version", &flags.KubeadmConfigVersion, settings.Kubeadm.ConfigVersion)
+	setIfNotChanged("kubeadm-feature-gate", &flags.FeatureGate, settings.Kubeadm.FeatureGate)
+	setIfNotChanged("kubeadm-encryption-algorithm", &flags.EncryptionAlgorithm, settings.Kubeadm.EncryptionAlgorithm)
+	setIfNotChanged("copy-certs", &flags.CopyCerts, settings.Kubeadm.CopyCertsMode)
+	setIfNotChanged("discovery-mode", &flags.Discovery, settings.Kubeadm.DiscoveryMode)
+	setIfNotChanged("patches", &flags.PatchesDir, settings.Kubeadm.PatchesDir)
+}
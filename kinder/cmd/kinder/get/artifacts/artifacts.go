@@ -23,6 +23,8 @@ import (
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 
+	"k8s.io/kubeadm/kinder/pkg/build/alter"
+	"k8s.io/kubeadm/kinder/pkg/constants"
 	"k8s.io/kubeadm/kinder/pkg/extract"
 )
 
@@ -38,6 +40,15 @@ type flagpole struct {
 	OnlyKubelet  bool
 	OnlyBinaries bool
 	OnlyImages   bool
+	OS           string
+	Arch         string
+	Checksum     string
+	CacheOnly    bool
+	Parallelism  int
+	Build        bool
+	Bundle       string
+	BaseImage    string
+	Insecure     bool
 }
 
 // NewCommand returns a new cobra.Command for exec
@@ -55,6 +66,12 @@ func NewCommand() *cobra.Command {
 			"    VERSION          as shortcut to release/VERSION if build metadata are empty, else to ci/VERSION\n" +
 			"    URL              an http or http server where release artifacts are available\n" +
 			"    PATH             a local folder (file:// schema can be use to disambiguate release/ or ci/ folder)\n" +
+			"    image://IMAGE    a kindest/node image, e.g. image://docker.io/kindest/node:v1.31.0, where Kubernetes\n" +
+			"                     images are pulled from registry.k8s.io, or a registry hosting Kubernetes images,\n" +
+			"                     e.g. image://registry.k8s.io:v1.31.0\n" +
+			"    kuberoot://PATH  a Kubernetes source checkout, where artifacts are built with make quick-release;\n" +
+			"                     if PATH is empty, $GOPATH/src/k8s.io/kubernetes is used\n" +
+			"    bundle://FILE    a bundle created with kinder get artifacts --bundle\n" +
 			"  DESTINATION_PATH should be a local path; if missing the current path will be used",
 		Aliases: []string{"build-artifacts", "release-artifacts", "ci-artifacts"},
 		Short:   "Gets ci/release artifacts for a given Kubernetes version",
@@ -80,6 +97,45 @@ func NewCommand() *cobra.Command {
 		onlyImagesFLagName, false,
 		"Gets only the kube-apiserver, kube-scheduler, kube-controller-manager and kube-proxy image tarballs (instead of all artifacts)",
 	)
+	cmd.Flags().StringVar(&flags.OS,
+		"os", extract.DefaultOS,
+		"The OS of the artifacts to get from release or ci builds",
+	)
+	cmd.Flags().StringVar(&flags.Arch,
+		"arch", extract.DefaultArch,
+		"The architecture of the artifacts to get from release or ci builds, e.g. amd64, arm64, ppc64le or s390x",
+	)
+	cmd.Flags().StringVar(&flags.Checksum,
+		"checksum", "",
+		"The algorithm used for verifying checksums of downloaded artifacts, one of sha256, sha512 or none. "+
+			"If not set, sha256 checksums are verified for release or ci builds only",
+	)
+	cmd.Flags().BoolVar(&flags.CacheOnly,
+		"cache-only", false,
+		"Gets artifacts only from the local artifact cache, without accessing release or ci builds or remote repositories",
+	)
+	cmd.Flags().IntVar(&flags.Parallelism,
+		"parallelism", extract.DefaultParallelism,
+		"The number of artifacts downloaded in parallel",
+	)
+	cmd.Flags().BoolVar(&flags.Build,
+		"build", false,
+		"Builds missing artifacts using make, when getting artifacts from a Kubernetes source checkout",
+	)
+	cmd.Flags().StringVar(&flags.BaseImage,
+		"base-image", constants.DefaultBaseImage,
+		"The kindest/base or kindest/node image used for running kubeadm when listing the additional images required "+
+			"by kubeadm for --bundle",
+	)
+	cmd.Flags().BoolVar(&flags.Insecure,
+		"insecure-registry", false,
+		"Accesses the registry of image:// sources via http instead of https",
+	)
+	cmd.Flags().StringVar(&flags.Bundle,
+		"bundle", "",
+		"Packs all the artifacts, including additional images required by kubeadm, into the given tar.gz file, "+
+			"that can be used as a source for air-gapped environments using bundle://FILE",
+	)
 
 	return cmd
 }
@@ -91,6 +147,11 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
 		return errors.Errorf("flags [%s] are mutually exclusive, please set only one of them", strings.Join(exclusiveFlags, ", "))
 	}
 
+	checksum, err := extract.ParseChecksumAlgorithm(flags.Checksum)
+	if err != nil {
+		return err
+	}
+
 	// retrieve src and dst from arguments
 	src := args[0]
 	dst := ""
@@ -98,16 +159,52 @@ func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
 		dst = args[1]
 	}
 
+	// creates a bundle with all the artifacts, if required
+	if flags.Bundle != "" {
+		for _, f := range exclusiveFlags {
+			if cmd.Flags().Changed(f) {
+				return errors.Errorf("flag --%s can't be used together with --bundle", f)
+			}
+		}
+		if dst != "" {
+			return errors.New("DESTINATION_PATH can't be used together with --bundle")
+		}
+
+		// kubeadm is executed in a container like when altering node images, so it does not depend on the host OS;
+		// kubeadm binaries for other architectures require emulation, e.g. qemu, to be configured on the host
+		ctx, err := alter.NewContext(alter.WithBaseImage(flags.BaseImage), alter.WithArch(flags.Arch))
+		if err != nil {
+			return err
+		}
+		err = extract.CreateBundle(src, flags.Bundle, ctx.ListKubeadmImages,
+			extract.WithOS(flags.OS),
+			extract.WithArch(flags.Arch),
+			extract.WithChecksum(checksum),
+			extract.WithCacheOnly(flags.CacheOnly),
+			extract.WithParallelism(flags.Parallelism),
+			extract.WithBuild(flags.Build),
+			extract.WithInsecureRegistry(flags.Insecure),
+		)
+		return errors.Wrapf(err, "failed to create a bundle for %s version", src)
+	}
+
 	// Build an artifact extractor customized with the command options
 	e := extract.NewExtractor(src, dst,
 		extract.OnlyKubeadm(flags.OnlyKubeadm),
 		extract.OnlyKubelet(flags.OnlyKubelet),
 		extract.OnlyKubernetesBinaries(flags.OnlyBinaries),
 		extract.OnlyKubernetesImages(flags.OnlyImages),
+		extract.WithOS(flags.OS),
+		extract.WithArch(flags.Arch),
+		extract.WithChecksum(checksum),
+		extract.WithCacheOnly(flags.CacheOnly),
+		extract.WithParallelism(flags.Parallelism),
+		extract.WithBuild(flags.Build),
+		extract.WithInsecureRegistry(flags.Insecure),
 	)
 
 	// Extracts the artifacts from the source
-	_, err := e.Extract()
+	_, err = e.Extract()
 	if err != nil {
 		return errors.Wrapf(err, "failed to gets build artifacts for %s version", src)
 	}
@@ -51,7 +51,7 @@ func NewCommand() *cobra.Command {
 }
 
 func runE(flags *flagpole, cmd *cobra.Command, args []string) error {
-	cluster, err := status.FromDocker(flags.Name)
+	cluster, err := status.Discover(flags.Name)
 	if err != nil {
 		return err
 	}
@@ -18,22 +18,26 @@ limitations under the License.
 package kinder
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"k8s.io/kubeadm/kinder/cmd/kinder/build"
+	"k8s.io/kubeadm/kinder/cmd/kinder/cache"
 	"k8s.io/kubeadm/kinder/cmd/kinder/cp"
 	"k8s.io/kubeadm/kinder/cmd/kinder/create"
+	"k8s.io/kubeadm/kinder/cmd/kinder/delete"
 	"k8s.io/kubeadm/kinder/cmd/kinder/do"
 	"k8s.io/kubeadm/kinder/cmd/kinder/exec"
 	"k8s.io/kubeadm/kinder/cmd/kinder/get"
 	"k8s.io/kubeadm/kinder/cmd/kinder/test"
 	"k8s.io/kubeadm/kinder/cmd/kinder/version"
 	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 	kindcmd "sigs.k8s.io/kind/pkg/cmd"
-	kinddelete "sigs.k8s.io/kind/pkg/cmd/kind/delete"
 	kindexport "sigs.k8s.io/kind/pkg/cmd/kind/export"
 )
 
@@ -42,6 +46,7 @@ const defaultLevel = log.WarnLevel
 // Flags for the kinder command
 type Flags struct {
 	LogLevel string
+	Provider string
 }
 
 // NewCommand returns a new cobra.Command implementing the root command for kinder
@@ -71,21 +76,29 @@ func NewCommand() *cobra.Command {
 		defaultLevel.String(),
 		"logrus log level [panic, fatal, error, warning, info, debug, trace]",
 	)
+	cmd.PersistentFlags().StringVar(
+		&flags.Provider,
+		"provider",
+		"",
+		fmt.Sprintf("experimental: the container engine hosting the nodes; use one of %s. "+
+			"If not set, the %s environment variable is used, otherwise docker", provider.Known(), provider.EnvVar),
+	)
 
 	logger := kindcmd.NewLogger()
 	ioStreams := kindcmd.StandardIOStreams()
 
 	// add kind top level subcommands re-used without changes
-	cmd.AddCommand(kinddelete.NewCommand(logger, ioStreams))
 	cmd.AddCommand(kindexport.NewCommand(logger, ioStreams))
 
 	// add kind commands customized in kind
 	cmd.AddCommand(build.NewCommand())
 	cmd.AddCommand(create.NewCommand())
+	cmd.AddCommand(delete.NewCommand())
 	cmd.AddCommand(version.NewCommand())
 	cmd.AddCommand(get.NewCommand())
 
 	// add kinder only commands
+	cmd.AddCommand(cache.NewCommand())
 	cmd.AddCommand(cp.NewCommand())
 	cmd.AddCommand(do.NewCommand())
 	cmd.AddCommand(exec.NewCommand())
@@ -103,6 +116,20 @@ func runE(flags *Flags, cmd *cobra.Command, args []string) error {
 		level = parsed
 	}
 	log.SetLevel(level)
+
+	// select the container engine hosting the nodes
+	p := os.Getenv(provider.EnvVar)
+	if flags.Provider != "" {
+		p = flags.Provider
+	}
+	if p != "" {
+		if err := provider.Set(provider.Provider(strings.ToLower(p))); err != nil {
+			return err
+		}
+		if provider.Get() != provider.Docker {
+			log.Warnf("Using the experimental %s provider", provider.Get())
+		}
+	}
 	return nil
 }
 
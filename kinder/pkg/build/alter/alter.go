@@ -34,6 +34,7 @@ import (
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes"
 	"k8s.io/kubeadm/kinder/pkg/exec"
 	"k8s.io/kubeadm/kinder/pkg/extract"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 	kindfs "sigs.k8s.io/kind/pkg/fs"
 )
 
@@ -56,6 +57,7 @@ type Context struct {
 	kubeletSrc              string
 	prePullAdditionalImages bool
 	paths                   []string
+	arch                    string
 }
 
 // Option is Context configuration option supplied to NewContext
@@ -131,11 +133,23 @@ func WithPath(paths []string) Option {
 	}
 }
 
+// WithArch configures a NewContext to retrieve artifacts for the given architecture
+// when using release or ci builds as a source
+func WithArch(arch string) Option {
+	return func(b *Context) {
+		if arch != "" {
+			b.arch = arch
+		}
+	}
+}
+
 // NewContext creates a new Context with default configuration,
 // overridden by the options supplied in the order that they are supplied
 func NewContext(options ...Option) (ctx *Context, err error) {
 	// default options
-	ctx = &Context{}
+	ctx = &Context{
+		arch: extract.DefaultArch,
+	}
 
 	// apply user options
 	for _, option := range options {
@@ -167,18 +181,18 @@ func (c *Context) Alter() (err error) {
 	var bitsInstallers []bits.Installer
 
 	if c.initArtifactsSrc != "" {
-		bitsInstallers = append(bitsInstallers, bits.NewInitBits(c.initArtifactsSrc))
+		bitsInstallers = append(bitsInstallers, bits.NewInitBits(c.initArtifactsSrc, c.arch))
 	}
 
 	if c.kubeadmSrc != "" {
-		bitsInstallers = append(bitsInstallers, bits.NewBinaryBits(c.kubeadmSrc, "kubeadm"))
+		bitsInstallers = append(bitsInstallers, bits.NewBinaryBits(c.kubeadmSrc, "kubeadm", c.arch))
 	}
 	if c.kubeletSrc != "" {
-		bitsInstallers = append(bitsInstallers, bits.NewBinaryBits(c.kubeletSrc, "kubelet"))
+		bitsInstallers = append(bitsInstallers, bits.NewBinaryBits(c.kubeletSrc, "kubelet", c.arch))
 	}
 
 	if len(c.imageSrcs) > 0 {
-		bitsInstallers = append(bitsInstallers, bits.NewImageBits(c.imageSrcs, c.imageNamePrefix))
+		bitsInstallers = append(bitsInstallers, bits.NewImageBits(c.imageSrcs, c.imageNamePrefix, c.arch))
 	}
 
 	if c.upgradeArtifactsSrc != "" {
@@ -188,7 +202,7 @@ func (c *Context) Alter() (err error) {
 		if src == c.initArtifactsSrc {
 			src = filepath.Join(bc.HostBitsPath(), bits.InitBitsDir)
 		}
-		bitsInstallers = append(bitsInstallers, bits.NewUpgradeBits(src))
+		bitsInstallers = append(bitsInstallers, bits.NewUpgradeBits(src, c.arch))
 	}
 
 	if len(c.paths) > 0 {
@@ -230,7 +244,7 @@ func (c *Context) prepareBits(bitsInstallers []bits.Installer, bc *bits.BuildCon
 		for k, v := range bits {
 			// if the bit is one of the kubernetes images, we should ensure the repository/name matches kubeadm expectations
 			if isAKubernetesImages(k) {
-				if err := fixImageTar(v); err != nil {
+				if err := fixImageTar(v, c.arch); err != nil {
 					return errors.Wrap(err, "failed to fix bits")
 				}
 			}
@@ -241,7 +255,7 @@ func (c *Context) prepareBits(bitsInstallers []bits.Installer, bc *bits.BuildCon
 }
 
 // fixImageTar ensure the repository/name matches kubeadm expectations
-func fixImageTar(v string) error {
+func fixImageTar(v, arch string) error {
 	log.Infof("fixing %s", v)
 
 	// prepare to read the image tar
@@ -253,7 +267,7 @@ func fixImageTar(v string) error {
 
 	// read the image tar and write the fixed version on a string builder
 	var w strings.Builder
-	err = host.EditArchiveRepositories(f, &w, fixRepository)
+	err = host.EditArchiveRepositories(f, &w, fixRepository(arch))
 	if err != nil {
 		return err
 	}
@@ -268,20 +282,22 @@ func fixImageTar(v string) error {
 	return nil
 }
 
-// fixRepository drop the arch suffix from images to get the expected image;
+// fixRepository returns a func that drops the arch suffix from images to get the expected image;
 // this is necessary for kubernetes v1.15+
 // Nb. for < v1.12 it was requested to do the opposite, but it not necessary anymore
 // because v.11 is already out of the kubeadm e2e test matrix
-func fixRepository(repository string) string {
-	archSuffix := "-amd64"
+func fixRepository(arch string) func(string) string {
+	archSuffix := "-" + arch
+
+	return func(repository string) string {
+		if strings.HasSuffix(repository, archSuffix) {
+			fixed := strings.TrimSuffix(repository, archSuffix)
+			fmt.Println("fixed: " + repository + " -> " + fixed)
+			repository = fixed
+		}
 
-	if strings.HasSuffix(repository, archSuffix) {
-		fixed := strings.TrimSuffix(repository, archSuffix)
-		fmt.Println("fixed: " + repository + " -> " + fixed)
-		repository = fixed
+		return repository
 	}
-
-	return repository
 }
 
 func (c *Context) alterImage(bitsInstallers []bits.Installer, bc *bits.BuildContext) error {
@@ -311,7 +327,7 @@ func (c *Context) alterImage(bitsInstallers []bits.Installer, bc *bits.BuildCont
 	// ensure we will delete it
 	if containerID != "" {
 		defer func() {
-			exec.NewHostCmd("docker", "rm", "-f", "-v", containerID).Run()
+			exec.NewHostCmd(provider.Command(), "rm", "-f", "-v", containerID).Run()
 		}()
 	}
 	if err != nil {
@@ -425,7 +441,7 @@ func pullImages(alterHelper *nodes.AlterHelper, bc *bits.BuildContext, images []
 
 	for _, image := range images {
 		// Pull the image on the host
-		if err := exec.NewHostCmd("docker", "pull", image).Run(); err != nil {
+		if err := exec.NewHostCmd(provider.Command(), "pull", image).Run(); err != nil {
 			return errors.Wrapf(err, "failed to pull image %q on the host", image)
 		}
 
@@ -438,12 +454,12 @@ func pullImages(alterHelper *nodes.AlterHelper, bc *bits.BuildContext, images []
 		hostPath := filepath.Join(tempDir, fileName)
 
 		// Save the tar
-		if err := exec.NewHostCmd("docker", "save", "-o="+hostPath, image).Run(); err != nil {
+		if err := exec.NewHostCmd(provider.Command(), "save", "-o="+hostPath, image).Run(); err != nil {
 			return errors.Wrapf(err, "failed to save image %q to path %q", image, hostPath)
 		}
 
 		// Copy the tar to the container
-		if err := exec.NewHostCmd("docker", "cp", hostPath, containerID+":"+savePath).Run(); err != nil {
+		if err := exec.NewHostCmd(provider.Command(), "cp", hostPath, containerID+":"+savePath).Run(); err != nil {
 			return errors.Wrapf(err, "failed to copy the file %q to container %q", image, containerID)
 		}
 
@@ -455,6 +471,48 @@ func pullImages(alterHelper *nodes.AlterHelper, bc *bits.BuildContext, images []
 	return nil
 }
 
+// ListKubeadmImages runs a kubeadm binary in a container created from the base image, and returns the images
+// required by kubeadm that are not Kubernetes images, e.g. etcd, coredns and pause
+func (c *Context) ListKubeadmImages(kubeadm string) ([]string, error) {
+	dir, err := kindfs.TempDir("", "kinder-kubeadm-images")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// initialize the build context, with the kubeadm binary
+	bc := bits.NewBuildContext(dir)
+	if err := kindfs.CopyFile(kubeadm, filepath.Join(bc.HostBasePath(), "kubeadm")); err != nil {
+		return nil, errors.Wrapf(err, "failed to copy %s", kubeadm)
+	}
+	if err := os.Chmod(filepath.Join(bc.HostBasePath(), "kubeadm"), 0755); err != nil {
+		return nil, err
+	}
+
+	runtime, err := status.InspectCRIinImage(c.baseImage)
+	if err != nil {
+		return nil, errors.Wrap(err, "error detecting CRI!")
+	}
+	alterHelper, err := nodes.NewAlterHelper(runtime)
+	if err != nil {
+		return nil, err
+	}
+
+	runArgs, containerArgs := alterHelper.GetAlterContainerArgs()
+	containerID, err := c.createAlterContainer(bc, runArgs, containerArgs)
+	if containerID != "" {
+		defer func() {
+			exec.NewHostCmd(provider.Command(), "rm", "-f", "-v", containerID).Run()
+		}()
+	}
+	if err != nil {
+		return nil, err
+	}
+	bc.BindToContainer(containerID)
+
+	return alterHelper.GetImagesForKubeadmBinary(bc, filepath.Join(bc.ContainerBasePath(), "kubeadm"))
+}
+
 func (c *Context) createAlterContainer(bc *bits.BuildContext, runArgs, containerArgs []string) (id string, err error) {
 	// attempt to explicitly pull the image if it doesn't exist locally
 	// we don't care if this errors, we'll still try to run which also pulls
@@ -0,0 +1,69 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alter
+
+import (
+	"testing"
+)
+
+func TestFixRepository(t *testing.T) {
+	tests := []struct {
+		name       string
+		arch       string
+		repository string
+		expected   string
+	}{
+		{
+			name:       "amd64 suffix is removed for amd64",
+			arch:       "amd64",
+			repository: "registry.k8s.io/kube-apiserver-amd64",
+			expected:   "registry.k8s.io/kube-apiserver",
+		},
+		{
+			name:       "arm64 suffix is removed for arm64",
+			arch:       "arm64",
+			repository: "registry.k8s.io/kube-proxy-arm64",
+			expected:   "registry.k8s.io/kube-proxy",
+		},
+		{
+			name:       "ppc64le suffix is removed for ppc64le",
+			arch:       "ppc64le",
+			repository: "gcr.io/k8s-staging-ci-images/kube-scheduler-ppc64le",
+			expected:   "gcr.io/k8s-staging-ci-images/kube-scheduler",
+		},
+		{
+			name:       "suffix for other arch is preserved",
+			arch:       "arm64",
+			repository: "registry.k8s.io/kube-apiserver-amd64",
+			expected:   "registry.k8s.io/kube-apiserver-amd64",
+		},
+		{
+			name:       "repository without suffix is preserved",
+			arch:       "s390x",
+			repository: "registry.k8s.io/kube-controller-manager",
+			expected:   "registry.k8s.io/kube-controller-manager",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := fixRepository(test.arch)(test.repository); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 	"sigs.k8s.io/kind/pkg/fs"
 )
 
@@ -127,7 +128,7 @@ func (c *BuildContext) buildEntrypoint(dir string) error {
 
 func (c *BuildContext) buildImage(dir string) error {
 	// build the image, tagged as tagImageAs, using the our tempdir as the context
-	cmd := exec.NewHostCmd("docker", "build", "-t", c.image, dir)
+	cmd := exec.NewHostCmd(provider.Command(), "build", "-t", c.image, dir)
 	log.Info("Starting Docker build ...")
 
 	if err := cmd.RunWithEcho(); err != nil {
@@ -29,15 +29,18 @@ import (
 type binaryBits struct {
 	src        string
 	binaryName string
+	arch       string
 }
 
 var _ Installer = &binaryBits{}
 
-// NewBinaryBits returns a new binary Installer
-func NewBinaryBits(src, binaryName string) Installer {
+// NewBinaryBits returns a new binary Installer; arch is the architecture of the binary
+// to be retrieved from release or ci builds
+func NewBinaryBits(src, binaryName, arch string) Installer {
 	return &binaryBits{
 		src:        src,
 		binaryName: binaryName,
+		arch:       arch,
 	}
 }
 
@@ -50,6 +53,7 @@ func (b *binaryBits) Prepare(c *BuildContext) (map[string]string, error) {
 		b.src, c.HostBitsPath(),
 		extract.OnlyKubeadm(b.binaryName == "kubeadm"),
 		extract.OnlyKubelet(b.binaryName == "kubelet"),
+		extract.WithArch(b.arch),
 	)
 
 	// Extracts the binary bit
@@ -31,15 +31,18 @@ import (
 type imageBits struct {
 	srcs       []string
 	namePrefix string
+	arch       string
 }
 
 var _ Installer = &imageBits{}
 
-// NewImageBits returns a new imageBits
-func NewImageBits(args []string, namePrefix string) Installer {
+// NewImageBits returns a new imageBits; arch is the architecture of the image tarballs
+// to be retrieved from release or ci builds
+func NewImageBits(args []string, namePrefix, arch string) Installer {
 	return &imageBits{
 		srcs:       args,
 		namePrefix: namePrefix,
+		arch:       arch,
 	}
 }
 
@@ -61,6 +64,7 @@ func (b *imageBits) Prepare(c *BuildContext) (map[string]string, error) {
 			src, dst,
 			extract.OnlyKubernetesImages(true),
 			extract.WithNamePrefix(b.namePrefix),
+			extract.WithArch(b.arch),
 		)
 
 		// if the source is a local repository
@@ -34,15 +34,18 @@ const (
 // initBits defines a bit installer that allows to add Kubernetes binaries & images to the node image;
 // those artifact will be used by the kinder do kubeadm-init script
 type initBits struct {
-	src string
+	src  string
+	arch string
 }
 
 var _ Installer = &initBits{}
 
-// NewInitBits returns a new initBits
-func NewInitBits(arg string) Installer {
+// NewInitBits returns a new initBits; arch is the architecture of the artifacts
+// to be retrieved from release or ci builds
+func NewInitBits(arg, arch string) Installer {
 	return &initBits{
-		src: arg,
+		src:  arg,
+		arch: arch,
 	}
 }
 
@@ -59,6 +62,7 @@ func (b *initBits) Prepare(c *BuildContext) (map[string]string, error) {
 	// and save it to the dst folder
 	e := extract.NewExtractor(
 		b.src, dst,
+		extract.WithArch(b.arch),
 	)
 
 	// Extracts the binaries & images
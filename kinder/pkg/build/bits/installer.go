@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // Installer interface defines the behaviour of a type in charge of installing a specific set of bits (files/artifacts)
@@ -74,7 +75,7 @@ func (c *BuildContext) BindToContainer(containerID string) {
 // RunInContainer executes a command on the container used for altering the image
 func (c *BuildContext) RunInContainer(command string, args ...string) error {
 	cmd := exec.NewHostCmd(
-		"docker",
+		provider.Command(),
 		append(
 			[]string{"exec", c.containerID, command},
 			args...,
@@ -86,7 +87,7 @@ func (c *BuildContext) RunInContainer(command string, args ...string) error {
 // CombinedOutputLinesInContainer executes a command on the container used for altering the image and returns CombinedOutputLines
 func (c *BuildContext) CombinedOutputLinesInContainer(command string, args ...string) ([]string, error) {
 	cmd := exec.NewHostCmd(
-		"docker",
+		provider.Command(),
 		append(
 			[]string{"exec", c.containerID, command},
 			args...,
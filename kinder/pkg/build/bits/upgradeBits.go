@@ -29,15 +29,18 @@ import (
 // upgradeBits defines a bit installer that allows to add Kubernetes binaries & images to the /kinder/upgrade folder into the node image;
 // those artifact will be used by the kinder do kubeadm-upgrade script
 type upgradeBits struct {
-	src string
+	src  string
+	arch string
 }
 
 var _ Installer = &upgradeBits{}
 
-// NewUpgradeBits returns a new upgradeBits
-func NewUpgradeBits(arg string) Installer {
+// NewUpgradeBits returns a new upgradeBits; arch is the architecture of the artifacts
+// to be retrieved from release or ci builds
+func NewUpgradeBits(arg, arch string) Installer {
 	return &upgradeBits{
-		src: arg,
+		src:  arg,
+		arch: arch,
 	}
 }
 
@@ -55,6 +58,7 @@ func (b *upgradeBits) Prepare(c *BuildContext) (map[string]string, error) {
 	e := extract.NewExtractor(
 		b.src, dst,
 		extract.WithVersionFolder(true),
+		extract.WithArch(b.arch),
 	)
 
 	// Extracts the binary bit
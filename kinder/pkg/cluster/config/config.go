@@ -0,0 +1,268 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package config implements the kinder cluster config file, that allows to describe
+the topology of a kinder cluster declaratively, node by node.
+
+A config file looks like:
+
+	kind: Cluster
+	apiVersion: kinder.k8s.io/v1alpha1
+	image: kindest/node:v1.31.0
+	externalEtcd: false
+	externalLoadBalancer: true
+	nodes:
+	- role: control-plane
+	- role: worker
+	  extraMounts:
+	  - hostPath: /tmp/data
+	    containerPath: /data
+	  extraPortMappings:
+	  - containerPort: 30080
+	    hostPort: 8080
+	  labels:
+	    foo: bar
+	  env:
+	    FOO: bar
+	  resources:
+	    cpus: "2"
+	    memory: 2g
+*/
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+const (
+	// Kind is the kind of the kinder cluster config
+	Kind = "Cluster"
+
+	// APIVersion is the version of the kinder cluster config
+	APIVersion = "kinder.k8s.io/v1alpha1"
+)
+
+// Cluster defines the topology of a kinder cluster
+type Cluster struct {
+	// Kind of the config; it must be Cluster
+	Kind string `json:"kind"`
+
+	// APIVersion of the config; it must be kinder.k8s.io/v1alpha1
+	APIVersion string `json:"apiVersion"`
+
+	// Image is the default node image for nodes without an explicit image
+	Image string `json:"image,omitempty"`
+
+	// ExternalEtcd instructs kinder to add an external etcd to the cluster
+	ExternalEtcd bool `json:"externalEtcd,omitempty"`
+
+	// ExternalLoadBalancer instructs kinder to add an external load balancer to the cluster.
+	// NB. this happens automatically when there are more than one control-plane node
+	ExternalLoadBalancer bool `json:"externalLoadBalancer,omitempty"`
+
+	// Nodes defines the list of control-plane and worker nodes in the cluster
+	Nodes []Node `json:"nodes"`
+}
+
+// Node defines a control-plane or a worker node in a kinder cluster
+type Node struct {
+	// Role of the node; it must be control-plane or worker
+	Role string `json:"role"`
+
+	// Image is the node image for the node; if not set, the cluster image is used
+	Image string `json:"image,omitempty"`
+
+	// ExtraMounts defines additional mounts for the node container
+	ExtraMounts []Mount `json:"extraMounts,omitempty"`
+
+	// ExtraPortMappings defines additional ports to be exposed by the node container
+	ExtraPortMappings []PortMapping `json:"extraPortMappings,omitempty"`
+
+	// Labels defines additional labels for the node container
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Env defines additional environment variables for the node container
+	Env map[string]string `json:"env,omitempty"`
+
+	// Resources defines resource limits for the node container
+	Resources *Resources `json:"resources,omitempty"`
+}
+
+// Mount defines a mount from the host into a node container
+type Mount struct {
+	// HostPath is the path on the host; relative paths are resolved from the current folder
+	HostPath string `json:"hostPath"`
+
+	// ContainerPath is the path in the node container
+	ContainerPath string `json:"containerPath"`
+
+	// ReadOnly instructs to mount the path in read only mode
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// PortMapping defines a port exposed by a node container on the host
+type PortMapping struct {
+	// ContainerPort is the port in the node container
+	ContainerPort int32 `json:"containerPort"`
+
+	// HostPort is the port on the host; if not set, a random port is used
+	HostPort int32 `json:"hostPort,omitempty"`
+
+	// ListenAddress is the address on the host; if not set, all the addresses are used
+	ListenAddress string `json:"listenAddress,omitempty"`
+
+	// Protocol is one of TCP, UDP, SCTP; if not set, TCP is used
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// Resources defines resource limits for a node container
+type Resources struct {
+	// CPUs limits the number of CPUs for the node container, e.g. "1.5"
+	CPUs string `json:"cpus,omitempty"`
+
+	// Memory limits the memory for the node container, e.g. "2g"
+	Memory string `json:"memory,omitempty"`
+}
+
+// Load reads a kinder cluster config file, sets defaults and validates it
+func Load(file string) (*Cluster, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading cluster config file %s", file)
+	}
+
+	var c Cluster
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, errors.Wrapf(err, "error unmarshalling cluster config file %s", file)
+	}
+
+	if err := c.setDefaults(); err != nil {
+		return nil, errors.Wrapf(err, "invalid cluster config file %s", file)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid cluster config file %s", file)
+	}
+
+	return &c, nil
+}
+
+// setDefaults sets default values for the optional fields in the config
+func (c *Cluster) setDefaults() error {
+	for i := range c.Nodes {
+		n := &c.Nodes[i]
+		for j := range n.ExtraMounts {
+			if n.ExtraMounts[j].HostPath == "" {
+				continue
+			}
+			hostPath, err := filepath.Abs(n.ExtraMounts[j].HostPath)
+			if err != nil {
+				return errors.Wrapf(err, "failed to resolve the host path %s", n.ExtraMounts[j].HostPath)
+			}
+			n.ExtraMounts[j].HostPath = hostPath
+		}
+		for j := range n.ExtraPortMappings {
+			if n.ExtraPortMappings[j].Protocol == "" {
+				n.ExtraPortMappings[j].Protocol = "TCP"
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the config is consistent
+func (c *Cluster) Validate() error {
+	if c.Kind != Kind || c.APIVersion != APIVersion {
+		return errors.Errorf("kind and apiVersion must be %s and %s", Kind, APIVersion)
+	}
+
+	controlPlanes := 0
+	for i, n := range c.Nodes {
+		switch n.Role {
+		case constants.ControlPlaneNodeRoleValue:
+			controlPlanes++
+		case constants.WorkerNodeRoleValue:
+		default:
+			return errors.Errorf("nodes[%d]: invalid role %q. Use one of [%s, %s]", i, n.Role, constants.ControlPlaneNodeRoleValue, constants.WorkerNodeRoleValue)
+		}
+
+		for j, m := range n.ExtraMounts {
+			if m.HostPath == "" || m.ContainerPath == "" {
+				return errors.Errorf("nodes[%d].extraMounts[%d]: hostPath and containerPath must be set", i, j)
+			}
+		}
+
+		for k := range n.Labels {
+			if isReservedLabel(k) {
+				return errors.Errorf("nodes[%d].labels: %q is reserved for kinder", i, k)
+			}
+		}
+
+		for j, p := range n.ExtraPortMappings {
+			if p.ContainerPort <= 0 || p.HostPort < 0 {
+				return errors.Errorf("nodes[%d].extraPortMappings[%d]: invalid port", i, j)
+			}
+			switch p.Protocol {
+			case "TCP", "UDP", "SCTP":
+			default:
+				return errors.Errorf("nodes[%d].extraPortMappings[%d]: invalid protocol %q. Use one of [TCP, UDP, SCTP]", i, j, p.Protocol)
+			}
+		}
+	}
+
+	if controlPlanes == 0 {
+		return errors.Errorf("please add at least one node with role %q", constants.ControlPlaneNodeRoleValue)
+	}
+
+	return nil
+}
+
+// isReservedLabel returns true for the labels used by kinder for identifying the cluster and the role of node containers
+func isReservedLabel(key string) bool {
+	switch key {
+	case constants.ClusterLabelKey, constants.DeprecatedClusterLabelKey, constants.NodeRoleLabelKey, constants.DeprecatedNodeRoleLabelKey:
+		return true
+	}
+	return false
+}
+
+// ControlPlanes returns the number of control-plane nodes in the config
+func (c *Cluster) ControlPlanes() int {
+	return c.countNodes(constants.ControlPlaneNodeRoleValue)
+}
+
+// Workers returns the number of worker nodes in the config
+func (c *Cluster) Workers() int {
+	return c.countNodes(constants.WorkerNodeRoleValue)
+}
+
+func (c *Cluster) countNodes(role string) int {
+	count := 0
+	for _, n := range c.Nodes {
+		if n.Role == role {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		expectedConfig *Cluster
+		expectedError  bool
+	}{
+		{
+			name: "valid: minimal config",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1alpha1
+nodes:
+- role: control-plane
+`,
+			expectedConfig: &Cluster{
+				Kind:       Kind,
+				APIVersion: APIVersion,
+				Nodes: []Node{
+					{Role: "control-plane"},
+				},
+			},
+		},
+		{
+			name: "valid: full config with defaults",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1alpha1
+image: kindest/node:test
+externalEtcd: true
+externalLoadBalancer: true
+nodes:
+- role: control-plane
+- role: worker
+  image: kindest/node:worker
+  extraMounts:
+  - hostPath: /tmp/data
+    containerPath: /data
+    readOnly: true
+  extraPortMappings:
+  - containerPort: 30080
+    hostPort: 8080
+  labels:
+    foo: bar
+  env:
+    FOO: bar
+  resources:
+    cpus: "2"
+    memory: 2g
+`,
+			expectedConfig: &Cluster{
+				Kind:                 Kind,
+				APIVersion:           APIVersion,
+				Image:                "kindest/node:test",
+				ExternalEtcd:         true,
+				ExternalLoadBalancer: true,
+				Nodes: []Node{
+					{Role: "control-plane"},
+					{
+						Role:              "worker",
+						Image:             "kindest/node:worker",
+						ExtraMounts:       []Mount{{HostPath: "/tmp/data", ContainerPath: "/data", ReadOnly: true}},
+						ExtraPortMappings: []PortMapping{{ContainerPort: 30080, HostPort: 8080, Protocol: "TCP"}},
+						Labels:            map[string]string{"foo": "bar"},
+						Env:               map[string]string{"FOO": "bar"},
+						Resources:         &Resources{CPUs: "2", Memory: "2g"},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid: unknown apiVersion",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1
+nodes:
+- role: control-plane
+`,
+			expectedError: true,
+		},
+		{
+			name: "invalid: unknown field",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1alpha1
+nodes:
+- role: control-plane
+  foo: bar
+`,
+			expectedError: true,
+		},
+		{
+			name: "invalid: no control-plane nodes",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1alpha1
+nodes:
+- role: worker
+`,
+			expectedError: true,
+		},
+		{
+			name: "invalid: unknown role",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1alpha1
+nodes:
+- role: control-plane
+- role: external-etcd
+`,
+			expectedError: true,
+		},
+		{
+			name: "invalid: unknown protocol",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1alpha1
+nodes:
+- role: control-plane
+  extraPortMappings:
+  - containerPort: 80
+    protocol: HTTP
+`,
+			expectedError: true,
+		},
+		{
+			name: "invalid: reserved cluster label",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1alpha1
+nodes:
+- role: control-plane
+  labels:
+    io.x-k8s.kind.cluster: other
+`,
+			expectedError: true,
+		},
+		{
+			name: "invalid: reserved role label",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1alpha1
+nodes:
+- role: worker
+  labels:
+    io.x-k8s.kind.role: control-plane
+- role: control-plane
+`,
+			expectedError: true,
+		},
+		{
+			name: "invalid: reserved deprecated role label",
+			input: `kind: Cluster
+apiVersion: kinder.k8s.io/v1alpha1
+nodes:
+- role: control-plane
+  labels:
+    io.k8s.sigs.kind.role: worker
+`,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(file, []byte(test.input), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := Load(file)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error %v, got %v, error: %v", test.expectedError, err != nil, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(config, test.expectedConfig) {
+				t.Errorf("expected config %+v, got %+v", test.expectedConfig, config)
+			}
+		})
+	}
+}
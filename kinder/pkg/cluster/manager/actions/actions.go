@@ -49,10 +49,16 @@ var actionRegistry = map[string]func(*status.Cluster, *RunOptions) error{
 		return KubeadmJoin(c, flags.usePhases, flags.copyCertsMode, flags.discoveryMode, flags.kubeadmConfigVersion, flags.patchesDir, flags.ignorePreflightErrors, flags.wait, flags.vLevel)
 	},
 	"kubeadm-upgrade": func(c *status.Cluster, flags *RunOptions) error {
-		return KubeadmUpgrade(c, flags.upgradeVersion, flags.patchesDir, flags.ignorePreflightErrors, flags.wait, flags.vLevel)
+		return KubeadmUpgrade(c, flags.usePhases, flags.upgradeVersion, flags.patchesDir, flags.ignorePreflightErrors, flags.injectUpgradeFailure, flags.drain, flags.drainTimeout, flags.wait, flags.vLevel)
+	},
+	"kubeadm-certs-renew": func(c *status.Cluster, flags *RunOptions) error {
+		return KubeadmCertsRenew(c, flags.certificate, flags.clockOffset, flags.wait, flags.vLevel)
 	},
 	"kubeadm-reset": func(c *status.Cluster, flags *RunOptions) error {
-		return KubeadmReset(c, flags.vLevel)
+		return KubeadmReset(c, flags.usePhases, flags.vLevel)
+	},
+	"kubeadm-remove-node": func(c *status.Cluster, flags *RunOptions) error {
+		return KubeadmRemoveNode(c, flags.drainTimeout, flags.vLevel)
 	},
 	"copy-certs": func(c *status.Cluster, flags *RunOptions) error {
 		return CopyCertificates(c)
@@ -60,6 +66,9 @@ var actionRegistry = map[string]func(*status.Cluster, *RunOptions) error{
 	"setup-external-ca": func(c *status.Cluster, flags *RunOptions) error {
 		return SetupExternalCA(c, flags.vLevel)
 	},
+	"rotate-ca": func(c *status.Cluster, flags *RunOptions) error {
+		return RotateCA(c, flags.wait, flags.vLevel)
+	},
 	"cluster-info": func(c *status.Cluster, flags *RunOptions) error {
 		return CluterInfo(c)
 	},
@@ -167,6 +176,42 @@ func EncryptionAlgorithm(algorithm string) Option {
 	}
 }
 
+// Certificate option sets the certificate to be renewed by kubeadm certs renew
+func Certificate(certificate string) Option {
+	return func(r *RunOptions) {
+		r.certificate = certificate
+	}
+}
+
+// ClockOffset option sets the offset for simulating a node clock moved forward, e.g. for renewing near-expiry certificates
+func ClockOffset(clockOffset time.Duration) Option {
+	return func(r *RunOptions) {
+		r.clockOffset = clockOffset
+	}
+}
+
+// InjectUpgradeFailure option instructs kubeadm upgrade to inject a failure on control-plane nodes, for testing
+// the kubeadm rollback before executing the actual upgrade
+func InjectUpgradeFailure(injectUpgradeFailure bool) Option {
+	return func(r *RunOptions) {
+		r.injectUpgradeFailure = injectUpgradeFailure
+	}
+}
+
+// Drain option instructs kubeadm upgrade to cordon and drain nodes before upgrading them, and to uncordon them after
+func Drain(drain bool) Option {
+	return func(r *RunOptions) {
+		r.drain = drain
+	}
+}
+
+// DrainTimeout option sets the timeout for draining nodes
+func DrainTimeout(drainTimeout time.Duration) Option {
+	return func(r *RunOptions) {
+		r.drainTimeout = drainTimeout
+	}
+}
+
 // RunOptions holds options supplied to actions.Run
 type RunOptions struct {
 	usePhases             bool
@@ -180,6 +225,11 @@ type RunOptions struct {
 	kubeadmConfigVersion  string
 	featureGate           string
 	encryptionAlgorithm   string
+	certificate           string
+	clockOffset           time.Duration
+	drain                 bool
+	drainTimeout          time.Duration
+	injectUpgradeFailure  bool
 }
 
 // DiscoveryMode defines discovery mode supported by kubeadm join
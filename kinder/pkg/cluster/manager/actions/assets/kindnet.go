@@ -16,13 +16,60 @@ limitations under the License.
 
 package assets
 
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
 // KindnetImage054 is the image for kindnet 0.5.4
 const KindnetImage054 = "kindest/kindnetd:0.5.4"
 
-// KindnetManifest054 holds the kindnet manifest for 0.5.4
-const KindnetManifest054 = `
+// KindnetImageDualStack is the image for kindnet used in dual-stack clusters,
+// because kindnet 0.5.4 supports only one pod subnet.
+// NB. this is the kindnet image shipped with kind v0.27.0, and it must be used with the corresponding manifest
+const KindnetImageDualStack = "docker.io/kindest/kindnetd:v20250214-acbabc1a"
+
+// KindnetConfigData is supplied to the kindnet manifest template
+type KindnetConfigData struct {
+	// Image is the kindnet image; it must be one of KindnetImage054 or KindnetImageDualStack
+	Image string
+	// PodSubnet is the pod subnet; dual-stack clusters have a comma separated IPv4 and IPv6 subnet
+	PodSubnet string
+}
+
+// kindnetManifestTemplates maps each supported kindnet image to the manifest template compatible with it
+var kindnetManifestTemplates = map[string]string{
+	KindnetImage054:       kindnetManifest054Template,
+	KindnetImageDualStack: kindnetManifestDualStackTemplate,
+}
+
+// KindnetManifest returns the kindnet manifest generated from config data
+func KindnetManifest(data *KindnetConfigData) (string, error) {
+	manifest, ok := kindnetManifestTemplates[data.Image]
+	if !ok {
+		return "", errors.Errorf("no kindnet manifest compatible with image %s", data.Image)
+	}
+	if data.Image == KindnetImage054 && strings.Contains(data.PodSubnet, ",") {
+		return "", errors.Errorf("kindnet image %s does not support multiple pod subnets %s", data.Image, data.PodSubnet)
+	}
+
+	t, err := template.New("kindnet-manifest").Parse(manifest)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse kindnet manifest template")
+	}
+	var buff bytes.Buffer
+	if err := t.Execute(&buff, data); err != nil {
+		return "", errors.Wrap(err, "error executing kindnet manifest template")
+	}
+	return buff.String(), nil
+}
+
+// kindnetManifest054Template holds the kindnet manifest template for 0.5.4
+const kindnetManifest054Template = `
 # kindnetd networking manifest
-# would you kindly template this file
 ---
 kind: ClusterRole
 apiVersion: rbac.authorization.k8s.io/v1
@@ -102,7 +149,134 @@ spec:
                 fieldRef:
                   fieldPath: status.podIP
             - name: POD_SUBNET
-              value: "192.168.0.0/16"
+              value: "{{ .PodSubnet }}"
+          volumeMounts:
+            - name: cni-cfg
+              mountPath: /etc/cni/net.d
+            - name: xtables-lock
+              mountPath: /run/xtables.lock
+              readOnly: false
+            - name: lib-modules
+              mountPath: /lib/modules
+              readOnly: true
+          resources:
+            requests:
+              cpu: "100m"
+              memory: "50Mi"
+            limits:
+              cpu: "100m"
+              memory: "50Mi"
+          securityContext:
+            privileged: false
+            capabilities:
+              add: ["NET_RAW", "NET_ADMIN"]
+      volumes:
+        - name: cni-cfg
+          hostPath:
+            path: /etc/cni/net.d
+        - name: xtables-lock
+          hostPath:
+            path: /run/xtables.lock
+            type: FileOrCreate
+        - name: lib-modules
+          hostPath:
+            path: /lib/modules
+`
+
+// kindnetManifestDualStackTemplate holds the kindnet manifest template for KindnetImageDualStack;
+// this is the manifest shipped with kind v0.27.0
+const kindnetManifestDualStackTemplate = `
+# kindnetd networking manifest
+---
+kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: kindnet
+rules:
+  - apiGroups:
+      - policy
+    resources:
+      - podsecuritypolicies
+    verbs:
+      - use
+    resourceNames:
+      - kindnet
+  - apiGroups:
+      - ""
+    resources:
+      - nodes
+      - pods
+      - namespaces
+    verbs:
+      - list
+      - watch
+  - apiGroups:
+      - "networking.k8s.io"
+    resources:
+      - networkpolicies
+    verbs:
+      - list
+      - watch
+---
+kind: ClusterRoleBinding
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: kindnet
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: kindnet
+subjects:
+  - kind: ServiceAccount
+    name: kindnet
+    namespace: kube-system
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kindnet
+  namespace: kube-system
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: kindnet
+  namespace: kube-system
+  labels:
+    tier: node
+    app: kindnet
+    k8s-app: kindnet
+spec:
+  selector:
+    matchLabels:
+      app: kindnet
+  template:
+    metadata:
+      labels:
+        tier: node
+        app: kindnet
+        k8s-app: kindnet
+    spec:
+      hostNetwork: true
+      nodeSelector:
+        kubernetes.io/os: linux
+      tolerations:
+        - operator: Exists
+      serviceAccountName: kindnet
+      containers:
+        - name: kindnet-cni
+          image: {{ .Image }}
+          env:
+            - name: HOST_IP
+              valueFrom:
+                fieldRef:
+                  fieldPath: status.hostIP
+            - name: POD_IP
+              valueFrom:
+                fieldRef:
+                  fieldPath: status.podIP
+            - name: POD_SUBNET
+              value: "{{ .PodSubnet }}"
           volumeMounts:
             - name: cni-cfg
               mountPath: /etc/cni/net.d
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package assets
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKindnetManifest(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             *KindnetConfigData
+		expectedContains []string
+		expectedError    bool
+	}{
+		{
+			name: "kindnet 0.5.4",
+			data: &KindnetConfigData{Image: KindnetImage054, PodSubnet: "192.168.0.0/16"},
+			expectedContains: []string{
+				"image: kindest/kindnetd:0.5.4",
+				`value: "192.168.0.0/16"`,
+			},
+		},
+		{
+			name: "dual-stack kindnet",
+			data: &KindnetConfigData{Image: KindnetImageDualStack, PodSubnet: "192.168.0.0/16,fd00:10:244::/56"},
+			expectedContains: []string{
+				"image: " + KindnetImageDualStack,
+				`value: "192.168.0.0/16,fd00:10:244::/56"`,
+				"networkpolicies",
+			},
+		},
+		{
+			name:          "kindnet 0.5.4 with multiple pod subnets",
+			data:          &KindnetConfigData{Image: KindnetImage054, PodSubnet: "192.168.0.0/16,fd00:10:244::/56"},
+			expectedError: true,
+		},
+		{
+			name:          "image without a compatible manifest",
+			data:          &KindnetConfigData{Image: "docker.io/kindest/kindnetd:v20230511-dc714da8", PodSubnet: "192.168.0.0/16"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest, err := KindnetManifest(test.data)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error %t, got %v", test.expectedError, err)
+			}
+			for _, s := range test.expectedContains {
+				if !strings.Contains(manifest, s) {
+					t.Errorf("expected manifest to contain %q", s)
+				}
+			}
+		})
+	}
+}
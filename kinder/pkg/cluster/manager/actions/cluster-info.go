@@ -58,46 +58,10 @@ func CluterInfo(c *status.Cluster) error {
 	fmt.Println()
 
 	if c.ExternalEtcd() == nil {
-		// NB. before v1.13 local etcd is listening on localhost only; after v1.13
-		// local etcd is listening on localhost and on the advertise address; we are
-		// using localhost to accommodate both the use cases
-
-		etcdArgs := []string{
-			"--kubeconfig=/etc/kubernetes/admin.conf", "exec", "-n=kube-system", fmt.Sprintf("etcd-%s", c.BootstrapControlPlane().Name()),
-			"--",
-		}
-
-		var lines []string
-		var err error
-
-		// Get the version of etcdctl from the etcd binary
-		// Retry the version command for a while to avoid "exec" flakes
-		versionArgs := append(etcdArgs, "etcd", "--version")
-		versionArgs = append([]string{"--request-timeout=2"}, versionArgs...) // Ensure shorter timeout
-		for i := 0; i < 10; i++ {
-			lines, err = cp1.Command("kubectl", versionArgs...).RunAndCapture()
-			if err == nil {
-				break
-			}
-			cp1.Infof("Could not execute 'etcd --version' inside %q (attempt %d/%d): %v\n", cp1.Name(), i+1, 10,
-				errors.Wrap(err, strings.Join(lines, "\n")))
-		}
-		if err != nil {
-			return err
-		}
-
-		etcdctlVersion, err := parseEtcdctlVersion(lines)
+		etcdArgs, err := etcdctlArgs(c)
 		if err != nil {
 			return err
 		}
-
-		cp1.Infof("Using etcdctl version: %s\n", etcdctlVersion)
-		etcdArgs = append(etcdArgs, "etcdctl", "--endpoints=https://127.0.0.1:2379")
-
-		// Append version specific etcdctl certificate flags
-		if err := appendEtcdctlCertArgs(etcdctlVersion, &etcdArgs); err != nil {
-			return err
-		}
 		etcdArgs = append(etcdArgs, "member", "list")
 
 		if err := cp1.Command(
@@ -112,6 +76,62 @@ func CluterInfo(c *status.Cluster) error {
 	return nil
 }
 
+// dryRunEtcdctlVersion is the etcdctl version assumed when running etcdctlArgs in dry run
+const dryRunEtcdctlVersion = "3.5.0"
+
+// etcdctlArgs returns the kubectl args for running etcdctl into the etcd pod of the bootstrap
+// control-plane node, including the version specific etcdctl certificate flags
+func etcdctlArgs(c *status.Cluster) ([]string, error) {
+	cp1 := c.BootstrapControlPlane()
+
+	// NB. before v1.13 local etcd is listening on localhost only; after v1.13
+	// local etcd is listening on localhost and on the advertise address; we are
+	// using localhost to accommodate both the use cases
+
+	etcdArgs := []string{
+		"--kubeconfig=/etc/kubernetes/admin.conf", "exec", "-n=kube-system", fmt.Sprintf("etcd-%s", cp1.Name()),
+		"--",
+	}
+
+	var lines []string
+	var err error
+
+	// Get the version of etcdctl from the etcd binary
+	// Retry the version command for a while to avoid "exec" flakes
+	versionArgs := append(etcdArgs, "etcd", "--version")
+	versionArgs = append([]string{"--request-timeout=2"}, versionArgs...) // Ensure shorter timeout
+	for i := 0; i < 10; i++ {
+		lines, err = cp1.Command("kubectl", versionArgs...).RunAndCapture()
+		if err == nil {
+			break
+		}
+		cp1.Infof("Could not execute 'etcd --version' inside %q (attempt %d/%d): %v\n", cp1.Name(), i+1, 10,
+			errors.Wrap(err, strings.Join(lines, "\n")))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// under dry run the etcd version is unknown, so the etcdctl flags for the current etcd releases are used
+	etcdctlVersion := dryRunEtcdctlVersion
+	if !cp1.IsDryRun() {
+		etcdctlVersion, err = parseEtcdctlVersion(lines)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cp1.Infof("Using etcdctl version: %s\n", etcdctlVersion)
+	etcdArgs = append(etcdArgs, "etcdctl", "--endpoints=https://127.0.0.1:2379")
+
+	// Append version specific etcdctl certificate flags
+	if err := appendEtcdctlCertArgs(etcdctlVersion, &etcdArgs); err != nil {
+		return nil, err
+	}
+
+	return etcdArgs, nil
+}
+
 // parseEtcdctlVersion takes the output lines of 'etcdctl version' and returns the version
 func parseEtcdctlVersion(lines []string) (string, error) {
 	if len(lines) < 1 {
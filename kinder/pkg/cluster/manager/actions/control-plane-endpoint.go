@@ -0,0 +1,179 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes"
+)
+
+// ensureControlPlaneEndpoint ensures the control-plane endpoint of the cluster is the external load balancer.
+// This is not the case when the external load balancer was added to a cluster initialized with a single
+// control-plane node; in this case the kubeadm-config and the cluster-info ConfigMaps are updated, and the
+// API server serving certificate on the bootstrap control-plane is regenerated for the new endpoint.
+func ensureControlPlaneEndpoint(c *status.Cluster, wait time.Duration, vLevel int) error {
+	if c.ExternalLoadBalancer() == nil {
+		return nil
+	}
+
+	cp1 := c.BootstrapControlPlane()
+
+	lines, err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf",
+		"get", "configmap", "kubeadm-config", "-n=kube-system",
+		"-o=jsonpath={.data.ClusterConfiguration}",
+	).Silent().RunAndCapture()
+	if err != nil {
+		return errors.Wrap(err, "failed to read the kubeadm-config ConfigMap")
+	}
+
+	// if the current endpoint is unknown (e.g. dry run), there is nothing to do
+	current := parseControlPlaneEndpoint(lines)
+	if current == "" {
+		return nil
+	}
+
+	endpoint, endpointIPv6, port, err := getControlPlaneAddress(c)
+	if err != nil {
+		return err
+	}
+	if c.Settings.IPFamily == status.IPv6Family {
+		endpoint = endpointIPv6
+	}
+	controlPlaneEndpoint := net.JoinHostPort(endpoint, strconv.Itoa(port))
+
+	if current == controlPlaneEndpoint {
+		return nil
+	}
+
+	cp1.Infof("Updating the control-plane endpoint from %s to %s", current, controlPlaneEndpoint)
+
+	// updates the control-plane endpoint in the kubeadm ClusterConfiguration, used by the joining nodes
+	if err := cp1.Command(
+		"/bin/sh", "-c",
+		fmt.Sprintf("kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap kubeadm-config -n=kube-system -o=yaml | "+
+			"sed 's#controlPlaneEndpoint: .*#controlPlaneEndpoint: %s#' | "+
+			"kubectl --kubeconfig=/etc/kubernetes/admin.conf replace -f -", controlPlaneEndpoint),
+	).RunWithEcho(); err != nil {
+		return errors.Wrap(err, "failed to update the kubeadm-config ConfigMap")
+	}
+
+	// updates the server address in the cluster-info ConfigMap, used for discovery
+	// NB. the bootstrap signer controller takes care of signing again the updated kubeconfig
+	if err := cp1.Command(
+		"/bin/sh", "-c",
+		fmt.Sprintf("kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap cluster-info -n=kube-public -o=yaml | "+
+			"sed 's#server: https://.*#server: https://%s#' | "+
+			"kubectl --kubeconfig=/etc/kubernetes/admin.conf replace -f -", controlPlaneEndpoint),
+	).RunWithEcho(); err != nil {
+		return errors.Wrap(err, "failed to update the cluster-info ConfigMap")
+	}
+
+	// regenerates the API server serving certificate, so it is valid for the new endpoint
+	if err := cp1.Command(
+		"rm", "-f", "/etc/kubernetes/pki/apiserver.crt", "/etc/kubernetes/pki/apiserver.key",
+	).RunWithEcho(); err != nil {
+		return errors.Wrap(err, "failed to remove the API server certificate")
+	}
+
+	// updates the control-plane endpoint in the kubeadm config file used for init, so the certificate
+	// is generated with the same settings used by kubeadm-init, including certSANs
+	if err := cp1.Command(
+		"sed", "-i", fmt.Sprintf(`s#controlPlaneEndpoint: .*#controlPlaneEndpoint: "%s"#`, controlPlaneEndpoint), constants.KubeadmConfigPath,
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to update %s", constants.KubeadmConfigPath)
+	}
+
+	if err := cp1.Command(
+		"kubeadm", "init", "phase", "certs", "apiserver",
+		fmt.Sprintf("--config=%s", constants.KubeadmConfigPath),
+		fmt.Sprintf("--v=%d", vLevel),
+	).RunWithEcho(); err != nil {
+		return errors.Wrap(err, "failed to generate the API server certificate")
+	}
+
+	// restarts the API server, so the new certificate is used
+	nodeCRI, err := cp1.CRI()
+	if err != nil {
+		return err
+	}
+	actionHelper, err := nodes.NewActionHelper(nodeCRI)
+	if err != nil {
+		return err
+	}
+	if err := actionHelper.StopContainers(cp1, "kube-apiserver"); err != nil {
+		return errors.Wrap(err, "failed to restart the API server")
+	}
+
+	if err := waitNewControlPlaneNodeReady(c, cp1, wait); err != nil {
+		return err
+	}
+
+	// updates the kubeconfig file on the host, so it uses the load balancer
+	return copyKubeConfigToHost(c)
+}
+
+// parseControlPlaneEndpoint returns the controlPlaneEndpoint value from a ClusterConfiguration
+func parseControlPlaneEndpoint(lines []string) string {
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if !strings.HasPrefix(l, "controlPlaneEndpoint:") {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(strings.TrimPrefix(l, "controlPlaneEndpoint:")), `"`)
+	}
+	return ""
+}
+
+// uploadCertsIfMissing uploads again the control-plane certificates using the well known certificate key, if the
+// kubeadm-certs Secret created at init time does not exist anymore (kubeadm deletes it after two hours).
+func uploadCertsIfMissing(c *status.Cluster, kubeadmConfigVersion, ignorePreflightErrors string, vLevel int) error {
+	cp1 := c.BootstrapControlPlane()
+
+	if err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf",
+		"get", "secret", "kubeadm-certs", "-n=kube-system",
+	).Silent().Run(); err == nil {
+		return nil
+	}
+
+	cp1.Infof("kubeadm-certs Secret not found, uploading the control-plane certificates again")
+
+	// prepares the kubeadm config on the bootstrap control-plane, including the certificate key
+	if err := KubeadmInitConfig(c, kubeadmConfigVersion, CopyCertsModeAuto, "", "", ignorePreflightErrors, cp1); err != nil {
+		return err
+	}
+
+	if err := cp1.Command(
+		"kubeadm", "init", "phase", "upload-certs", "--upload-certs",
+		fmt.Sprintf("--config=%s", constants.KubeadmConfigPath),
+		fmt.Sprintf("--v=%d", vLevel),
+	).RunWithEcho(); err != nil {
+		return errors.Wrap(err, "failed to upload the control-plane certificates")
+	}
+
+	return nil
+}
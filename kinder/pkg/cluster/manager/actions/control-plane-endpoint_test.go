@@ -0,0 +1,178 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+func TestParseControlPlaneEndpoint(t *testing.T) {
+	tests := []struct {
+		name             string
+		inputLines       []string
+		expectedEndpoint string
+	}{
+		{
+			name: "valid: endpoint",
+			inputLines: []string{
+				"apiVersion: kubeadm.k8s.io/v1beta4",
+				"controlPlaneEndpoint: 172.17.0.2:6443",
+				"kind: ClusterConfiguration",
+			},
+			expectedEndpoint: "172.17.0.2:6443",
+		},
+		{
+			name: "valid: quoted endpoint",
+			inputLines: []string{
+				`  controlPlaneEndpoint: "172.17.0.5:6443"`,
+			},
+			expectedEndpoint: "172.17.0.5:6443",
+		},
+		{
+			name: "valid: no endpoint",
+			inputLines: []string{
+				"apiVersion: kubeadm.k8s.io/v1beta4",
+				"kind: ClusterConfiguration",
+			},
+			expectedEndpoint: "",
+		},
+		{
+			name:             "valid: no lines",
+			expectedEndpoint: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			endpoint := parseControlPlaneEndpoint(test.inputLines)
+			if endpoint != test.expectedEndpoint {
+				t.Errorf("expected endpoint %q, got %q", test.expectedEndpoint, endpoint)
+			}
+		})
+	}
+}
+
+func TestEnsureControlPlaneEndpoint(t *testing.T) {
+	updateEndpoint := func(stopAPIServer string) []string {
+		return []string{
+			"kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap kubeadm-config -n=kube-system -o=jsonpath={.data.ClusterConfiguration}",
+			"/bin/sh -c kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap kubeadm-config -n=kube-system -o=yaml | " +
+				"sed 's#controlPlaneEndpoint: .*#controlPlaneEndpoint: 172.17.0.9:6443#' | " +
+				"kubectl --kubeconfig=/etc/kubernetes/admin.conf replace -f -",
+			"/bin/sh -c kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap cluster-info -n=kube-public -o=yaml | " +
+				"sed 's#server: https://.*#server: https://172.17.0.9:6443#' | " +
+				"kubectl --kubeconfig=/etc/kubernetes/admin.conf replace -f -",
+			"rm -f /etc/kubernetes/pki/apiserver.crt /etc/kubernetes/pki/apiserver.key",
+			`sed -i s#controlPlaneEndpoint: .*#controlPlaneEndpoint: "172.17.0.9:6443"# /kind/kubeadm.conf`,
+			"kubeadm init phase certs apiserver --config=/kind/kubeadm.conf --v=1",
+			stopAPIServer,
+		}
+	}
+
+	tests := []struct {
+		name               string
+		withLoadBalancer   bool
+		currentEndpoint    []string
+		detectedCRI        string
+		expectedCommands   []string
+		expectedKubeconfig bool
+	}{
+		{
+			name:             "no external load balancer",
+			currentEndpoint:  []string{"controlPlaneEndpoint: 172.17.0.2:6443"},
+			expectedCommands: nil,
+		},
+		{
+			name:             "endpoint already set to the external load balancer",
+			withLoadBalancer: true,
+			currentEndpoint:  []string{"controlPlaneEndpoint: 172.17.0.9:6443"},
+			expectedCommands: []string{
+				"kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap kubeadm-config -n=kube-system -o=jsonpath={.data.ClusterConfiguration}",
+			},
+		},
+		{
+			name:             "unknown endpoint (dry run)",
+			withLoadBalancer: true,
+			expectedCommands: []string{
+				"kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap kubeadm-config -n=kube-system -o=jsonpath={.data.ClusterConfiguration}",
+			},
+		},
+		{
+			name:               "endpoint updated on containerd",
+			withLoadBalancer:   true,
+			currentEndpoint:    []string{"controlPlaneEndpoint: 172.17.0.2:6443"},
+			expectedCommands:   updateEndpoint("/bin/sh -c crictl ps --name=kube-apiserver -q | xargs -r crictl stop"),
+			expectedKubeconfig: true,
+		},
+		{
+			name:               "endpoint updated on docker",
+			withLoadBalancer:   true,
+			currentEndpoint:    []string{"controlPlaneEndpoint: 172.17.0.2:6443"},
+			detectedCRI:        "docker",
+			expectedCommands:   updateEndpoint("/bin/sh -c docker ps --filter=name=k8s_kube-apiserver_ -q | xargs -r docker stop"),
+			expectedKubeconfig: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var nodes []fake.Node
+			if test.withLoadBalancer {
+				nodes = append(nodes, fake.Node{
+					Name:  "kinder-lb",
+					Role:  constants.ExternalLoadBalancerNodeRoleValue,
+					IPv4:  "172.17.0.9",
+					Ports: map[int32]int32{constants.ControlPlanePort: 32770},
+				})
+			}
+			c, p := newFakeCluster(t, nodes...)
+			p.On("kinder-control-plane-1", "kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap kubeadm-config -n=kube-system -o=jsonpath", test.currentEndpoint...)
+			p.On("kinder-control-plane-1", "/bin/sh -c "+status.DetectCRICommand, test.detectedCRI)
+			p.On("kinder-control-plane-1", "cat /etc/kubernetes/admin.conf", fakeAdminConf...)
+
+			if err := ensureControlPlaneEndpoint(c, 0, 1); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var commands []string
+			for _, cmd := range p.Commands() {
+				if strings.HasPrefix(cmd.Text, "cat ") || strings.HasSuffix(cmd.Text, status.DetectCRICommand) {
+					continue
+				}
+				commands = append(commands, cmd.Text)
+			}
+			if !reflect.DeepEqual(commands, test.expectedCommands) {
+				t.Errorf("expected commands:\n%s\ngot:\n%s", strings.Join(test.expectedCommands, "\n"), strings.Join(commands, "\n"))
+			}
+
+			kubeconfig, err := os.ReadFile(c.KubeConfigPath())
+			if test.expectedKubeconfig != (err == nil) {
+				t.Fatalf("expected kubeconfig on the host %t, got %v", test.expectedKubeconfig, err)
+			}
+			if test.expectedKubeconfig && !strings.Contains(string(kubeconfig), ":32770") {
+				t.Errorf("expected kubeconfig on the host to use the load balancer port, got:\n%s", kubeconfig)
+			}
+		})
+	}
+}
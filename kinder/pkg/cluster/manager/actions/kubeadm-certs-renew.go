@@ -0,0 +1,446 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+// kubeadmCertificates maps the names of the certificates managed by kubeadm certs renew to the files where
+// they are stored; certificates for kubeconfig files are embedded in the file
+var kubeadmCertificates = map[string]string{
+	"admin.conf":               "admin.conf",
+	"super-admin.conf":         "super-admin.conf",
+	"controller-manager.conf":  "controller-manager.conf",
+	"scheduler.conf":           "scheduler.conf",
+	"apiserver":                "pki/apiserver.crt",
+	"apiserver-etcd-client":    "pki/apiserver-etcd-client.crt",
+	"apiserver-kubelet-client": "pki/apiserver-kubelet-client.crt",
+	"front-proxy-client":       "pki/front-proxy-client.crt",
+	"etcd-healthcheck-client":  "pki/etcd/healthcheck-client.crt",
+	"etcd-peer":                "pki/etcd/peer.crt",
+	"etcd-server":              "pki/etcd/server.crt",
+}
+
+// certificateStaticPods maps the names of the certificates managed by kubeadm certs renew to the static pods
+// using them, that must be restarted after renewal; kubeconfig files for admins are not used by static pods
+var certificateStaticPods = map[string][]string{
+	"controller-manager.conf":  {"kube-controller-manager"},
+	"scheduler.conf":           {"kube-scheduler"},
+	"apiserver":                {"kube-apiserver"},
+	"apiserver-etcd-client":    {"kube-apiserver"},
+	"apiserver-kubelet-client": {"kube-apiserver"},
+	"front-proxy-client":       {"kube-apiserver"},
+	"etcd-peer":                {"etcd"},
+	"etcd-server":              {"etcd"},
+}
+
+// staticPodRestartDelay is the time static pod manifests are kept out of the manifests folder when restarting
+// static pods; it must be longer than the period used by the kubelet for checking static pod manifests (20s).
+var staticPodRestartDelay = 25 * time.Second
+
+// defaultCertificateValidityPeriod is the validity period of the certificates issued by kubeadm, if not set in
+// the ClusterConfiguration
+const defaultCertificateValidityPeriod = 365 * 24 * time.Hour
+
+// clockOffsetConfigPath is the path of the kubeadm config used for issuing certificates simulating a clock offset
+const clockOffsetConfigPath = "/kind/kubeadm-clock-offset.conf"
+
+// KubeadmCertsRenew executes the kubeadm certs renew workflow on all the control-plane nodes, renewing all the
+// certificates or only the given one, then restarts the static pods using the renewed certificates,
+// and verifies that NotAfter of each renewed certificate advanced.
+//
+// If clockOffset is set, e.g. 8700h, near-expiry certificates are simulated before the renewal, by re-issuing
+// the certificates as if the node clock was moved forward by clockOffset. Nb. this requires kubeadm v1.31 or
+// higher, supporting certificateValidityPeriod in the ClusterConfiguration.
+func KubeadmCertsRenew(c *status.Cluster, certificate string, clockOffset, wait time.Duration, vLevel int) error {
+	if certificate == "" {
+		certificate = "all"
+	}
+	if _, ok := kubeadmCertificates[certificate]; !ok && certificate != "all" {
+		names := []string{}
+		for name := range kubeadmCertificates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return errors.Errorf("invalid certificate %q. Use all or one of %s", certificate, names)
+	}
+
+	for _, n := range c.ControlPlanes().EligibleForActions() {
+		if err := kubeadmCertsRenewOnNode(c, n, certificate, clockOffset, wait, vLevel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func kubeadmCertsRenewOnNode(c *status.Cluster, n *status.Node, certificate string, clockOffset, wait time.Duration, vLevel int) error {
+	names, err := certificatesToRenew(n, certificate)
+	if err != nil {
+		return err
+	}
+
+	if clockOffset > 0 {
+		if err := simulateClockOffset(n, certificate, names, clockOffset, vLevel); err != nil {
+			return err
+		}
+	}
+
+	// under dry run commands are only printed, so certificates can't be read and verified
+	dryRun := n.IsDryRun()
+
+	var before map[string]time.Time
+	if !dryRun {
+		if before, err = certificatesNotAfter(n, names); err != nil {
+			return err
+		}
+	}
+
+	n.Infof("checking certificates expiration before renewal")
+	if err := n.Command(
+		"kubeadm", "certs", "check-expiration", fmt.Sprintf("--v=%d", vLevel),
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to check certificates expiration on node %s", n.Name())
+	}
+
+	n.Infof("renewing certificates")
+	if err := n.Command(
+		"kubeadm", "certs", "renew", certificate, fmt.Sprintf("--v=%d", vLevel),
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to renew certificates on node %s", n.Name())
+	}
+
+	if err := restartSelectedStaticPods(c, n, staticPodsToRestart(n, names), wait); err != nil {
+		return err
+	}
+
+	n.Infof("checking certificates expiration after renewal")
+	if err := n.Command(
+		"kubeadm", "certs", "check-expiration", fmt.Sprintf("--v=%d", vLevel),
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to check certificates expiration on node %s", n.Name())
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	after, err := certificatesNotAfter(n, names)
+	if err != nil {
+		return err
+	}
+	var failures []string
+	for _, name := range names {
+		if !after[name].After(before[name]) {
+			failures = append(failures, fmt.Sprintf("%s NotAfter did not advance (%s)", name, after[name].Format(time.RFC3339)))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.Errorf("certificates were not renewed on node %s: %s", n.Name(), strings.Join(failures, "; "))
+	}
+
+	n.Infof("%d certificates renewed", len(names))
+	return nil
+}
+
+// simulateClockOffset re-issues the given certificates with a validity period shortened by clockOffset, so they
+// expire as if the node clock was moved forward by clockOffset; then it verifies the certificates expiration.
+// Nb. moving the node clock is not an option, because node containers share the clock with the host.
+func simulateClockOffset(n *status.Node, certificate string, names []string, clockOffset time.Duration, vLevel int) error {
+	// under dry run commands are only printed, so the kubeadm config can't be read and certificates can't be verified
+	dryRun := n.IsDryRun()
+
+	var validity time.Duration
+	if !dryRun {
+		lines, err := n.Command(
+			"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf",
+			"get", "configmap", "kubeadm-config", "-n=kube-system",
+			"-o=jsonpath={.data.ClusterConfiguration}",
+		).Silent().RunAndCapture()
+		if err != nil {
+			return errors.Wrap(err, "failed to read the kubeadm-config ConfigMap")
+		}
+
+		var config string
+		config, validity, err = clockOffsetClusterConfiguration(lines, clockOffset)
+		if err != nil {
+			return err
+		}
+		if err := n.WriteFile(clockOffsetConfigPath, []byte(config)); err != nil {
+			return err
+		}
+	}
+
+	n.Infof("issuing certificates expiring as if the node clock was moved forward by %s", clockOffset)
+	if err := n.Command(
+		"kubeadm", "certs", "renew", certificate, fmt.Sprintf("--config=%s", clockOffsetConfigPath), fmt.Sprintf("--v=%d", vLevel),
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to issue certificates simulating a clock offset on node %s", n.Name())
+	}
+	if err := n.Command("rm", "-f", clockOffsetConfigPath).Silent().Run(); err != nil {
+		return errors.Wrapf(err, "failed to remove %s on node %s", clockOffsetConfigPath, n.Name())
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	// checks that certificates were issued with the shortened validity period, e.g. kubeadm versions
+	// ignoring certificateValidityPeriod would issue certificates with the default one
+	now, err := nodeTime(n)
+	if err != nil {
+		return err
+	}
+	expiresBy := now.Add(validity - clockOffset)
+	notAfter, err := certificatesNotAfter(n, names)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if notAfter[name].After(expiresBy) {
+			return errors.Errorf("failed to simulate a clock offset on node %s: certificate %s expires at %s, after %s", n.Name(), name, notAfter[name].Format(time.RFC3339), expiresBy.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// clockOffsetClusterConfiguration takes the output lines reading the ClusterConfiguration from the kubeadm-config
+// ConfigMap and returns a ClusterConfiguration issuing certificates with a validity period shortened by clockOffset,
+// together with the validity period used by the cluster.
+func clockOffsetClusterConfiguration(lines []string, clockOffset time.Duration) (string, time.Duration, error) {
+	validity := defaultCertificateValidityPeriod
+	isV1beta4 := false
+	config := []string{}
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "apiVersion: kubeadm.k8s.io/v1beta4" {
+			isV1beta4 = true
+		}
+		// NB. certificateValidityPeriod is a top level field of the ClusterConfiguration, so it is not indented
+		if v, ok := strings.CutPrefix(l, "certificateValidityPeriod:"); ok {
+			d, err := time.ParseDuration(strings.TrimSpace(v))
+			if err != nil {
+				return "", 0, errors.Wrapf(err, "invalid certificateValidityPeriod in the ClusterConfiguration")
+			}
+			validity = d
+			continue
+		}
+		config = append(config, l)
+	}
+	if !isV1beta4 {
+		return "", 0, errors.New("simulating a clock offset requires a ClusterConfiguration v1beta4 (kubeadm v1.31 or higher)")
+	}
+	if clockOffset >= validity {
+		return "", 0, errors.Errorf("the clock offset %s must be shorter than the certificate validity period %s", clockOffset, validity)
+	}
+
+	config = append(config, fmt.Sprintf("certificateValidityPeriod: %s", validity-clockOffset))
+	return strings.Join(config, "\n"), validity, nil
+}
+
+// certificatesToRenew returns the certificates to renew that exist on a node
+func certificatesToRenew(n *status.Node, certificate string) ([]string, error) {
+	if certificate != "all" {
+		return []string{certificate}, nil
+	}
+
+	names := []string{}
+	for name, file := range kubeadmCertificates {
+		// e.g. etcd certificates do not exist when using external etcd, and super-admin.conf
+		// exists only on the bootstrap control-plane node
+		if err := n.Command("test", "-f", filepath.Join(etcKubernetes, file)).Silent().Run(); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return nil, errors.Errorf("no certificates to renew on node %s", n.Name())
+	}
+	return names, nil
+}
+
+// certificatesNotAfter returns the NotAfter of the given certificates
+func certificatesNotAfter(n *status.Node, names []string) (map[string]time.Time, error) {
+	notAfter := map[string]time.Time{}
+	for _, name := range names {
+		cert, err := readCertificate(n, filepath.Join(etcKubernetes, kubeadmCertificates[name]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read certificate %s on node %s", name, n.Name())
+		}
+		notAfter[name] = cert.NotAfter
+	}
+	return notAfter, nil
+}
+
+// readCertificate reads a certificate from a PEM file, or the client certificate from a kubeconfig file
+func readCertificate(n *status.Node, path string) (*x509.Certificate, error) {
+	lines, err := n.Command("cat", path).Silent().RunAndCapture()
+	if err != nil {
+		return nil, err
+	}
+	data := []byte(strings.Join(lines, "\n"))
+
+	if strings.HasSuffix(path, ".conf") {
+		data = nil
+		for _, l := range lines {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(l), "client-certificate-data:"); ok {
+				if data, err = base64.StdEncoding.DecodeString(strings.TrimSpace(v)); err != nil {
+					return nil, errors.Wrap(err, "invalid client-certificate-data")
+				}
+				break
+			}
+		}
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.Errorf("no certificate found in %s", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// nodeTime returns the current time on a node
+func nodeTime(n *status.Node) (time.Time, error) {
+	lines, err := n.Command("date", "+%s").Silent().RunAndCapture()
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to read the time on node %s", n.Name())
+	}
+	if len(lines) != 1 {
+		return time.Time{}, errors.Errorf("expected the time on node %s to have 1 line, got %d", n.Name(), len(lines))
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid time on node %s", n.Name())
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// staticPods returns all the static pods on a node
+func staticPods(n *status.Node) ([]string, error) {
+	lines, err := n.Command("ls", filepath.Join(etcKubernetes, "manifests")).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list static pods on node %s", n.Name())
+	}
+	pods := []string{}
+	for _, l := range lines {
+		if pod, ok := strings.CutSuffix(strings.TrimSpace(l), ".yaml"); ok {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// restartStaticPods restarts all the static pods on a node and waits for them to become ready again
+func restartStaticPods(c *status.Cluster, n *status.Node, wait time.Duration) error {
+	pods, err := staticPods(n)
+	if err != nil {
+		return err
+	}
+	return moveStaticPodManifests(c, n, pods, []string{"*.yaml"}, wait)
+}
+
+// staticPodsToRestart returns the static pods using the given certificates that exist on a node
+func staticPodsToRestart(n *status.Node, names []string) []string {
+	pods := []string{}
+	for _, name := range names {
+		for _, pod := range certificateStaticPods[name] {
+			if slices.Contains(pods, pod) {
+				continue
+			}
+			// e.g. the etcd static pod does not exist when using external etcd
+			if err := n.Command("test", "-f", filepath.Join(etcKubernetes, "manifests", pod+".yaml")).Silent().Run(); err != nil {
+				continue
+			}
+			pods = append(pods, pod)
+		}
+	}
+	sort.Strings(pods)
+	return pods
+}
+
+// restartSelectedStaticPods restarts the given static pods on a node and waits for them to become ready again
+func restartSelectedStaticPods(c *status.Cluster, n *status.Node, pods []string, wait time.Duration) error {
+	if len(pods) == 0 {
+		n.Infof("no static pods to restart")
+		return nil
+	}
+
+	manifests := []string{}
+	for _, pod := range pods {
+		manifests = append(manifests, pod+".yaml")
+	}
+	return moveStaticPodManifests(c, n, pods, manifests, wait)
+}
+
+// moveStaticPodManifests restarts static pods by moving the given manifests, or glob patterns matching them,
+// out of the manifests folder and back, and then waits for the pods to become ready again.
+// Nb. the kubelet restarts static pods only when manifests change, so manifests are temporarily moved
+// out of the manifests folder instead of touching them.
+func moveStaticPodManifests(c *status.Cluster, n *status.Node, pods, manifests []string, wait time.Duration) error {
+	manifestsDir := filepath.Join(etcKubernetes, "manifests")
+	tmpDir := filepath.Join(etcKubernetes, "tmp", "kinder-static-pods")
+	sources, moved := []string{}, []string{}
+	for _, m := range manifests {
+		sources = append(sources, filepath.Join(manifestsDir, m))
+		moved = append(moved, filepath.Join(tmpDir, m))
+	}
+
+	// under dry run commands are only printed, so there is nothing to wait for
+	dryRun := n.IsDryRun()
+
+	var now time.Time
+	if !dryRun {
+		var err error
+		if now, err = nodeTime(n); err != nil {
+			return err
+		}
+	}
+
+	n.Infof("restarting static pods %s", strings.Join(pods, ", "))
+	if err := n.Command(
+		"sh", "-c", fmt.Sprintf("mkdir -p %s && mv %s %s/", tmpDir, strings.Join(sources, " "), tmpDir),
+	).Silent().Run(); err != nil {
+		return errors.Wrapf(err, "failed to move static pod manifests on node %s", n.Name())
+	}
+	if !dryRun {
+		time.Sleep(staticPodRestartDelay)
+	}
+	if err := n.Command(
+		"sh", "-c", fmt.Sprintf("mv %s %s/ && rmdir %s", strings.Join(moved, " "), manifestsDir, tmpDir),
+	).Silent().Run(); err != nil {
+		return errors.Wrapf(err, "failed to restore static pod manifests on node %s", n.Name())
+	}
+
+	if dryRun {
+		return nil
+	}
+	return waitStaticPodsRestarted(c, n, pods, now, wait)
+}
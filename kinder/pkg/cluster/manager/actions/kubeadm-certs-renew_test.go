@@ -0,0 +1,285 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+)
+
+// setFakeCertificate writes a certificate expiring at notAfter to a node, embedding it into kubeconfig files if required
+func setFakeCertificate(t *testing.T, p *fake.Provider, node, name string, notAfter time.Time) {
+	t.Helper()
+
+	data := fakeCertificate(t, name, notAfter)
+	file := kubeadmCertificates[name]
+	if strings.HasSuffix(file, ".conf") {
+		// copy the fixture, so appending does not change it
+		lines := append([]string{}, fakeAdminConf[:len(fakeAdminConf)-2]...)
+		data = []byte(strings.Join(append(lines,
+			"    client-certificate-data: "+base64.StdEncoding.EncodeToString(data),
+			"    client-key-data: a2V5",
+		), "\n"))
+	}
+	p.SetFile(node, filepath.Join(etcKubernetes, file), data)
+}
+
+// fakeCertificate returns a PEM encoded self-signed certificate expiring at notAfter
+func fakeCertificate(t *testing.T, name string, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// clusterConfigurationV1beta4 is the ClusterConfiguration in the kubeadm-config ConfigMap of a fake cluster
+var clusterConfigurationV1beta4 = []string{
+	"apiVersion: kubeadm.k8s.io/v1beta4",
+	"kind: ClusterConfiguration",
+	"kubernetesVersion: v1.31.0",
+}
+
+func TestKubeadmCertsRenew(t *testing.T) {
+	defer func(d time.Duration) { staticPodRestartDelay = d }(staticPodRestartDelay)
+	staticPodRestartDelay = 0
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	issued := now.Add(365*24*time.Hour - time.Hour)
+	renewed := now.Add(365 * 24 * time.Hour)
+
+	tests := []struct {
+		name             string
+		certificate      string
+		clockOffset      time.Duration
+		config           []string
+		noClockOffset    bool
+		noRenewal        bool
+		dryRun           bool
+		expectedCommands []string
+		expectedRestarts []string
+		expectedConfig   string
+		expectedError    string
+	}{
+		{
+			name: "renew all the certificates",
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubeadm certs check-expiration --v=1",
+				"kinder-control-plane-1: kubeadm certs renew all --v=1",
+				"kinder-control-plane-1: kubeadm certs check-expiration --v=1",
+			},
+			expectedRestarts: []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler"},
+		},
+		{
+			name:        "renew one certificate",
+			certificate: "apiserver",
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubeadm certs check-expiration --v=1",
+				"kinder-control-plane-1: kubeadm certs renew apiserver --v=1",
+				"kinder-control-plane-1: kubeadm certs check-expiration --v=1",
+			},
+			expectedRestarts: []string{"kube-apiserver"},
+		},
+		{
+			name:             "renew a certificate not used by static pods",
+			certificate:      "admin.conf",
+			expectedRestarts: []string{},
+		},
+		{
+			name:        "simulate a clock offset",
+			certificate: "scheduler.conf",
+			clockOffset: 8700 * time.Hour,
+			config:      clusterConfigurationV1beta4,
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubeadm certs renew scheduler.conf --config=/kind/kubeadm-clock-offset.conf --v=1",
+				"kinder-control-plane-1: kubeadm certs check-expiration --v=1",
+				"kinder-control-plane-1: kubeadm certs renew scheduler.conf --v=1",
+				"kinder-control-plane-1: kubeadm certs check-expiration --v=1",
+			},
+			expectedRestarts: []string{"kube-scheduler"},
+			expectedConfig:   "certificateValidityPeriod: 60h0m0s",
+		},
+		{
+			name:           "simulate a clock offset with a custom validity period",
+			certificate:    "admin.conf",
+			clockOffset:    8700 * time.Hour,
+			config:         append(clusterConfigurationV1beta4, "certificateValidityPeriod: 8760h0m0s"),
+			expectedConfig: "certificateValidityPeriod: 60h0m0s",
+		},
+		{
+			name:          "clock offset longer than the validity period",
+			certificate:   "admin.conf",
+			clockOffset:   366 * 24 * time.Hour,
+			config:        clusterConfigurationV1beta4,
+			expectedError: "must be shorter than the certificate validity period",
+		},
+		{
+			name:          "clock offset with a ClusterConfiguration v1beta3",
+			certificate:   "admin.conf",
+			clockOffset:   8700 * time.Hour,
+			config:        []string{"apiVersion: kubeadm.k8s.io/v1beta3", "kind: ClusterConfiguration"},
+			expectedError: "requires a ClusterConfiguration v1beta4",
+		},
+		{
+			name:          "clock offset ignored by kubeadm",
+			certificate:   "admin.conf",
+			clockOffset:   8700 * time.Hour,
+			config:        clusterConfigurationV1beta4,
+			noClockOffset: true,
+			expectedError: "failed to simulate a clock offset",
+		},
+		{
+			name:        "dry run with a clock offset",
+			certificate: "apiserver",
+			clockOffset: 8700 * time.Hour,
+			dryRun:      true,
+		},
+		{
+			name:        "dry run",
+			certificate: "apiserver",
+			dryRun:      true,
+		},
+		{
+			name:          "certificates not renewed",
+			noRenewal:     true,
+			expectedError: "apiserver NotAfter did not advance",
+		},
+		{
+			name:          "invalid certificate",
+			certificate:   "ca",
+			expectedError: `invalid certificate "ca"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeCluster(t)
+			node := "kinder-control-plane-1"
+
+			// the cluster uses external etcd, so etcd certificates are missing
+			for name := range kubeadmCertificates {
+				if strings.HasPrefix(name, "etcd-") {
+					p.OnError(node, "test -f "+filepath.Join(etcKubernetes, kubeadmCertificates[name]), errors.New("missing"))
+					continue
+				}
+				setFakeCertificate(t, p, node, name, issued)
+			}
+			p.On(node, "date +%s", fmt.Sprint(now.Unix()))
+			p.OnError(node, "test -f /etc/kubernetes/manifests/etcd.yaml", errors.New("missing"))
+			p.On(node, "kubectl get pods", fmt.Sprintf("'%s True'", now.Add(time.Minute).Format(time.RFC3339)))
+			p.On(node, "kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap kubeadm-config", test.config...)
+			var config []byte
+			p.OnFunc(node, "kubeadm certs renew", func(text string) ([]string, error) {
+				notAfter := renewed
+				if strings.Contains(text, "--config="+clockOffsetConfigPath) {
+					config, _ = p.File(node, clockOffsetConfigPath)
+					if test.noClockOffset {
+						return nil, nil
+					}
+					notAfter = now.Add(365*24*time.Hour - test.clockOffset)
+				} else if test.noRenewal {
+					return nil, nil
+				}
+				for name := range kubeadmCertificates {
+					if strings.Contains(text, " all ") || strings.Contains(text, " "+name+" ") {
+						setFakeCertificate(t, p, node, name, notAfter)
+					}
+				}
+				return nil, nil
+			})
+
+			if test.dryRun {
+				for _, n := range c.AllNodes() {
+					n.DryRun()
+				}
+			}
+
+			err := KubeadmCertsRenew(c, test.certificate, test.clockOffset, 10*time.Second, 1)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if test.dryRun {
+				if commands := p.Commands(); len(commands) != 0 {
+					t.Errorf("expected no commands under dry run, got %v", commands)
+				}
+				return
+			}
+
+			if test.expectedConfig != "" && !strings.Contains(string(config), test.expectedConfig) {
+				t.Errorf("expected the kubeadm config for the clock offset to contain %q, got:\n%s", test.expectedConfig, config)
+			}
+
+			if test.expectedRestarts != nil {
+				restarts := []string{}
+				for _, c := range p.Commands() {
+					if strings.Contains(c.Text, "mv /etc/kubernetes/manifests/") {
+						for _, f := range strings.Fields(c.Text) {
+							if pod, ok := strings.CutPrefix(f, "/etc/kubernetes/manifests/"); ok {
+								restarts = append(restarts, strings.TrimSuffix(pod, ".yaml"))
+							}
+						}
+					}
+				}
+				if !reflect.DeepEqual(restarts, test.expectedRestarts) {
+					t.Errorf("expected static pods %v to be restarted, got %v", test.expectedRestarts, restarts)
+				}
+			}
+
+			if test.expectedCommands != nil {
+				commands := kubeadmCommands(p)
+				if !reflect.DeepEqual(commands, test.expectedCommands) {
+					t.Errorf("expected commands:\n%s\ngot:\n%s", strings.Join(test.expectedCommands, "\n"), strings.Join(commands, "\n"))
+				}
+			}
+		})
+	}
+}
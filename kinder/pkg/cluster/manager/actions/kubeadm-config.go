@@ -18,6 +18,8 @@ package actions
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -124,11 +126,12 @@ func KubeadmConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode
 	configData := kubeadm.ConfigData{
 		ClusterName:           c.Name(),
 		KubernetesVersion:     kubeVersion,
-		ControlPlaneEndpoint:  fmt.Sprintf("%s:%d", controlPlaneEndpoint, ControlPlanePort),
+		ControlPlaneEndpoint:  net.JoinHostPort(controlPlaneEndpoint, strconv.Itoa(ControlPlanePort)),
 		APIBindPort:           constants.APIServerPort,
 		APIServerAddress:      controlPlaneIP,
 		Token:                 constants.Token,
-		PodSubnet:             "192.168.0.0/16", // default for kindnet
+		PodSubnet:             podSubnet(c.Settings.IPFamily),
+		ServiceSubnet:         serviceSubnet(c.Settings.IPFamily),
 		ControlPlane:          true,
 		IPv6:                  c.Settings.IPFamily == status.IPv6Family,
 		FeatureGateName:       featureGateName,
@@ -155,6 +158,39 @@ func KubeadmConfig(c *status.Cluster, kubeadmConfigVersion string, copyCertsMode
 	return nil
 }
 
+const (
+	// podSubnetIPv4 is the default pod subnet for kindnet
+	podSubnetIPv4 = "192.168.0.0/16"
+	// podSubnetIPv6 is the pod subnet used in IPv6 and dual-stack clusters
+	podSubnetIPv6 = "fd00:10:244::/56"
+	// serviceSubnetIPv4 is the service subnet used in dual-stack clusters; in IPv4 clusters the kubeadm default is used
+	serviceSubnetIPv4 = "10.96.0.0/16"
+	// serviceSubnetIPv6 is the service subnet used in IPv6 and dual-stack clusters
+	serviceSubnetIPv6 = "fd00:10:96::/112"
+)
+
+// podSubnet returns the pod subnet for the given IP family; dual-stack clusters have both an IPv4 and an IPv6 subnet
+func podSubnet(ipFamily status.ClusterIPFamily) string {
+	switch ipFamily {
+	case status.IPv6Family:
+		return podSubnetIPv6
+	case status.DualStackFamily:
+		return fmt.Sprintf("%s,%s", podSubnetIPv4, podSubnetIPv6)
+	}
+	return podSubnetIPv4
+}
+
+// serviceSubnet returns the service subnet for the given IP family; dual-stack clusters have both an IPv4 and an IPv6 subnet
+func serviceSubnet(ipFamily status.ClusterIPFamily) string {
+	switch ipFamily {
+	case status.IPv6Family:
+		return serviceSubnetIPv6
+	case status.DualStackFamily:
+		return fmt.Sprintf("%s,%s", serviceSubnetIPv4, serviceSubnetIPv6)
+	}
+	return ""
+}
+
 // getControlPlaneAddress return the join address that is the control plane endpoint in case the cluster has
 // an external load balancer in front of the control-plane nodes, otherwise the address of the
 // bootstrap control plane node.
@@ -196,8 +232,12 @@ func writeKubeadmConfig(c *status.Cluster, n *status.Node, data kubeadm.ConfigDa
 	}
 
 	data.NodeAddress = nodeAddress
-	if c.Settings.IPFamily == status.IPv6Family {
+	switch c.Settings.IPFamily {
+	case status.IPv6Family:
 		data.NodeAddress = nodeAddressIPv6
+	case status.DualStackFamily:
+		// in dual-stack clusters IPv4 is the primary IP family, and the kubelet is configured with both the addresses
+		data.NodeAddressSecondary = nodeAddressIPv6
 	}
 
 	// Gets the kubeadm config customize for this node
@@ -327,7 +367,7 @@ func getKubeadmConfig(c *status.Cluster, n *status.Node, data kubeadm.ConfigData
 
 		// configure the right protocol addresses
 		if c.Settings.IPFamily == status.IPv6Family {
-			externalEtcdIP = externalEtcdIPV6
+			externalEtcdIP = fmt.Sprintf("[%s]", externalEtcdIPV6)
 		}
 
 		externalEtcdPatch, err := kubeadm.GetExternalEtcdPatch(kubeadmConfigVersion, externalEtcdIP)
@@ -0,0 +1,108 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
+)
+
+func TestSubnets(t *testing.T) {
+	tests := []struct {
+		name                  string
+		ipFamily              status.ClusterIPFamily
+		expectedPodSubnet     string
+		expectedServiceSubnet string
+	}{
+		{
+			name:                  "ipv4",
+			ipFamily:              status.IPv4Family,
+			expectedPodSubnet:     "192.168.0.0/16",
+			expectedServiceSubnet: "",
+		},
+		{
+			name:                  "ipv6",
+			ipFamily:              status.IPv6Family,
+			expectedPodSubnet:     "fd00:10:244::/56",
+			expectedServiceSubnet: "fd00:10:96::/112",
+		},
+		{
+			name:                  "dual",
+			ipFamily:              status.DualStackFamily,
+			expectedPodSubnet:     "192.168.0.0/16,fd00:10:244::/56",
+			expectedServiceSubnet: "10.96.0.0/16,fd00:10:96::/112",
+		},
+		{
+			name:                  "empty defaults to ipv4",
+			expectedPodSubnet:     "192.168.0.0/16",
+			expectedServiceSubnet: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if s := podSubnet(test.ipFamily); s != test.expectedPodSubnet {
+				t.Errorf("expected pod subnet %q, got %q", test.expectedPodSubnet, s)
+			}
+			if s := serviceSubnet(test.ipFamily); s != test.expectedServiceSubnet {
+				t.Errorf("expected service subnet %q, got %q", test.expectedServiceSubnet, s)
+			}
+		})
+	}
+}
+
+func TestKubeadmConfigCRISocket(t *testing.T) {
+	tests := []struct {
+		name              string
+		detectedCRI       string
+		expectedCRISocket string
+	}{
+		{
+			name:              "containerd",
+			expectedCRISocket: "/run/containerd/containerd.sock",
+		},
+		{
+			name:              "crio",
+			detectedCRI:       "crio",
+			expectedCRISocket: kubeadm.CRIOSocket,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeCluster(t)
+			p.On("", "/bin/sh -c "+status.DetectCRICommand, test.detectedCRI)
+
+			cp1 := c.BootstrapControlPlane()
+			if err := KubeadmInitConfig(c, "v1beta4", CopyCertsModeAuto, "", "", "", cp1); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			config, ok := p.File(cp1.Name(), constants.KubeadmConfigPath)
+			if !ok {
+				t.Fatalf("expected %s to be written on the node", constants.KubeadmConfigPath)
+			}
+			if !strings.Contains(string(config), "criSocket: "+test.expectedCRISocket) {
+				t.Errorf("expected criSocket %s, got config:\n%s", test.expectedCRISocket, config)
+			}
+		})
+	}
+}
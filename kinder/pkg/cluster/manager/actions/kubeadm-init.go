@@ -201,10 +201,22 @@ func postInit(c *status.Cluster, wait time.Duration) error {
 		return err
 	}
 
-	// Apply a CNI plugin using a hardcoded manifest
+	// Apply a CNI plugin using a manifest configured for the cluster IP family
+	kindnetImage := assets.KindnetImage054
+	if c.Settings.IPFamily == status.DualStackFamily {
+		kindnetImage = assets.KindnetImageDualStack
+	}
+	kindnetManifest, err := assets.KindnetManifest(&assets.KindnetConfigData{
+		Image:     kindnetImage,
+		PodSubnet: podSubnet(c.Settings.IPFamily),
+	})
+	if err != nil {
+		return err
+	}
+
 	cmd := cp1.Command("kubectl", "apply", "--kubeconfig=/etc/kubernetes/admin.conf", "-f", "-")
-	cp1.Infof("applying kindnet version 0.5.4")
-	cmd.Stdin(strings.NewReader(assets.KindnetManifest054))
+	cp1.Infof("applying kindnet %s", kindnetImage)
+	cmd.Stdin(strings.NewReader(kindnetManifest))
 	if err := cmd.RunWithEcho(); err != nil {
 		return err
 	}
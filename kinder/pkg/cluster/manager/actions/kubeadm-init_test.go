@@ -0,0 +1,145 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+// newFakeCluster returns a cluster with a bootstrap control-plane node, and eventually the given
+// additional nodes, hosted by a fake provider scripted for a Kubernetes v1.31.0 cluster
+func newFakeCluster(t *testing.T, nodes ...fake.Node) (*status.Cluster, *fake.Provider) {
+	t.Helper()
+
+	// kubeconfig files for the cluster are written in the home folder
+	t.Setenv("HOME", t.TempDir())
+
+	nodes = append([]fake.Node{{
+		Name:  "kinder-control-plane-1",
+		Role:  constants.ControlPlaneNodeRoleValue,
+		IPv4:  "172.17.0.2",
+		Ports: map[int32]int32{constants.APIServerPort: 32768},
+	}}, nodes...)
+
+	p := fake.NewProvider(nodes...)
+	p.On("", "cat /kind/version", "v1.31.0")
+	p.On("", "kubeadm version -o=short", "v1.31.0")
+
+	c, err := status.DiscoverWithProvider("kinder", p)
+	if err != nil {
+		t.Fatalf("failed to create the fake cluster: %v", err)
+	}
+	c.Settings = &status.ClusterSettings{IPFamily: status.IPv4Family}
+
+	return c, p
+}
+
+// kubeadmCommands returns the kubeadm commands executed on nodes, excluding kubeadm version
+func kubeadmCommands(p *fake.Provider) []string {
+	commands := []string{}
+	for _, c := range p.Commands() {
+		if !strings.HasPrefix(c.Text, "kubeadm ") || strings.HasPrefix(c.Text, "kubeadm version") {
+			continue
+		}
+		commands = append(commands, c.Node+": "+c.Text)
+	}
+	return commands
+}
+
+func TestKubeadmInit(t *testing.T) {
+	tests := []struct {
+		name             string
+		usePhases        bool
+		copyCertsMode    CopyCertsMode
+		expectedCommands []string
+	}{
+		{
+			name:          "kubeadm init with automatic copy certs",
+			copyCertsMode: CopyCertsModeAuto,
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubeadm init --config=/kind/kubeadm.conf --v=1 --upload-certs",
+			},
+		},
+		{
+			name:          "kubeadm init with manual copy certs",
+			copyCertsMode: CopyCertsModeManual,
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubeadm init --config=/kind/kubeadm.conf --v=1",
+			},
+		},
+		{
+			name:          "kubeadm init phases with automatic copy certs",
+			usePhases:     true,
+			copyCertsMode: CopyCertsModeAuto,
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubeadm init phase preflight --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase kubelet-start --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase certs all --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase kubeconfig all --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase control-plane all --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase etcd local --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase upload-config all --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase upload-certs --upload-certs --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase mark-control-plane --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase bootstrap-token --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase addon all --config=/kind/kubeadm.conf --v=1",
+			},
+		},
+		{
+			name:          "kubeadm init phases with manual copy certs",
+			usePhases:     true,
+			copyCertsMode: CopyCertsModeManual,
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubeadm init phase preflight --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase kubelet-start --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase certs all --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase kubeconfig all --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase control-plane all --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase etcd local --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase upload-config all --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase mark-control-plane --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase bootstrap-token --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-1: kubeadm init phase addon all --config=/kind/kubeadm.conf --v=1",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeCluster(t)
+
+			if err := KubeadmInit(c, test.usePhases, test.copyCertsMode, "", "", "", "", "", 0, 1); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			commands := kubeadmCommands(p)
+			if !reflect.DeepEqual(commands, test.expectedCommands) {
+				t.Errorf("expected commands:\n%s\ngot:\n%s", strings.Join(test.expectedCommands, "\n"), strings.Join(commands, "\n"))
+			}
+
+			if _, ok := p.File("kinder-control-plane-1", constants.KubeadmConfigPath); !ok {
+				t.Errorf("expected %s to be written on the node", constants.KubeadmConfigPath)
+			}
+		})
+	}
+}
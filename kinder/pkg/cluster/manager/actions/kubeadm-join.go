@@ -38,9 +38,34 @@ func KubeadmJoin(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode,
 }
 
 func joinControlPlanes(c *status.Cluster, usePhases bool, copyCertsMode CopyCertsMode, discoveryMode DiscoveryMode, kubeadmConfigVersion, patchesDir, ignorePreflightErrors string, wait time.Duration, vLevel int) (err error) {
+	cp2s := c.SecondaryControlPlanes().EligibleForActions()
+	if len(cp2s) == 0 {
+		return nil
+	}
+
+	// ensures the load balancer is the control-plane endpoint; this is required when the load balancer
+	// was added to a cluster initialized with a single control-plane node
+	if err := ensureControlPlaneEndpoint(c, wait, vLevel); err != nil {
+		return err
+	}
+
+	// if automatic copy certs, ensures the certificates are still available in the cluster
+	if copyCertsMode == CopyCertsModeAuto {
+		if err := uploadCertsIfMissing(c, kubeadmConfigVersion, ignorePreflightErrors, vLevel); err != nil {
+			return err
+		}
+	}
+
+	// the load balancer config starts from the bootstrap control-plane and the secondary control-plane nodes
+	// not eligible for this action, that are assumed already joined (e.g. when joining a new node with --only-node)
 	cpX := []*status.Node{c.BootstrapControlPlane()}
+	for _, cp := range c.SecondaryControlPlanes() {
+		if !containsNode(cp2s, cp) {
+			cpX = append(cpX, cp)
+		}
+	}
 
-	for _, cp2 := range c.SecondaryControlPlanes().EligibleForActions() {
+	for _, cp2 := range cp2s {
 		if err := copyPatchesToNode(cp2, patchesDir); err != nil {
 			return err
 		}
@@ -90,6 +115,15 @@ func joinControlPlanes(c *status.Cluster, usePhases bool, copyCertsMode CopyCert
 	return nil
 }
 
+func containsNode(nodes status.NodeList, n *status.Node) bool {
+	for _, x := range nodes {
+		if x.Name() == n.Name() {
+			return true
+		}
+	}
+	return false
+}
+
 func kubeadmJoinControlPlane(cp *status.Node, vLevel int) (err error) {
 	joinArgs := []string{
 		"join",
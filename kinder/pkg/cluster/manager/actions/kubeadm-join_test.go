@@ -0,0 +1,191 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+// fakeAdminConf is a minimal admin.conf file, used as a starting point for discovery files
+var fakeAdminConf = []string{
+	"apiVersion: v1",
+	"kind: Config",
+	"clusters:",
+	"- name: kinder",
+	"  cluster:",
+	"    server: https://172.17.0.5:6443",
+	"contexts:",
+	"- name: kubernetes-admin@kinder",
+	"  context:",
+	"    cluster: kinder",
+	"    user: kubernetes-admin",
+	"current-context: kubernetes-admin@kinder",
+	"users:",
+	"- name: kubernetes-admin",
+	"  user:",
+	"    client-certificate-data: Y2VydA==",
+	"    client-key-data: a2V5",
+}
+
+func TestKubeadmJoin(t *testing.T) {
+	joinPhasesControlPlane := func(node string) []string {
+		return []string{
+			node + ": kubeadm join phase preflight --config=/kind/kubeadm.conf --v=1",
+			node + ": kubeadm join phase control-plane-prepare all --config=/kind/kubeadm.conf --v=1",
+			node + ": kubeadm join phase kubelet-start --config=/kind/kubeadm.conf --v=1",
+			node + ": kubeadm join phase control-plane-join all --config=/kind/kubeadm.conf --v=1",
+		}
+	}
+	joinPhasesWorker := func(node string) []string {
+		return []string{
+			node + ": kubeadm join phase preflight --config=/kind/kubeadm.conf --v=1",
+			node + ": kubeadm join phase kubelet-start --config=/kind/kubeadm.conf --v=1",
+		}
+	}
+
+	tests := []struct {
+		name             string
+		usePhases        bool
+		copyCertsMode    CopyCertsMode
+		discoveryMode    DiscoveryMode
+		missingCerts     bool
+		expectedCommands []string
+		expectedFiles    []string
+	}{
+		{
+			name:          "kubeadm join with automatic copy certs and token discovery",
+			copyCertsMode: CopyCertsModeAuto,
+			discoveryMode: TokenDiscovery,
+			expectedCommands: []string{
+				"kinder-control-plane-2: kubeadm join --config=/kind/kubeadm.conf --v=1",
+				"kinder-worker-1: kubeadm join --config=/kind/kubeadm.conf --v=1",
+			},
+		},
+		{
+			name:          "kubeadm join with automatic copy certs and missing kubeadm-certs Secret",
+			copyCertsMode: CopyCertsModeAuto,
+			discoveryMode: TokenDiscovery,
+			missingCerts:  true,
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubeadm init phase upload-certs --upload-certs --config=/kind/kubeadm.conf --v=1",
+				"kinder-control-plane-2: kubeadm join --config=/kind/kubeadm.conf --v=1",
+				"kinder-worker-1: kubeadm join --config=/kind/kubeadm.conf --v=1",
+			},
+		},
+		{
+			name:          "kubeadm join with manual copy certs and token discovery",
+			copyCertsMode: CopyCertsModeManual,
+			discoveryMode: TokenDiscovery,
+			expectedCommands: []string{
+				"kinder-control-plane-2: kubeadm join --config=/kind/kubeadm.conf --v=1",
+				"kinder-worker-1: kubeadm join --config=/kind/kubeadm.conf --v=1",
+			},
+			expectedFiles: []string{
+				"kinder-control-plane-2:/etc/kubernetes/pki/ca.crt",
+			},
+		},
+		{
+			name:          "kubeadm join with automatic copy certs and file discovery",
+			copyCertsMode: CopyCertsModeAuto,
+			discoveryMode: FileDiscoveryWithoutCredentials,
+			expectedCommands: []string{
+				"kinder-control-plane-2: kubeadm join --config=/kind/kubeadm.conf --v=1",
+				"kinder-worker-1: kubeadm join --config=/kind/kubeadm.conf --v=1",
+			},
+			expectedFiles: []string{
+				"kinder-control-plane-2:" + constants.DiscoveryFile,
+				"kinder-worker-1:" + constants.DiscoveryFile,
+			},
+		},
+		{
+			name:          "kubeadm join with file discovery with external client certificates",
+			copyCertsMode: CopyCertsModeAuto,
+			discoveryMode: FileDiscoveryWithExternalClientCerts,
+			expectedCommands: []string{
+				"kinder-control-plane-2: kubeadm join --config=/kind/kubeadm.conf --v=1",
+				"kinder-worker-1: kubeadm join --config=/kind/kubeadm.conf --v=1",
+			},
+			expectedFiles: []string{
+				"kinder-control-plane-2:" + constants.DiscoveryFile,
+				"kinder-control-plane-2:/kinder/discovery-client-cert.pem",
+				"kinder-worker-1:/kinder/discovery-client-key.pem",
+			},
+		},
+		{
+			name:             "kubeadm join phases with automatic copy certs and token discovery",
+			usePhases:        true,
+			copyCertsMode:    CopyCertsModeAuto,
+			discoveryMode:    TokenDiscovery,
+			expectedCommands: append(joinPhasesControlPlane("kinder-control-plane-2"), joinPhasesWorker("kinder-worker-1")...),
+		},
+		{
+			name:             "kubeadm join phases with manual copy certs and file discovery with token",
+			usePhases:        true,
+			copyCertsMode:    CopyCertsModeManual,
+			discoveryMode:    FileDiscoveryWithToken,
+			expectedCommands: append(joinPhasesControlPlane("kinder-control-plane-2"), joinPhasesWorker("kinder-worker-1")...),
+			expectedFiles: []string{
+				"kinder-control-plane-2:/etc/kubernetes/pki/ca.crt",
+				"kinder-control-plane-2:" + constants.DiscoveryFile,
+				"kinder-worker-1:" + constants.DiscoveryFile,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeCluster(t,
+				fake.Node{Name: "kinder-control-plane-2", Role: constants.ControlPlaneNodeRoleValue, IPv4: "172.17.0.3"},
+				fake.Node{Name: "kinder-worker-1", Role: constants.WorkerNodeRoleValue, IPv4: "172.17.0.4"},
+				fake.Node{Name: "kinder-lb", Role: constants.ExternalLoadBalancerNodeRoleValue, IPv4: "172.17.0.5"},
+			)
+			p.On("kinder-control-plane-1", "cat /etc/kubernetes/admin.conf", fakeAdminConf...)
+			p.On("kinder-control-plane-1", "cat /etc/kubernetes/pki/ca.crt", "ca")
+			if test.missingCerts {
+				p.OnError("kinder-control-plane-1", "kubectl --kubeconfig=/etc/kubernetes/admin.conf get secret kubeadm-certs", errors.New("not found"))
+			}
+
+			if err := KubeadmJoin(c, test.usePhases, test.copyCertsMode, test.discoveryMode, "", "", "", 0, 1); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			commands := kubeadmCommands(p)
+			if !reflect.DeepEqual(commands, test.expectedCommands) {
+				t.Errorf("expected commands:\n%s\ngot:\n%s", strings.Join(test.expectedCommands, "\n"), strings.Join(commands, "\n"))
+			}
+
+			for _, f := range test.expectedFiles {
+				nodePath := strings.SplitN(f, ":", 2)
+				if _, ok := p.File(nodePath[0], nodePath[1]); !ok {
+					t.Errorf("expected %s to be written on node %s", nodePath[1], nodePath[0])
+				}
+			}
+			if test.discoveryMode == TokenDiscovery {
+				if _, ok := p.File("kinder-worker-1", constants.DiscoveryFile); ok {
+					t.Errorf("expected %s not to be written when using token discovery", constants.DiscoveryFile)
+				}
+			}
+		})
+	}
+}
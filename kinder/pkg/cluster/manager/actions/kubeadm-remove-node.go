@@ -0,0 +1,238 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+// KubeadmRemoveNode removes nodes from the cluster; nodes are drained, reset with kubeadm, removed from the
+// Kubernetes cluster, from the etcd cluster and from the load balancer configuration, and finally the node
+// containers are deleted.
+// Please note that the bootstrap control-plane node cannot be removed, so this action should be used
+// in combination with --only-node.
+func KubeadmRemoveNode(c *status.Cluster, drainTimeout time.Duration, vLevel int) error {
+	cp1 := c.BootstrapControlPlane()
+
+	nodes := c.K8sNodes().EligibleForActions()
+	for _, n := range nodes {
+		if n.Name() == cp1.Name() {
+			return errors.Errorf("removing the bootstrap control-plane node %s is not supported. Use --only-node for selecting the node to remove", cp1.Name())
+		}
+	}
+
+	removed := status.NodeList{}
+	for _, n := range nodes {
+		if err := drainNode(cp1, n, drainTimeout); err != nil {
+			return err
+		}
+
+		if err := kubeadmReset(c, n, vLevel); err != nil {
+			return err
+		}
+
+		cp1.Infof("Deleting Node %s", n.Name())
+		if err := cp1.Command(
+			"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "delete", "node", n.Name(), "--ignore-not-found",
+		).RunWithEcho(); err != nil {
+			return errors.Wrapf(err, "failed to delete Node %s", n.Name())
+		}
+
+		removed = append(removed, n)
+
+		if n.IsControlPlane() {
+			// NB. kubeadm reset removes the local etcd member, but the removal is verified explicitly
+			// because kubeadm reset does not fail if the removal did not happen
+			if c.ExternalEtcd() == nil {
+				if err := removeEtcdMember(c, n); err != nil {
+					return err
+				}
+			}
+
+			// updates the loadbalancer config removing the control-plane node
+			cpX := []*status.Node{}
+			for _, cp := range c.ControlPlanes() {
+				if !containsNode(removed, cp) {
+					cpX = append(cpX, cp)
+				}
+			}
+			if err := LoadBalancer(c, cpX...); err != nil {
+				return err
+			}
+		}
+
+		if err := n.Delete(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// drainNode cordons and drains a node, using kubectl on the bootstrap control-plane; if the drain fails,
+// PodDisruptionBudgets not allowing disruptions are reported, because they are the most common cause of failures
+func drainNode(cp1, n *status.Node, timeout time.Duration) error {
+	cp1.Infof("Draining Node %s", n.Name())
+	if err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "drain", n.Name(),
+		"--ignore-daemonsets", "--delete-emptydir-data", "--force",
+		fmt.Sprintf("--timeout=%s", timeout),
+	).RunWithEcho(); err != nil {
+		if violations := podDisruptionBudgetViolations(cp1, n); len(violations) > 0 {
+			return errors.Wrapf(err, "failed to drain Node %s, PodDisruptionBudgets not allowing disruptions: %s", n.Name(), strings.Join(violations, ", "))
+		}
+		return errors.Wrapf(err, "failed to drain Node %s", n.Name())
+	}
+	return nil
+}
+
+// podDisruptionBudgetViolations returns the PodDisruptionBudgets not allowing disruptions for the pods
+// on the node being drained, if any
+func podDisruptionBudgetViolations(cp1, n *status.Node) []string {
+	pdbs, err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "get", "poddisruptionbudgets", "--all-namespaces", "-o=json",
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil
+	}
+	pods, err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "get", "pods", "--all-namespaces",
+		fmt.Sprintf("--field-selector=spec.nodeName=%s", n.Name()), "-o=json",
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil
+	}
+	violations, err := parsePodDisruptionBudgetViolations(pdbs, pods)
+	if err != nil {
+		cp1.Infof("failed to check PodDisruptionBudgets: %v", err)
+		return nil
+	}
+	return violations
+}
+
+// podDisruptionBudgetList defines the subset of a PodDisruptionBudgetList used for checking violations
+type podDisruptionBudgetList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+		Spec     struct {
+			Selector *metav1.LabelSelector `json:"selector"`
+		} `json:"spec"`
+		Status struct {
+			DisruptionsAllowed int32 `json:"disruptionsAllowed"`
+			CurrentHealthy     int32 `json:"currentHealthy"`
+			DesiredHealthy     int32 `json:"desiredHealthy"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// podList defines the subset of a PodList used for checking violations
+type podList struct {
+	Items []struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	} `json:"items"`
+}
+
+// parsePodDisruptionBudgetViolations takes the output lines listing PodDisruptionBudgets and pods in json format,
+// and returns the PodDisruptionBudgets not allowing disruptions that select at least one of the pods
+func parsePodDisruptionBudgetViolations(pdbLines, podLines []string) ([]string, error) {
+	pdbs := podDisruptionBudgetList{}
+	if err := json.Unmarshal([]byte(strings.Join(pdbLines, "\n")), &pdbs); err != nil {
+		return nil, errors.Wrap(err, "invalid PodDisruptionBudget list")
+	}
+	pods := podList{}
+	if err := json.Unmarshal([]byte(strings.Join(podLines, "\n")), &pods); err != nil {
+		return nil, errors.Wrap(err, "invalid Pod list")
+	}
+
+	violations := []string{}
+	for _, pdb := range pdbs.Items {
+		if pdb.Status.DisruptionsAllowed != 0 {
+			continue
+		}
+		// NB. a null selector selects no pods, while an empty selector selects all the pods in the namespace
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid selector for PodDisruptionBudget %s/%s", pdb.Metadata.Namespace, pdb.Metadata.Name)
+		}
+		for _, pod := range pods.Items {
+			if pod.Metadata.Namespace == pdb.Metadata.Namespace && selector.Matches(labels.Set(pod.Metadata.Labels)) {
+				violations = append(violations, fmt.Sprintf("%s/%s (healthy %d, desired %d)",
+					pdb.Metadata.Namespace, pdb.Metadata.Name, pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy))
+				break
+			}
+		}
+	}
+	return violations, nil
+}
+
+// removeEtcdMember removes the etcd member for a control-plane node, if still present
+func removeEtcdMember(c *status.Cluster, n *status.Node) error {
+	cp1 := c.BootstrapControlPlane()
+
+	etcdArgs, err := etcdctlArgs(c)
+	if err != nil {
+		return err
+	}
+
+	listArgs := append(etcdArgs, "member", "list")
+	lines, err := cp1.Command(
+		"kubectl", listArgs...,
+	).Silent().RunAndCapture()
+	if err != nil {
+		return errors.Wrap(err, "failed to list etcd members")
+	}
+
+	id := parseEtcdMemberID(lines, n.Name())
+	if id == "" {
+		cp1.Infof("etcd member for %s already removed", n.Name())
+		return nil
+	}
+
+	removeArgs := append(etcdArgs, "member", "remove", id)
+	if err := cp1.Command(
+		"kubectl", removeArgs...,
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to remove the etcd member for %s", n.Name())
+	}
+	return nil
+}
+
+// parseEtcdMemberID takes the output lines of 'etcdctl member list' and returns the ID of the
+// member with the given name, if any; e.g.
+//
+//	8e9e05c52164694d, started, kind-control-plane-1, https://172.17.0.2:2380, https://172.17.0.2:2379, false
+func parseEtcdMemberID(lines []string, name string) string {
+	for _, l := range lines {
+		fields := strings.Split(l, ",")
+		if len(fields) < 3 {
+			continue
+		}
+		if strings.TrimSpace(fields[2]) == name {
+			return strings.TrimSpace(fields[0])
+		}
+	}
+	return ""
+}
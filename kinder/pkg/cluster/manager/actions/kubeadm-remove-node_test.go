@@ -0,0 +1,167 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+func TestParseEtcdMemberID(t *testing.T) {
+	memberList := []string{
+		"8e9e05c52164694d, started, kind-control-plane-1, https://172.17.0.2:2380, https://172.17.0.2:2379, false",
+		"91bc3c398fb3c146, started, kind-control-plane-2, https://172.17.0.3:2380, https://172.17.0.3:2379, false",
+	}
+
+	tests := []struct {
+		name       string
+		inputLines []string
+		inputName  string
+		expectedID string
+	}{
+		{
+			name:       "valid: member exists",
+			inputLines: memberList,
+			inputName:  "kind-control-plane-2",
+			expectedID: "91bc3c398fb3c146",
+		},
+		{
+			name:       "valid: member does not exist",
+			inputLines: memberList,
+			inputName:  "kind-control-plane-3",
+			expectedID: "",
+		},
+		{
+			name:       "valid: unexpected output",
+			inputLines: []string{"Error: context deadline exceeded"},
+			inputName:  "kind-control-plane-2",
+			expectedID: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id := parseEtcdMemberID(test.inputLines, test.inputName)
+			if id != test.expectedID {
+				t.Errorf("expected ID %q, got %q", test.expectedID, id)
+			}
+		})
+	}
+}
+
+func TestKubeadmRemoveNode(t *testing.T) {
+	memberList := []string{
+		"8e9e05c52164694d, started, kinder-control-plane-1, https://172.17.0.2:2380, https://172.17.0.2:2379, false",
+		"91bc3c398fb3c146, started, kinder-control-plane-2, https://172.17.0.3:2380, https://172.17.0.3:2379, false",
+	}
+
+	tests := []struct {
+		name             string
+		node             string
+		dryRun           bool
+		expectedCommands []string
+		expectedNodes    []string
+		expectedError    bool
+	}{
+		{
+			name: "remove worker",
+			node: "kinder-worker-1",
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubectl --kubeconfig=/etc/kubernetes/admin.conf drain kinder-worker-1 --ignore-daemonsets --delete-emptydir-data --force --timeout=2m0s",
+				"kinder-worker-1: kubeadm reset --v=1 --config /kind/kubeadm.conf",
+				"kinder-control-plane-1: kubectl --kubeconfig=/etc/kubernetes/admin.conf delete node kinder-worker-1 --ignore-not-found",
+			},
+			expectedNodes: []string{"kinder-control-plane-1", "kinder-control-plane-2", "kinder-lb"},
+		},
+		{
+			name: "remove control-plane",
+			node: "kinder-control-plane-2",
+			expectedCommands: []string{
+				"kinder-control-plane-1: kubectl --kubeconfig=/etc/kubernetes/admin.conf drain kinder-control-plane-2 --ignore-daemonsets --delete-emptydir-data --force --timeout=2m0s",
+				"kinder-control-plane-2: kubeadm reset --v=1 --config /kind/kubeadm.conf",
+				"kinder-control-plane-1: kubectl --kubeconfig=/etc/kubernetes/admin.conf delete node kinder-control-plane-2 --ignore-not-found",
+				"kinder-control-plane-1: kubectl --kubeconfig=/etc/kubernetes/admin.conf exec -n=kube-system etcd-kinder-control-plane-1 -- etcdctl --endpoints=https://127.0.0.1:2379 " +
+					strings.Join(etcdCertArgsNew, " ") + " member remove 91bc3c398fb3c146",
+			},
+			expectedNodes: []string{"kinder-control-plane-1", "kinder-lb", "kinder-worker-1"},
+		},
+		{
+			name:          "dry run",
+			node:          "kinder-control-plane-2",
+			dryRun:        true,
+			expectedNodes: []string{"kinder-control-plane-1", "kinder-control-plane-2", "kinder-lb", "kinder-worker-1"},
+		},
+		{
+			name:          "bootstrap control-plane",
+			node:          "kinder-control-plane-1",
+			expectedNodes: []string{"kinder-control-plane-1", "kinder-control-plane-2", "kinder-lb", "kinder-worker-1"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeCluster(t,
+				fake.Node{Name: "kinder-control-plane-2", Role: constants.ControlPlaneNodeRoleValue, IPv4: "172.17.0.3"},
+				fake.Node{Name: "kinder-worker-1", Role: constants.WorkerNodeRoleValue, IPv4: "172.17.0.4"},
+				fake.Node{Name: "kinder-lb", Role: constants.ExternalLoadBalancerNodeRoleValue, IPv4: "172.17.0.5"},
+			)
+			p.On("kinder-control-plane-1", "kubectl --request-timeout=2", "etcd Version: 3.5.15")
+			p.On("kinder-control-plane-1", "kubectl --kubeconfig=/etc/kubernetes/admin.conf exec -n=kube-system etcd-kinder-control-plane-1 -- etcdctl", memberList...)
+
+			for _, n := range c.AllNodes() {
+				if n.Name() != test.node {
+					n.SkipActions()
+				}
+				if test.dryRun {
+					n.DryRun()
+				}
+			}
+
+			err := KubeadmRemoveNode(c, 2*time.Minute, 1)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error %t, got %v", test.expectedError, err)
+			}
+
+			var commands []string
+			for _, cmd := range p.Commands() {
+				if strings.Contains(cmd.Text, " drain ") || strings.Contains(cmd.Text, " delete node ") ||
+					strings.HasPrefix(cmd.Text, "kubeadm reset") || strings.Contains(cmd.Text, " member remove ") {
+					commands = append(commands, cmd.Node+": "+cmd.Text)
+				}
+			}
+			if !reflect.DeepEqual(commands, test.expectedCommands) {
+				t.Errorf("expected commands:\n%s\ngot:\n%s", strings.Join(test.expectedCommands, "\n"), strings.Join(commands, "\n"))
+			}
+
+			nodes, err := p.ListNodes("kinder")
+			if err != nil {
+				t.Fatalf("failed to list nodes: %v", err)
+			}
+			sort.Strings(nodes)
+			if !reflect.DeepEqual(nodes, test.expectedNodes) {
+				t.Errorf("expected nodes %v, got %v", test.expectedNodes, nodes)
+			}
+		})
+	}
+}
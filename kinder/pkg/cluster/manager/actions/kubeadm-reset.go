@@ -18,39 +18,201 @@ package actions
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes"
 	"k8s.io/kubeadm/kinder/pkg/kubeadm"
 )
 
-// KubeadmReset executes the kubeadm reset workflow
-func KubeadmReset(c *status.Cluster, vLevel int) error {
-	//TODO: implements kubeadm reset with phases
-	for _, n := range c.K8sNodes().EligibleForActions() {
-		flags := []string{"reset", fmt.Sprintf("--v=%d", vLevel)}
+// KubeadmReset executes the kubeadm reset workflow, and then verifies that nodes were cleaned up
+func KubeadmReset(c *status.Cluster, usePhases bool, vLevel int) error {
+	cp1 := c.BootstrapControlPlane()
 
-		// After upgrade, the 'kubeadm version' should return the version of the kubeadm used
-		// to perform the upgrade. Use this version to determine if v1beta4 is enabled. If yes,
-		// use ResetConfiguration with a 'force: true', else just use the '--force' flag.
-		v, err := n.KubeadmVersion()
-		if err != nil {
-			return errors.Wrap(err, "could not obtain the kubeadm version before calling 'kubeadm reset'")
+	// the etcd membership can be verified only using the etcd member on the bootstrap control-plane,
+	// so the bootstrap control-plane is reset last
+	nodes := status.NodeList{}
+	var cp1Node *status.Node
+	for _, n := range c.K8sNodes().EligibleForActions() {
+		if n.Name() == cp1.Name() {
+			cp1Node = n
+			continue
 		}
-		if kubeadm.GetKubeadmConfigVersion(v) == "v1beta4" {
-			if err := KubeadmResetConfig(c, "", n); err != nil {
-				return errors.Wrap(err, "could not write kubeadm config before calling 'kubeadm reset'")
+		nodes = append(nodes, n)
+	}
+	if cp1Node != nil {
+		nodes = append(nodes, cp1Node)
+	}
+
+	for _, n := range nodes {
+		if usePhases {
+			if err := kubeadmResetWithPhases(c, n, vLevel); err != nil {
+				return err
 			}
-			flags = append(flags, "--config", constants.KubeadmConfigPath)
 		} else {
-			flags = append(flags, "--force")
+			if err := kubeadmReset(c, n, vLevel); err != nil {
+				return err
+			}
 		}
 
-		if err := n.Command("kubeadm", flags...).RunWithEcho(); err != nil {
+		checkEtcdMember := n.IsControlPlane() && c.ExternalEtcd() == nil && n.Name() != cp1.Name()
+		if err := verifyKubeadmReset(c, n, checkEtcdMember); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kubeadmResetConfigFlags returns the flags for using a ResetConfiguration with 'force: true', writing it on the node,
+// or no flags if the ResetConfiguration is not supported by the kubeadm version on the node.
+func kubeadmResetConfigFlags(c *status.Cluster, n *status.Node) ([]string, error) {
+	// After upgrade, the 'kubeadm version' should return the version of the kubeadm used
+	// to perform the upgrade. Use this version to determine if v1beta4 is enabled.
+	v, err := n.KubeadmVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not obtain the kubeadm version before calling 'kubeadm reset'")
+	}
+	if kubeadm.GetKubeadmConfigVersion(v) != "v1beta4" {
+		return nil, nil
+	}
+	if err := KubeadmResetConfig(c, "", n); err != nil {
+		return nil, errors.Wrap(err, "could not write kubeadm config before calling 'kubeadm reset'")
+	}
+	return []string{"--config", constants.KubeadmConfigPath}, nil
+}
+
+// kubeadmReset executes kubeadm reset on a node
+func kubeadmReset(c *status.Cluster, n *status.Node, vLevel int) error {
+	flags := []string{"reset", fmt.Sprintf("--v=%d", vLevel)}
+
+	// If v1beta4 is enabled, use ResetConfiguration with a 'force: true', else just use the '--force' flag.
+	configFlags, err := kubeadmResetConfigFlags(c, n)
+	if err != nil {
+		return err
+	}
+	if len(configFlags) > 0 {
+		flags = append(flags, configFlags...)
+	} else {
+		flags = append(flags, "--force")
+	}
+
+	return n.Command("kubeadm", flags...).RunWithEcho()
+}
+
+// kubeadmResetWithPhases executes kubeadm reset on a node invoking single phases
+func kubeadmResetWithPhases(c *status.Cluster, n *status.Node, vLevel int) error {
+	// If v1beta4 is enabled, use ResetConfiguration with a 'force: true' for all the phases,
+	// else just use the '--force' flag for the preflight phase.
+	configFlags, err := kubeadmResetConfigFlags(c, n)
+	if err != nil {
+		return err
+	}
+
+	preflightFlags := []string{"reset", "phase", "preflight", fmt.Sprintf("--v=%d", vLevel)}
+	if len(configFlags) > 0 {
+		preflightFlags = append(preflightFlags, configFlags...)
+	} else {
+		preflightFlags = append(preflightFlags, "--force")
+	}
+	if err := n.Command("kubeadm", preflightFlags...).RunWithEcho(); err != nil {
+		return err
+	}
+
+	if n.IsControlPlane() {
+		if err := n.Command(
+			"kubeadm", append([]string{"reset", "phase", "remove-etcd-member", fmt.Sprintf("--v=%d", vLevel)}, configFlags...)...,
+		).RunWithEcho(); err != nil {
 			return err
 		}
 	}
+
+	if err := n.Command(
+		"kubeadm", append([]string{"reset", "phase", "cleanup-node", fmt.Sprintf("--v=%d", vLevel)}, configFlags...)...,
+	).RunWithEcho(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resetPaths are the files and folders that kubeadm reset is expected to clean up. Nb. other files, e.g. files
+// placed by users in /etc/kubernetes/tmp or files in /var/lib/kubelet not owned by kubeadm, are intentionally
+// not checked, because kubeadm reset leaves them in place.
+var resetPaths = []string{
+	"/etc/kubernetes/manifests",
+	"/etc/kubernetes/pki",
+	"/etc/kubernetes/*.conf",
+	"/var/lib/etcd",
+	"/var/lib/kubelet/pki",
+	"/var/lib/kubelet/config.yaml",
+	"/var/lib/kubelet/kubeadm-flags.env",
+}
+
+// kubeadmStaticPods are the names of the containers of the static pods created by kubeadm
+var kubeadmStaticPods = []string{"etcd", "kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+
+// verifyKubeadmReset verifies that kubeadm reset cleaned up a node, checking that no files are left in resetPaths,
+// that no static pod containers are left, and eventually that the etcd member for the node was removed.
+// Any leftover is reported as a failure.
+func verifyKubeadmReset(c *status.Cluster, n *status.Node, checkEtcdMember bool) error {
+	n.Infof("verifying the node was cleaned up")
+	leftovers := []string{}
+
+	// NB. kubeadm reset cleans up the content of the folders, leaving empty folders in place; missing paths,
+	// e.g. /var/lib/etcd on worker nodes, are ignored
+	lines, err := n.Command(
+		"/bin/sh", "-c",
+		fmt.Sprintf("find %s ! -type d 2>/dev/null || true", strings.Join(resetPaths, " ")),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list files on node %s", n.Name())
+	}
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			leftovers = append(leftovers, l)
+		}
+	}
+
+	nodeCRI, err := n.CRI()
+	if err != nil {
+		return err
+	}
+	actionHelper, err := nodes.NewActionHelper(nodeCRI)
+	if err != nil {
+		return err
+	}
+	for _, pod := range kubeadmStaticPods {
+		ids, err := actionHelper.ListContainers(n, pod)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list static pod containers on node %s", n.Name())
+		}
+		for _, id := range ids {
+			leftovers = append(leftovers, fmt.Sprintf("%s container %s", pod, id))
+		}
+	}
+
+	if checkEtcdMember {
+		etcdArgs, err := etcdctlArgs(c)
+		if err != nil {
+			return err
+		}
+		listArgs := append(etcdArgs, "member", "list")
+		lines, err := c.BootstrapControlPlane().Command(
+			"kubectl", listArgs...,
+		).Silent().RunAndCapture()
+		if err != nil {
+			return errors.Wrap(err, "failed to list etcd members")
+		}
+		if id := parseEtcdMemberID(lines, n.Name()); id != "" {
+			leftovers = append(leftovers, fmt.Sprintf("etcd member %s", id))
+		}
+	}
+
+	if len(leftovers) > 0 {
+		return errors.Errorf("kubeadm reset did not clean up node %s, leftovers: %s", n.Name(), strings.Join(leftovers, ", "))
+	}
 	return nil
 }
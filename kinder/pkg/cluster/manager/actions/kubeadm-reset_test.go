@@ -0,0 +1,171 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+func TestKubeadmReset(t *testing.T) {
+	resetPhasesControlPlane := func(node string) []string {
+		return []string{
+			node + ": kubeadm reset phase preflight --v=1 --config /kind/kubeadm.conf",
+			node + ": kubeadm reset phase remove-etcd-member --v=1 --config /kind/kubeadm.conf",
+			node + ": kubeadm reset phase cleanup-node --v=1 --config /kind/kubeadm.conf",
+		}
+	}
+	resetPhasesWorker := func(node string) []string {
+		return []string{
+			node + ": kubeadm reset phase preflight --v=1 --config /kind/kubeadm.conf",
+			node + ": kubeadm reset phase cleanup-node --v=1 --config /kind/kubeadm.conf",
+		}
+	}
+
+	tests := []struct {
+		name             string
+		usePhases        bool
+		onlyNode         string
+		leftoverFiles    []string
+		detectedCRI      string
+		leftoverCommand  string
+		leftoverMember   bool
+		expectedCommands []string
+		// expectedMemberChecks is the number of times the etcd membership is verified
+		expectedMemberChecks int
+		expectedError        string
+	}{
+		{
+			name: "kubeadm reset",
+			expectedCommands: []string{
+				"kinder-control-plane-2: kubeadm reset --v=1 --config /kind/kubeadm.conf",
+				"kinder-worker-1: kubeadm reset --v=1 --config /kind/kubeadm.conf",
+				"kinder-control-plane-1: kubeadm reset --v=1 --config /kind/kubeadm.conf",
+			},
+			expectedMemberChecks: 1,
+		},
+		{
+			name:      "kubeadm reset phases",
+			usePhases: true,
+			expectedCommands: append(append(
+				resetPhasesControlPlane("kinder-control-plane-2"),
+				resetPhasesWorker("kinder-worker-1")...),
+				resetPhasesControlPlane("kinder-control-plane-1")...),
+			expectedMemberChecks: 1,
+		},
+		{
+			name:           "kubeadm reset leaving the etcd member of a secondary control-plane",
+			leftoverMember: true,
+			expectedError:  "kubeadm reset did not clean up node kinder-control-plane-2, leftovers: etcd member 91bc3c398fb3c146",
+		},
+		{
+			name:          "kubeadm reset leaving files",
+			onlyNode:      "kinder-worker-1",
+			leftoverFiles: []string{"/var/lib/kubelet/config.yaml"},
+			expectedError: "leftovers: /var/lib/kubelet/config.yaml",
+		},
+		{
+			name:            "kubeadm reset leaving static pod containers with containerd",
+			onlyNode:        "kinder-control-plane-2",
+			leftoverCommand: "crictl ps -a -q --name=^kube-apiserver$",
+			expectedError:   "leftovers: kube-apiserver container 3f4e1a2b",
+		},
+		{
+			name:            "kubeadm reset leaving static pod containers with docker",
+			onlyNode:        "kinder-control-plane-2",
+			detectedCRI:     "docker",
+			leftoverCommand: "docker ps -a -q --filter=name=k8s_kube-apiserver_",
+			expectedError:   "leftovers: kube-apiserver container 3f4e1a2b",
+		},
+		{
+			name:            "kubeadm reset leaving static pod containers with CRI-O",
+			onlyNode:        "kinder-control-plane-2",
+			detectedCRI:     "crio",
+			leftoverCommand: "crictl ps -a -q --name=^kube-apiserver$",
+			expectedError:   "leftovers: kube-apiserver container 3f4e1a2b",
+		},
+		{
+			name:           "kubeadm reset leaving the etcd member",
+			usePhases:      true,
+			onlyNode:       "kinder-control-plane-2",
+			leftoverMember: true,
+			expectedError:  "leftovers: etcd member 91bc3c398fb3c146",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeCluster(t,
+				fake.Node{Name: "kinder-control-plane-2", Role: constants.ControlPlaneNodeRoleValue, IPv4: "172.17.0.3"},
+				fake.Node{Name: "kinder-worker-1", Role: constants.WorkerNodeRoleValue, IPv4: "172.17.0.4"},
+			)
+			if test.onlyNode != "" {
+				for _, n := range c.K8sNodes() {
+					if n.Name() != test.onlyNode {
+						n.SkipActions()
+					}
+				}
+			}
+			if test.leftoverFiles != nil {
+				p.On(test.onlyNode, "/bin/sh -c find", test.leftoverFiles...)
+			}
+			p.On("", "/bin/sh -c "+status.DetectCRICommand, test.detectedCRI)
+			if test.leftoverCommand != "" {
+				p.On(test.onlyNode, test.leftoverCommand, "3f4e1a2b")
+			}
+			members := []string{
+				"8e9e05c52164694d, started, kinder-control-plane-1, https://172.17.0.2:2380, https://172.17.0.2:2379, false",
+			}
+			if test.leftoverMember {
+				members = append(members, "91bc3c398fb3c146, started, kinder-control-plane-2, https://172.17.0.3:2380, https://172.17.0.3:2379, false")
+			}
+			p.On("kinder-control-plane-1", "kubectl --request-timeout=2", "etcd Version: 3.5.15")
+			p.On("kinder-control-plane-1", "kubectl --kubeconfig=/etc/kubernetes/admin.conf exec -n=kube-system etcd-kinder-control-plane-1 -- etcdctl", members...)
+
+			err := KubeadmReset(c, test.usePhases, 1)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			commands := kubeadmCommands(p)
+			if !reflect.DeepEqual(commands, test.expectedCommands) {
+				t.Errorf("expected commands:\n%s\ngot:\n%s", strings.Join(test.expectedCommands, "\n"), strings.Join(commands, "\n"))
+			}
+
+			memberChecks := 0
+			for _, c := range p.Commands() {
+				if strings.Contains(c.Text, " etcdctl ") && strings.HasSuffix(c.Text, " member list") {
+					memberChecks++
+				}
+			}
+			if memberChecks != test.expectedMemberChecks {
+				t.Errorf("expected the etcd membership to be verified %d times, got %d", test.expectedMemberChecks, memberChecks)
+			}
+		})
+	}
+}
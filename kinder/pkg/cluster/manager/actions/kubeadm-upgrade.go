@@ -19,6 +19,9 @@ package actions
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -32,12 +35,23 @@ import (
 )
 
 // KubeadmUpgrade executes the kubeadm upgrade workflow, including also deployment of new
-// kubeadm/kubelet/kubectl binaries; for sake of simplicity, drain/uncordon when upgrading nodes
-// is not executed.
+// kubeadm/kubelet/kubectl binaries.
+//
+// If usePhases is set, kubeadm upgrade apply/node are executed by invoking single phases, when supported by
+// the kubeadm version.
+//
+// If injectFailure is set, on control-plane nodes kubeadm upgrade is executed a first time with a patch breaking
+// the kube-apiserver, verifying that the upgrade fails and that kubeadm rolls back the static pod manifests;
+// then the patch is removed and kubeadm upgrade is executed again.
+//
+// If drain is set, each node is cordoned and drained before kubeadm upgrade apply/node, and it is uncordoned
+// after upgrading kubelet and kubectl; in this case nodes are fully upgraded one at a time, so the workloads
+// can be rescheduled on other nodes. Otherwise, for sake of simplicity, drain/uncordon is not executed and
+// kubelets are upgraded after upgrading all the nodes with kubeadm.
 //
 // The implementation assumes that the kubeadm/kubelet/kubectl binaries and all the necessary images
 // for the new kubernetes version are available in the /kinder/upgrade/{version} folder.
-func KubeadmUpgrade(c *status.Cluster, upgradeVersion *version.Version, patchesDir, ignorePreflightErrors string, wait time.Duration, vLevel int) (err error) {
+func KubeadmUpgrade(c *status.Cluster, usePhases bool, upgradeVersion *version.Version, patchesDir, ignorePreflightErrors string, injectFailure, drain bool, drainTimeout, wait time.Duration, vLevel int) (err error) {
 	if upgradeVersion == nil {
 		return errors.New("kubeadm-upgrade actions requires the --upgrade-version parameter to be set")
 	}
@@ -85,26 +99,71 @@ func KubeadmUpgrade(c *status.Cluster, upgradeVersion *version.Version, patchesD
 
 		v, err := n.KubeadmVersion()
 		if err != nil {
-			errors.Wrap(err, "could not obtain the kubeadm version before calling kubeadm reset")
+			return errors.Wrap(err, "could not obtain the kubeadm version before calling kubeadm upgrade")
 		}
 		kubeadmConfigVersion := kubeadm.GetKubeadmConfigVersion(v)
 
-		if n.Name() == c.BootstrapControlPlane().Name() {
-			if err := kubeadmUpgradePlan(c, n, kubeadmConfigVersion, upgradeVersion, vLevel); err != nil {
+		// the upgrade failure is injected using a patch, so kubeadm is always instructed to use patches in this case
+		upgradePatchesDir := patchesDir
+		if injectFailure {
+			upgradePatchesDir = constants.PatchesDir
+		}
+
+		upgradeNode := func() error {
+			var upgrade func() error
+			if n.Name() == c.BootstrapControlPlane().Name() {
+				if err := kubeadmUpgradePlan(c, n, kubeadmConfigVersion, upgradeVersion, vLevel); err != nil {
+					return err
+				}
+				if err := kubeadmUpgradeDiff(c, n, kubeadmConfigVersion, upgradeVersion, vLevel); err != nil {
+					return err
+				}
+				upgrade = func() error {
+					return kubeadmUpgradeApply(c, n, kubeadmConfigVersion, upgradeVersion, upgradePatchesDir, kubeadmUpgradeApplyPhases(usePhases, v), wait, vLevel)
+				}
+			} else {
+				upgrade = func() error {
+					return kubeadmUpgradeNode(c, n, kubeadmConfigVersion, upgradeVersion, upgradePatchesDir, kubeadmUpgradeNodePhases(usePhases, v), wait, vLevel)
+				}
+			}
+
+			if injectFailure && n.IsControlPlane() {
+				if err := kubeadmUpgradeRollback(c, n, upgrade, wait); err != nil {
+					return err
+				}
+			}
+			if err := upgrade(); err != nil {
 				return err
 			}
-			if err := kubeadmUpgradeDiff(c, n, kubeadmConfigVersion, upgradeVersion, vLevel); err != nil {
+
+			if drain {
+				return upgradeKubeletKubectl(c, n, upgradeVersion, wait)
+			}
+			return nil
+		}
+
+		if !drain {
+			if err := upgradeNode(); err != nil {
 				return err
 			}
-			err = kubeadmUpgradeApply(c, n, kubeadmConfigVersion, upgradeVersion, patchesDir, wait, vLevel)
-		} else {
-			err = kubeadmUpgradeNode(c, n, kubeadmConfigVersion, upgradeVersion, patchesDir, wait, vLevel)
+			continue
 		}
-		if err != nil {
+
+		if err := drainNode(c.BootstrapControlPlane(), n, drainTimeout); err != nil {
+			return err
+		}
+		if err := upgradeNode(); err != nil {
+			return uncordonNodeAfterFailure(c, n, err)
+		}
+		if err := uncordonNode(c, n, wait); err != nil {
 			return err
 		}
 	}
 
+	if drain {
+		return nil
+	}
+
 	for _, n := range nodeList {
 		if err := upgradeKubeletKubectl(c, n, upgradeVersion, wait); err != nil {
 			return err
@@ -114,6 +173,37 @@ func KubeadmUpgrade(c *status.Cluster, upgradeVersion *version.Version, patchesD
 	return nil
 }
 
+// uncordonNode uncordons a node, using kubectl on the bootstrap control-plane, and waits for the node to become Ready
+func uncordonNode(c *status.Cluster, n *status.Node, wait time.Duration) error {
+	cp1 := c.BootstrapControlPlane()
+
+	cp1.Infof("Uncordoning Node %s", n.Name())
+	if err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "uncordon", n.Name(),
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to uncordon Node %s", n.Name())
+	}
+
+	if n.IsControlPlane() {
+		return waitNewControlPlaneNodeReady(c, n, wait)
+	}
+	return waitNewWorkerNodeReady(c, n, wait)
+}
+
+// uncordonNodeAfterFailure uncordons a drained node after a failed upgrade, so the node is not left unschedulable,
+// and returns the upgrade error; the node might be not Ready, so there is no wait.
+func uncordonNodeAfterFailure(c *status.Cluster, n *status.Node, upgradeErr error) error {
+	cp1 := c.BootstrapControlPlane()
+
+	cp1.Infof("Uncordoning Node %s after the upgrade failure", n.Name())
+	if err := cp1.Command(
+		"kubectl", "--kubeconfig=/etc/kubernetes/admin.conf", "uncordon", n.Name(),
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(upgradeErr, "Node %s is left cordoned, failed to uncordon it: %v", n.Name(), err)
+	}
+	return upgradeErr
+}
+
 func preloadNodeUpgradeImages(n *status.Node, upgradeVersion *version.Version) {
 	srcFolder := filepath.Join("/kinder", "upgrade", fmt.Sprintf("v%s", upgradeVersion))
 
@@ -200,24 +290,63 @@ func kubeadmUpgradePlan(c *status.Cluster, cp1 *status.Node, configVersion strin
 	return nil
 }
 
-func kubeadmUpgradeApply(c *status.Cluster, cp1 *status.Node, configVersion string, upgradeVersion *version.Version, patchesDir string, wait time.Duration, vLevel int) error {
-	applyArgs := []string{
-		"upgrade", "apply", fmt.Sprintf("--v=%d", vLevel),
+// kubeadmUpgradeApplyPhases returns the kubeadm upgrade apply phases to be invoked, if phases should be used and they
+// are supported by the kubeadm version (v1.32+)
+func kubeadmUpgradeApplyPhases(usePhases bool, kubeadmVersion *version.Version) []string {
+	if !usePhases {
+		return nil
+	}
+	if kubeadmVersion.LessThan(version.MustParseSemantic("v1.32.0-0")) {
+		fmt.Printf("kubeadm %s does not support kubeadm upgrade apply phases, using kubeadm upgrade apply\n", kubeadmVersion)
+		return nil
 	}
+	return []string{"preflight", "control-plane", "upload-config all", "kubelet-config", "bootstrap-token", "addon all", "post-upgrade"}
+}
 
-	if configVersion == "v1beta4" {
-		applyArgs = append(applyArgs, "--config", constants.KubeadmConfigPath)
+// kubeadmUpgradeNodePhases returns the kubeadm upgrade node phases to be invoked, if phases should be used;
+// the addon and post-upgrade phases are supported by kubeadm v1.31+ only
+func kubeadmUpgradeNodePhases(usePhases bool, kubeadmVersion *version.Version) []string {
+	if !usePhases {
+		return nil
+	}
+	phases := []string{"preflight", "control-plane", "kubelet-config"}
+	if kubeadmVersion.AtLeast(version.MustParseSemantic("v1.31.0-0")) {
+		phases = append(phases, "addon all", "post-upgrade")
+	}
+	return phases
+}
+
+func kubeadmUpgradeApply(c *status.Cluster, cp1 *status.Node, configVersion string, upgradeVersion *version.Version, patchesDir string, phases []string, wait time.Duration, vLevel int) error {
+	if len(phases) > 0 {
+		// NB. kubeadm upgrade apply phases are supported only by kubeadm versions using v1beta4
+		for _, phase := range phases {
+			phaseArgs := append([]string{"upgrade", "apply", "phase"}, strings.Fields(phase)...)
+			phaseArgs = append(phaseArgs, "--config", constants.KubeadmConfigPath, fmt.Sprintf("--v=%d", vLevel))
+			if err := cp1.Command(
+				"kubeadm", phaseArgs...,
+			).RunWithEcho(); err != nil {
+				return err
+			}
+		}
 	} else {
-		if patchesDir != "" {
-			applyArgs = append(applyArgs, fmt.Sprintf("--patches=%s", constants.PatchesDir))
+		applyArgs := []string{
+			"upgrade", "apply", fmt.Sprintf("--v=%d", vLevel),
 		}
-		applyArgs = append(applyArgs, "-f", fmt.Sprintf("v%s", upgradeVersion.String()))
-	}
 
-	if err := cp1.Command(
-		"kubeadm", applyArgs...,
-	).RunWithEcho(); err != nil {
-		return err
+		if configVersion == "v1beta4" {
+			applyArgs = append(applyArgs, "--config", constants.KubeadmConfigPath)
+		} else {
+			if patchesDir != "" {
+				applyArgs = append(applyArgs, fmt.Sprintf("--patches=%s", constants.PatchesDir))
+			}
+			applyArgs = append(applyArgs, "-f", fmt.Sprintf("v%s", upgradeVersion.String()))
+		}
+
+		if err := cp1.Command(
+			"kubeadm", applyArgs...,
+		).RunWithEcho(); err != nil {
+			return err
+		}
 	}
 
 	if err := waitControlPlaneUpgraded(c, cp1, upgradeVersion, wait); err != nil {
@@ -227,7 +356,7 @@ func kubeadmUpgradeApply(c *status.Cluster, cp1 *status.Node, configVersion stri
 	return nil
 }
 
-func kubeadmUpgradeNode(c *status.Cluster, n *status.Node, configVersion string, upgradeVersion *version.Version, patchesDir string, wait time.Duration, vLevel int) error {
+func kubeadmUpgradeNode(c *status.Cluster, n *status.Node, configVersion string, upgradeVersion *version.Version, patchesDir string, phases []string, wait time.Duration, vLevel int) error {
 	// waitKubeletHasRBAC waits for the kubelet to have access to the expected config map
 	// please note that this is a temporary workaround for a problem we are observing on upgrades while
 	// executing node upgrades immediately after control-plane upgrade.
@@ -235,23 +364,40 @@ func kubeadmUpgradeNode(c *status.Cluster, n *status.Node, configVersion string,
 		return err
 	}
 
-	// kubeadm upgrade node
-	nodeArgs := []string{
-		"upgrade", "node", fmt.Sprintf("--v=%d", vLevel),
-	}
-
-	if configVersion == "v1beta4" {
-		nodeArgs = append(nodeArgs, "--config", constants.KubeadmConfigPath)
+	if len(phases) > 0 {
+		for _, phase := range phases {
+			phaseArgs := append([]string{"upgrade", "node", "phase"}, strings.Fields(phase)...)
+			if configVersion == "v1beta4" {
+				phaseArgs = append(phaseArgs, "--config", constants.KubeadmConfigPath)
+			} else if phase == "control-plane" && patchesDir != "" {
+				phaseArgs = append(phaseArgs, fmt.Sprintf("--patches=%s", constants.PatchesDir))
+			}
+			phaseArgs = append(phaseArgs, fmt.Sprintf("--v=%d", vLevel))
+			if err := n.Command(
+				"kubeadm", phaseArgs...,
+			).RunWithEcho(); err != nil {
+				return err
+			}
+		}
 	} else {
-		if patchesDir != "" {
-			nodeArgs = append(nodeArgs, fmt.Sprintf("--patches=%s", constants.PatchesDir))
+		// kubeadm upgrade node
+		nodeArgs := []string{
+			"upgrade", "node", fmt.Sprintf("--v=%d", vLevel),
 		}
-	}
 
-	if err := n.Command(
-		"kubeadm", nodeArgs...,
-	).RunWithEcho(); err != nil {
-		return err
+		if configVersion == "v1beta4" {
+			nodeArgs = append(nodeArgs, "--config", constants.KubeadmConfigPath)
+		} else {
+			if patchesDir != "" {
+				nodeArgs = append(nodeArgs, fmt.Sprintf("--patches=%s", constants.PatchesDir))
+			}
+		}
+
+		if err := n.Command(
+			"kubeadm", nodeArgs...,
+		).RunWithEcho(); err != nil {
+			return err
+		}
 	}
 
 	if n.IsControlPlane() {
@@ -308,3 +454,141 @@ func upgradeKubeletKubectl(c *status.Cluster, n *status.Node, upgradeVersion *ve
 
 	return nil
 }
+
+// upgradeFailureFlag is an unknown kube-apiserver flag, so the kube-apiserver does not start
+const upgradeFailureFlag = "--kinder-upgrade-failure"
+
+// upgradeFailurePatch is a kubeadm patch adding upgradeFailureFlag to the kube-apiserver, so the kube-apiserver
+// does not start after being upgraded and kubeadm rolls back the control-plane
+const upgradeFailurePatch = `[{"op": "add", "path": "/spec/containers/0/command/-", "value": "` + upgradeFailureFlag + `"}]`
+
+// upgradeFailurePatchFile is the name of the file for upgradeFailurePatch in the patches directory on nodes
+const upgradeFailurePatchFile = "kube-apiserver-kinder-upgrade-failure+json.json"
+
+// kubeadmUpgradeRollback executes kubeadm upgrade with a patch breaking the kube-apiserver, and verifies that
+// the upgrade fails and that kubeadm restores the previous static pod manifests from a backup folder
+// in /etc/kubernetes/tmp.
+// Nb. kubeadm backs up manifests by moving them to the backup folder, and rolls back by moving them back,
+// so after a rollback the backup folder does not contain the restored manifests anymore.
+func kubeadmUpgradeRollback(c *status.Cluster, n *status.Node, upgrade func() error, wait time.Duration) error {
+	// under dry run commands are only printed, so kubeadm upgrade can't fail and the rollback can't be verified
+	if n.IsDryRun() {
+		n.Infof("skipping the injection of an upgrade failure under dry run")
+		return nil
+	}
+
+	manifestsDir := filepath.Join(etcKubernetes, "manifests")
+	patchPath := filepath.Join(constants.PatchesDir, upgradeFailurePatchFile)
+
+	before, err := readStaticPodManifests(n, manifestsDir)
+	if err != nil {
+		return err
+	}
+	previousBackups, err := upgradeBackupDirs(n)
+	if err != nil {
+		return err
+	}
+
+	n.Infof("injecting an upgrade failure for testing the kubeadm rollback")
+	if err := n.WriteFile(patchPath, []byte(upgradeFailurePatch)); err != nil {
+		return err
+	}
+	upgradeErr := upgrade()
+	if err := n.Command("rm", "-f", patchPath).Silent().Run(); err != nil {
+		return errors.Wrapf(err, "failed to remove %s on node %s", patchPath, n.Name())
+	}
+	if upgradeErr == nil {
+		return errors.Errorf("kubeadm upgrade was expected to fail on node %s because of the injected failure", n.Name())
+	}
+
+	n.Infof("kubeadm upgrade failed as expected, verifying the rollback")
+	backups, err := upgradeBackupDirs(n)
+	if err != nil {
+		return err
+	}
+	backupDir := ""
+	for _, dir := range backups {
+		if !slices.Contains(previousBackups, dir) {
+			backupDir = dir
+		}
+	}
+	if backupDir == "" {
+		return errors.Errorf("kubeadm upgrade did not back up the static pod manifests in %s/tmp on node %s", etcKubernetes, n.Name())
+	}
+
+	after, err := readStaticPodManifests(n, manifestsDir)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, name := range sortedKeys(before) {
+		if after[name] != before[name] {
+			failures = append(failures, fmt.Sprintf("%s was not restored", name))
+		}
+	}
+	for _, name := range sortedKeys(after) {
+		if _, ok := before[name]; !ok {
+			failures = append(failures, fmt.Sprintf("%s was not removed", name))
+		}
+	}
+	if strings.Contains(after["kube-apiserver.yaml"], upgradeFailureFlag) {
+		failures = append(failures, fmt.Sprintf("kube-apiserver.yaml contains the injected %s flag", upgradeFailureFlag))
+	}
+	if len(failures) > 0 {
+		return errors.Errorf("kubeadm did not roll back the static pod manifests on node %s: %s", n.Name(), strings.Join(failures, "; "))
+	}
+	fmt.Printf("Static pod manifests on node %s were restored from %s\n", n.Name(), backupDir)
+
+	return waitNewControlPlaneNodeReady(c, n, wait)
+}
+
+// upgradeBackupDirs returns the folders where kubeadm upgrade backed up static pod manifests on a node
+func upgradeBackupDirs(n *status.Node) ([]string, error) {
+	// NB. the folders might not exist, e.g. before the first upgrade
+	lines, err := n.Command(
+		"/bin/sh", "-c", fmt.Sprintf("ls -d %s/tmp/kubeadm-backup-manifests-* 2>/dev/null || true", etcKubernetes),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the backups of the static pod manifests on node %s", n.Name())
+	}
+	dirs := []string{}
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			dirs = append(dirs, l)
+		}
+	}
+	return dirs, nil
+}
+
+// readStaticPodManifests returns the content of the static pod manifests in a folder on a node
+func readStaticPodManifests(n *status.Node, dir string) (map[string]string, error) {
+	lines, err := n.Command("ls", dir).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list static pod manifests in %s on node %s", dir, n.Name())
+	}
+
+	manifests := map[string]string{}
+	for _, l := range lines {
+		name := strings.TrimSpace(l)
+		if !strings.HasSuffix(name, ".yaml") {
+			continue
+		}
+		data, err := readNodeFile(n, filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		manifests[name] = string(data)
+	}
+	return manifests, nil
+}
+
+// sortedKeys returns the keys of a map in alphabetical order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
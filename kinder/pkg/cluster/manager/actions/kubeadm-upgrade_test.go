@@ -0,0 +1,311 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+// upgradeSteps returns the upgrade, drain and uncordon commands executed on nodes, as well as the removal
+// of the patch injecting an upgrade failure
+func upgradeSteps(p *fake.Provider) []string {
+	steps := []string{}
+	for _, c := range p.Commands() {
+		var step string
+		switch {
+		case strings.HasPrefix(c.Text, "kubeadm upgrade apply"), strings.HasPrefix(c.Text, "kubeadm upgrade node"):
+			// flags are omitted
+			step, _, _ = strings.Cut(c.Text, " --")
+		case strings.HasPrefix(c.Text, "kubectl --kubeconfig=/etc/kubernetes/admin.conf drain"):
+			step = "drain " + strings.Fields(c.Text)[3]
+		case strings.HasPrefix(c.Text, "kubectl --kubeconfig=/etc/kubernetes/admin.conf uncordon"):
+			step = "uncordon " + strings.Fields(c.Text)[3]
+		case c.Text == "rm -f /kinder/patches/"+upgradeFailurePatchFile:
+			step = "remove upgrade failure patch"
+		case c.Text == "systemctl restart kubelet":
+			step = "restart kubelet"
+		default:
+			continue
+		}
+		steps = append(steps, c.Node+": "+step)
+	}
+	return steps
+}
+
+func TestKubeadmUpgrade(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: kube-apiserver\n"
+	backupDir := "/etc/kubernetes/tmp/kubeadm-backup-manifests-2026-01-01-00-00-00"
+
+	tests := []struct {
+		name            string
+		usePhases       bool
+		kubeadmVersion  string
+		injectFailure   bool
+		upgradeFailures int
+		previousBackups []string
+		noBackup        bool
+		noRollback      bool
+		drain           bool
+		pdbViolation    bool
+		uncordonFailure bool
+		expectedSteps   []string
+		expectedError   string
+	}{
+		{
+			name: "kubeadm upgrade",
+			expectedSteps: []string{
+				"kinder-control-plane-1: kubeadm upgrade apply",
+				"kinder-worker-1: kubeadm upgrade node",
+				"kinder-control-plane-1: restart kubelet",
+				"kinder-worker-1: restart kubelet",
+			},
+		},
+		{
+			name:      "kubeadm upgrade phases",
+			usePhases: true,
+			expectedSteps: []string{
+				"kinder-control-plane-1: kubeadm upgrade apply",
+				"kinder-worker-1: kubeadm upgrade node phase preflight",
+				"kinder-worker-1: kubeadm upgrade node phase control-plane",
+				"kinder-worker-1: kubeadm upgrade node phase kubelet-config",
+				"kinder-worker-1: kubeadm upgrade node phase addon all",
+				"kinder-worker-1: kubeadm upgrade node phase post-upgrade",
+				"kinder-control-plane-1: restart kubelet",
+				"kinder-worker-1: restart kubelet",
+			},
+		},
+		{
+			name:           "kubeadm upgrade phases with kubeadm supporting upgrade apply phases",
+			usePhases:      true,
+			kubeadmVersion: "v1.32.0",
+			expectedSteps: []string{
+				"kinder-control-plane-1: kubeadm upgrade apply phase preflight",
+				"kinder-control-plane-1: kubeadm upgrade apply phase control-plane",
+				"kinder-control-plane-1: kubeadm upgrade apply phase upload-config all",
+				"kinder-control-plane-1: kubeadm upgrade apply phase kubelet-config",
+				"kinder-control-plane-1: kubeadm upgrade apply phase bootstrap-token",
+				"kinder-control-plane-1: kubeadm upgrade apply phase addon all",
+				"kinder-control-plane-1: kubeadm upgrade apply phase post-upgrade",
+				"kinder-worker-1: kubeadm upgrade node phase preflight",
+				"kinder-worker-1: kubeadm upgrade node phase control-plane",
+				"kinder-worker-1: kubeadm upgrade node phase kubelet-config",
+				"kinder-worker-1: kubeadm upgrade node phase addon all",
+				"kinder-worker-1: kubeadm upgrade node phase post-upgrade",
+				"kinder-control-plane-1: restart kubelet",
+				"kinder-worker-1: restart kubelet",
+			},
+		},
+		{
+			name:            "kubeadm upgrade with injected failure",
+			injectFailure:   true,
+			upgradeFailures: 1,
+			expectedSteps: []string{
+				"kinder-control-plane-1: kubeadm upgrade apply",
+				"kinder-control-plane-1: remove upgrade failure patch",
+				"kinder-control-plane-1: kubeadm upgrade apply",
+				"kinder-worker-1: kubeadm upgrade node",
+				"kinder-control-plane-1: restart kubelet",
+				"kinder-worker-1: restart kubelet",
+			},
+		},
+		{
+			name:          "kubeadm upgrade with injected failure not failing",
+			injectFailure: true,
+			expectedError: "kubeadm upgrade was expected to fail on node kinder-control-plane-1",
+		},
+		{
+			name:            "kubeadm upgrade with injected failure not rolled back",
+			injectFailure:   true,
+			upgradeFailures: 1,
+			noRollback:      true,
+			expectedError:   "kube-apiserver.yaml was not restored; kube-apiserver.yaml contains the injected --kinder-upgrade-failure flag",
+		},
+		{
+			name:            "kubeadm upgrade with injected failure not backed up",
+			injectFailure:   true,
+			upgradeFailures: 1,
+			previousBackups: []string{"/etc/kubernetes/tmp/kubeadm-backup-manifests-2025-01-01-00-00-00"},
+			noBackup:        true,
+			expectedError:   "kubeadm upgrade did not back up the static pod manifests",
+		},
+		{
+			name:            "kubeadm upgrade with injected failure and previous backups",
+			injectFailure:   true,
+			upgradeFailures: 1,
+			previousBackups: []string{"/etc/kubernetes/tmp/kubeadm-backup-manifests-2025-01-01-00-00-00"},
+			expectedSteps: []string{
+				"kinder-control-plane-1: kubeadm upgrade apply",
+				"kinder-control-plane-1: remove upgrade failure patch",
+				"kinder-control-plane-1: kubeadm upgrade apply",
+				"kinder-worker-1: kubeadm upgrade node",
+				"kinder-control-plane-1: restart kubelet",
+				"kinder-worker-1: restart kubelet",
+			},
+		},
+		{
+			name:  "kubeadm upgrade with drain",
+			drain: true,
+			expectedSteps: []string{
+				"kinder-control-plane-1: drain kinder-control-plane-1",
+				"kinder-control-plane-1: kubeadm upgrade apply",
+				"kinder-control-plane-1: restart kubelet",
+				"kinder-control-plane-1: uncordon kinder-control-plane-1",
+				"kinder-control-plane-1: drain kinder-worker-1",
+				"kinder-worker-1: kubeadm upgrade node",
+				"kinder-worker-1: restart kubelet",
+				"kinder-control-plane-1: uncordon kinder-worker-1",
+			},
+		},
+		{
+			name:          "kubeadm upgrade with drain blocked by a PodDisruptionBudget",
+			drain:         true,
+			pdbViolation:  true,
+			expectedError: "PodDisruptionBudgets not allowing disruptions: default/web (healthy 2, desired 2): timeout",
+		},
+		{
+			name:            "kubeadm upgrade with drain failing",
+			drain:           true,
+			upgradeFailures: 2,
+			expectedSteps: []string{
+				"kinder-control-plane-1: drain kinder-control-plane-1",
+				"kinder-control-plane-1: kubeadm upgrade apply",
+				"kinder-control-plane-1: uncordon kinder-control-plane-1",
+			},
+			expectedError: "couldn't upgrade control plane",
+		},
+		{
+			name:            "kubeadm upgrade with drain failing and uncordon failing",
+			drain:           true,
+			upgradeFailures: 2,
+			uncordonFailure: true,
+			expectedError:   "Node kinder-control-plane-1 is left cordoned",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeCluster(t,
+				fake.Node{Name: "kinder-worker-1", Role: constants.WorkerNodeRoleValue, IPv4: "172.17.0.4"},
+			)
+			p.On("", "cat /kinder/upgrade/version", "v1.32.0")
+			if test.kubeadmVersion != "" {
+				p.On("", "kubeadm version -o=short", test.kubeadmVersion)
+			}
+
+			// scripts the static pod manifests, and the backups created by kubeadm upgrade
+			p.On("", "ls /etc/kubernetes/manifests", "kube-apiserver.yaml")
+			p.SetFile("kinder-control-plane-1", "/etc/kubernetes/manifests/kube-apiserver.yaml", []byte(manifest))
+			backups := append([]string{}, test.previousBackups...)
+			p.OnFunc("", "/bin/sh -c ls -d /etc/kubernetes/tmp/kubeadm-backup-manifests-*", func(string) ([]string, error) {
+				return backups, nil
+			})
+
+			failures := test.upgradeFailures
+			patchInjected := false
+			p.OnFunc("kinder-control-plane-1", "kubeadm upgrade apply", func(string) ([]string, error) {
+				if failures == 0 {
+					return nil, nil
+				}
+				failures--
+				if data, ok := p.File("kinder-control-plane-1", "/kinder/patches/"+upgradeFailurePatchFile); ok && string(data) == upgradeFailurePatch {
+					patchInjected = true
+				}
+				// kubeadm moves the manifests to a new backup folder before upgrading, and moves them back
+				// when rolling back, so the backup folder is left empty
+				if !test.noBackup {
+					backups = append(backups, backupDir)
+				}
+				if test.noRollback {
+					p.SetFile("kinder-control-plane-1", "/etc/kubernetes/manifests/kube-apiserver.yaml", []byte(manifest+"spec:\n  containers:\n  - command:\n    - "+upgradeFailureFlag+"\n"))
+				}
+				return nil, errors.New("couldn't upgrade control plane")
+			})
+			if test.pdbViolation {
+				p.OnError("kinder-control-plane-1", "kubectl --kubeconfig=/etc/kubernetes/admin.conf drain", errors.New("timeout"))
+				// only default/web selects pods on the node being drained
+				p.On("kinder-control-plane-1", "kubectl --kubeconfig=/etc/kubernetes/admin.conf get poddisruptionbudgets", `{"items": [
+					{"metadata": {"namespace": "kube-system", "name": "coredns"}, "spec": {"selector": {"matchLabels": {"k8s-app": "kube-dns"}}},
+					 "status": {"disruptionsAllowed": 1, "currentHealthy": 2, "desiredHealthy": 1}},
+					{"metadata": {"namespace": "default", "name": "web"}, "spec": {"selector": {"matchExpressions": [{"key": "app", "operator": "In", "values": ["web"]}]}},
+					 "status": {"disruptionsAllowed": 0, "currentHealthy": 2, "desiredHealthy": 2}},
+					{"metadata": {"namespace": "default", "name": "db"}, "spec": {"selector": {"matchLabels": {"app": "db"}}},
+					 "status": {"disruptionsAllowed": 0, "currentHealthy": 1, "desiredHealthy": 1}}
+				]}`)
+				p.On("kinder-control-plane-1", "kubectl --kubeconfig=/etc/kubernetes/admin.conf get pods --all-namespaces --field-selector=spec.nodeName=kinder-control-plane-1", `{"items": [
+					{"metadata": {"namespace": "kube-system", "name": "coredns-1", "labels": {"k8s-app": "kube-dns"}}},
+					{"metadata": {"namespace": "default", "name": "web-1", "labels": {"app": "web"}}}
+				]}`)
+			}
+
+			if test.uncordonFailure {
+				p.OnError("kinder-control-plane-1", "kubectl --kubeconfig=/etc/kubernetes/admin.conf uncordon", errors.New("timeout"))
+			}
+
+			err := KubeadmUpgrade(c, test.usePhases, version.MustParseSemantic("v1.32.0"), "", "", test.injectFailure, test.drain, 0, 0, 1)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if test.expectedSteps != nil {
+				steps := upgradeSteps(p)
+				if !reflect.DeepEqual(steps, test.expectedSteps) {
+					t.Errorf("expected steps:\n%s\ngot:\n%s", strings.Join(test.expectedSteps, "\n"), strings.Join(steps, "\n"))
+				}
+			}
+			if test.expectedError != "" {
+				return
+			}
+
+			if test.injectFailure && !patchInjected {
+				t.Errorf("expected the upgrade failure patch to be in place when kubeadm upgrade failed")
+			}
+		})
+	}
+}
+
+func TestKubeadmUpgradeRollbackDryRun(t *testing.T) {
+	c, p := newFakeCluster(t)
+	cp1 := c.BootstrapControlPlane()
+	cp1.DryRun()
+
+	upgraded := false
+	err := kubeadmUpgradeRollback(c, cp1, func() error {
+		upgraded = true
+		return nil
+	}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if upgraded {
+		t.Errorf("expected the upgrade with the injected failure to be skipped under dry run")
+	}
+	if _, ok := p.File(cp1.Name(), "/kinder/patches/"+upgradeFailurePatchFile); ok {
+		t.Errorf("expected the upgrade failure patch not to be written under dry run")
+	}
+}
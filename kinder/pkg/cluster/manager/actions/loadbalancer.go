@@ -24,7 +24,6 @@ import (
 
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/constants"
-	"k8s.io/kubeadm/kinder/pkg/cri/host"
 	"k8s.io/kubeadm/kinder/pkg/loadbalancer"
 )
 
@@ -40,6 +39,7 @@ func LoadBalancer(c *status.Cluster, nodes ...*status.Node) error {
 		return nil
 	}
 
+	// backends use IPv6 addresses only in IPv6 clusters, while in dual-stack clusters IPv4 is the primary IP family
 	ipv6 := (c.Settings.IPFamily == status.IPv6Family)
 
 	// collect info about the existing controlplane nodes
@@ -63,7 +63,7 @@ func LoadBalancer(c *status.Cluster, nodes ...*status.Node) error {
 	loadbalancerConfig, err := loadbalancer.Config(&loadbalancer.ConfigData{
 		ControlPlanePort: constants.ControlPlanePort,
 		BackendServers:   backendServers,
-		IPv6:             ipv6,
+		IPv6:             c.Settings.IPFamily != status.IPv4Family,
 	})
 	if err != nil {
 		return errors.Wrap(err, "failed to generate loadbalancer config data")
@@ -77,7 +77,7 @@ func LoadBalancer(c *status.Cluster, nodes ...*status.Node) error {
 	}
 
 	// reload the config
-	if err := host.SendSignal("SIGHUP", lb.Name()); err != nil {
+	if err := lb.Signal("SIGHUP"); err != nil {
 		return errors.Wrap(err, "failed to reload loadbalancer")
 	}
 
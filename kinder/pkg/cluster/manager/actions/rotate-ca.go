@@ -0,0 +1,337 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+// rotateCADir is the folder on the bootstrap control-plane node where the new CA is generated
+const rotateCADir = "/etc/kubernetes/tmp/kinder-rotate-ca"
+
+// kubeletClientCertificate is the file where the kubelet stores its current client certificate and key
+const kubeletClientCertificate = "/var/lib/kubelet/pki/kubelet-client-current.pem"
+
+// RotateCA rotates the cluster CA, implementing the manual procedure for CA rotation, given that kubeadm
+// does not support it. The rotation is executed in stages, and the cluster health is checked after each stage:
+//
+//  1. a new CA is generated on the bootstrap control-plane node
+//  2. a trust bundle with the old and the new CA is distributed to all the nodes, including kubeconfig files
+//     and the cluster-info ConfigMap, and then kubelets and control-plane pods are restarted
+//  3. the new CA becomes the signing CA; leaf certificates and kubeconfig files are re-issued on all the
+//     control-plane nodes, kubelet.conf files and kubelet client certificates are re-issued on all the nodes,
+//     and then kubelets and control-plane pods are restarted
+//  4. the old CA is dropped from the trust bundle, kubelets and control-plane pods are restarted, and
+//     kubelet client certificates are verified against the new CA
+//
+// Please note that only the cluster CA is rotated, while the front-proxy and the etcd CA are left unchanged.
+func RotateCA(c *status.Cluster, wait time.Duration, vLevel int) error {
+	cp1 := c.BootstrapControlPlane()
+
+	// under dry run commands are only printed, so the new CA can't be read from the node and distributed
+	if cp1.IsDryRun() {
+		return errors.New("rotate-ca does not support dry run")
+	}
+
+	caCert := filepath.Join(etcKubernetes, "pki", "ca.crt")
+	caKey := filepath.Join(etcKubernetes, "pki", "ca.key")
+
+	if err := cp1.Command("test", "-f", caKey).Silent().Run(); err != nil {
+		return errors.Errorf("ca.key does not exist on node %s. Rotating the CA is not supported in external CA mode", cp1.Name())
+	}
+
+	endpoint, endpointIPv6, _, err := getControlPlaneAddress(c)
+	if err != nil {
+		return err
+	}
+	if c.Settings.IPFamily == status.IPv6Family {
+		endpoint = endpointIPv6
+	}
+
+	// stage 1: generates the new CA
+	cp1.Infof("generating a new CA")
+	if err := cp1.Command("rm", "-rf", rotateCADir).Silent().Run(); err != nil {
+		return errors.Wrapf(err, "failed to remove %s on node %s", rotateCADir, cp1.Name())
+	}
+	if err := cp1.Command(
+		"kubeadm", "init", "phase", "certs", "ca", fmt.Sprintf("--cert-dir=%s", rotateCADir), fmt.Sprintf("--v=%d", vLevel),
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "failed to generate a new CA on node %s", cp1.Name())
+	}
+
+	oldCA, err := readNodeCertificates(cp1, caCert)
+	if err != nil {
+		return err
+	}
+	newCA, err := readNodeCertificates(cp1, filepath.Join(rotateCADir, "ca.crt"))
+	if err != nil {
+		return err
+	}
+	newCAKey, err := readNodeFile(cp1, filepath.Join(rotateCADir, "ca.key"))
+	if err != nil {
+		return err
+	}
+
+	// stage 2: distributes the trust bundle; the old CA is kept first, because it is still used for signing
+	fmt.Println("Distributing the trust bundle with the old and the new CA...")
+	trustBundle := append(append([]byte{}, oldCA...), newCA...)
+	if err := distributeCA(c, trustBundle); err != nil {
+		return err
+	}
+	if err := rollNodes(c, wait); err != nil {
+		return err
+	}
+
+	// stage 3: re-issues certificates and kubeconfig files using the new CA; the new CA is kept first in the
+	// CA file, because kubeadm and the controller-manager use the first certificate for signing
+	fmt.Println("Re-issuing certificates and kubeconfig files with the new CA...")
+	signingBundle := append(append([]byte{}, newCA...), oldCA...)
+	if err := cp1.WriteFile(caCert, signingBundle); err != nil {
+		return err
+	}
+	if err := cp1.WriteFile(caKey, newCAKey); err != nil {
+		return err
+	}
+	for _, n := range c.ControlPlanes() {
+		if n.Name() != cp1.Name() {
+			if err := copyCAToNode(c, n); err != nil {
+				return err
+			}
+		}
+
+		n.Infof("re-issuing certificates and kubeconfig files")
+		if err := n.Command(
+			"kubeadm", "certs", "renew", "all", fmt.Sprintf("--v=%d", vLevel),
+		).RunWithEcho(); err != nil {
+			return errors.Wrapf(err, "failed to re-issue certificates on node %s", n.Name())
+		}
+		// kubeadm embeds only the signing CA in kubeconfig files, while the old CA is still required
+		// for connecting to API servers not restarted yet
+		if err := setKubeconfigCA(n, trustBundle); err != nil {
+			return err
+		}
+		if err := restartStaticPods(c, n, wait); err != nil {
+			return err
+		}
+	}
+	for _, n := range c.K8sNodes() {
+		if n.IsWorker() {
+			if err := copyCAToNode(c, n); err != nil {
+				return err
+			}
+		}
+
+		n.Infof("re-issuing kubelet.conf")
+		if err := n.Command("rm", "-f", filepath.Join(etcKubernetes, "kubelet.conf")).Silent().Run(); err != nil {
+			return errors.Wrapf(err, "failed to remove kubelet.conf on node %s", n.Name())
+		}
+		if err := generateKubeletConf(n, endpoint, vLevel); err != nil {
+			return err
+		}
+		if err := setKubeconfigCA(n, trustBundle); err != nil {
+			return err
+		}
+		// the kubelet uses the client certificate it stored before the one embedded in kubelet.conf, so the
+		// stored certificate, signed by the old CA, is removed; after the restart the kubelet stores the
+		// certificate from kubelet.conf instead
+		if err := n.Command("rm", "-f", kubeletClientCertificate).Silent().Run(); err != nil {
+			return errors.Wrapf(err, "failed to remove the kubelet client certificate on node %s", n.Name())
+		}
+
+		// the CA key is required on control-plane nodes only
+		if n.IsWorker() {
+			if err := n.Command("rm", "-f", caKey).Silent().Run(); err != nil {
+				return errors.Wrapf(err, "could not delete ca.key on node: %s", n.Name())
+			}
+		}
+
+		if err := restartKubelet(c, n, wait); err != nil {
+			return err
+		}
+	}
+
+	// stage 4: drops the old CA
+	fmt.Println("Dropping the old CA...")
+	if err := distributeCA(c, newCA); err != nil {
+		return err
+	}
+	if err := rollNodes(c, wait); err != nil {
+		return err
+	}
+	for _, n := range c.K8sNodes() {
+		if err := verifyKubeletClientCertificate(n, newCA); err != nil {
+			return err
+		}
+	}
+
+	if err := cp1.Command("rm", "-rf", rotateCADir).Silent().Run(); err != nil {
+		return errors.Wrapf(err, "failed to remove %s on node %s", rotateCADir, cp1.Name())
+	}
+
+	// updates the kubeconfig file on the host, so it trusts the new CA
+	return copyKubeConfigToHost(c)
+}
+
+// distributeCA writes the CA file on all the nodes, embeds the CA in kubeconfig files on all the nodes, and
+// updates the CA in the cluster-info ConfigMap, used for discovery
+func distributeCA(c *status.Cluster, ca []byte) error {
+	for _, n := range c.K8sNodes() {
+		n.Infof("updating the CA")
+		if err := n.WriteFile(filepath.Join(etcKubernetes, "pki", "ca.crt"), ca); err != nil {
+			return err
+		}
+		if err := setKubeconfigCA(n, ca); err != nil {
+			return err
+		}
+	}
+
+	// NB. the bootstrap signer controller takes care of signing again the updated kubeconfig
+	if err := c.BootstrapControlPlane().Command(
+		"/bin/sh", "-c",
+		fmt.Sprintf("kubectl --kubeconfig=/etc/kubernetes/admin.conf get configmap cluster-info -n=kube-public -o=yaml | "+
+			"sed 's#certificate-authority-data: .*#certificate-authority-data: %s#' | "+
+			"kubectl --kubeconfig=/etc/kubernetes/admin.conf replace -f -", base64.StdEncoding.EncodeToString(ca)),
+	).RunWithEcho(); err != nil {
+		return errors.Wrap(err, "failed to update the cluster-info ConfigMap")
+	}
+	return nil
+}
+
+// setKubeconfigCA embeds the CA in all the kubeconfig files on a node
+func setKubeconfigCA(n *status.Node, ca []byte) error {
+	if err := n.Command(
+		"/bin/sh", "-c",
+		fmt.Sprintf("sed -i 's#certificate-authority-data: .*#certificate-authority-data: %s#' %s/*.conf",
+			base64.StdEncoding.EncodeToString(ca), etcKubernetes),
+	).Silent().Run(); err != nil {
+		return errors.Wrapf(err, "failed to update the CA in kubeconfig files on node %s", n.Name())
+	}
+	return nil
+}
+
+// rollNodes restarts the control-plane pods and the kubelet on all the nodes, waiting for them to become healthy again
+func rollNodes(c *status.Cluster, wait time.Duration) error {
+	for _, n := range c.ControlPlanes() {
+		if err := restartStaticPods(c, n, wait); err != nil {
+			return err
+		}
+	}
+	for _, n := range c.K8sNodes() {
+		if err := restartKubelet(c, n, wait); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restartKubelet restarts the kubelet on a node and waits for it to renew its lease
+func restartKubelet(c *status.Cluster, n *status.Node, wait time.Duration) error {
+	now, err := nodeTime(n)
+	if err != nil {
+		return err
+	}
+
+	n.Infof("restarting the kubelet")
+	if err := n.Command(
+		"systemctl", "restart", "kubelet",
+	).Silent().Run(); err != nil {
+		return errors.Wrapf(err, "failed to restart the kubelet on node %s", n.Name())
+	}
+
+	return waitKubeletRestarted(c, n, now, wait)
+}
+
+// verifyKubeletClientCertificate verifies that the client certificate used by the kubelet on a node is signed by the CA
+func verifyKubeletClientCertificate(n *status.Node, ca []byte) error {
+	data, err := readNodeFile(n, kubeletClientCertificate)
+	if err != nil {
+		return err
+	}
+
+	// NB. the file contains both the client certificate and its key
+	var cert *x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			if cert, err = x509.ParseCertificate(block.Bytes); err != nil {
+				return errors.Wrapf(err, "invalid kubelet client certificate on node %s", n.Name())
+			}
+			break
+		}
+	}
+	if cert == nil {
+		return errors.Errorf("no kubelet client certificate found in %s on node %s", kubeletClientCertificate, n.Name())
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return errors.New("invalid CA")
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return errors.Wrapf(err, "the kubelet client certificate on node %s is not signed by the new CA", n.Name())
+	}
+	n.Infof("the kubelet client certificate is signed by the new CA")
+	return nil
+}
+
+// readNodeCertificates reads a file from a node, verifying that it contains only PEM encoded certificates
+func readNodeCertificates(n *status.Node, path string) ([]byte, error) {
+	data, err := readNodeFile(n, path)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := data
+	for count := 0; ; count++ {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			if count == 0 || len(bytes.TrimSpace(rest)) > 0 {
+				return nil, errors.Errorf("%s on node %s does not contain valid PEM encoded certificates", path, n.Name())
+			}
+			return data, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, errors.Errorf("%s on node %s contains an unexpected %s PEM block", path, n.Name(), block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return nil, errors.Wrapf(err, "invalid certificate in %s on node %s", path, n.Name())
+		}
+	}
+}
+
+// readNodeFile reads a file from a node
+func readNodeFile(n *status.Node, path string) ([]byte, error) {
+	lines, err := n.Command("cat", path).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s on node %s", path, n.Name())
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actions
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+// fakeCA returns a CA valid at the current time, PEM encoded, together with its certificate and key
+func fakeCA(t *testing.T, name string) ([]byte, *x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), cert, key
+}
+
+// fakeKubeletClientCertificate returns a kubelet client certificate signed by the CA, followed by its key,
+// like in the file where the kubelet stores its current client certificate
+func fakeKubeletClientCertificate(t *testing.T, node string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "system:node:" + node, Organization: []string{"system:nodes"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})...,
+	)
+}
+
+// rotateCASteps returns the steps of the CA rotation executed on nodes
+func rotateCASteps(p *fake.Provider) []string {
+	steps := []string{}
+	for _, c := range p.Commands() {
+		var step string
+		switch {
+		case strings.HasPrefix(c.Text, "kubectl get leases"):
+			// NB. leases are read on the bootstrap control-plane node, so the step is reported for the
+			// node the lease belongs to
+			steps = append(steps, strings.Fields(c.Text)[5]+": kubelet lease renewed")
+			continue
+		case strings.HasPrefix(c.Text, "kubeadm init phase certs ca"):
+			step = "generate CA"
+		case strings.Contains(c.Text, "configmap cluster-info"):
+			step = "update cluster-info"
+		case strings.HasPrefix(c.Text, "kubeadm certs renew all"):
+			step = "renew certificates"
+		case strings.Contains(c.Text, "kubeadm init phase kubeconfig kubelet"):
+			step = "generate kubelet.conf"
+		case strings.Contains(c.Text, "mv /etc/kubernetes/manifests/*.yaml"):
+			step = "restart static pods"
+		case c.Text == "rm -f "+kubeletClientCertificate:
+			step = "remove kubelet client certificate"
+		case c.Text == "systemctl restart kubelet":
+			step = "restart kubelet"
+		default:
+			continue
+		}
+		steps = append(steps, c.Node+": "+step)
+	}
+	return steps
+}
+
+func TestRotateCA(t *testing.T) {
+	defer func(d time.Duration) { staticPodRestartDelay = d }(staticPodRestartDelay)
+	staticPodRestartDelay = 0
+
+	concat := func(steps ...[]string) []string {
+		all := []string{}
+		for _, s := range steps {
+			all = append(all, s...)
+		}
+		return all
+	}
+	rollNodesSteps := []string{
+		"kinder-control-plane-1: restart static pods",
+		"kinder-control-plane-2: restart static pods",
+		"kinder-control-plane-1: restart kubelet",
+		"kinder-control-plane-1: kubelet lease renewed",
+		"kinder-control-plane-2: restart kubelet",
+		"kinder-control-plane-2: kubelet lease renewed",
+		"kinder-worker-1: restart kubelet",
+		"kinder-worker-1: kubelet lease renewed",
+	}
+	allSteps := concat(
+		[]string{
+			"kinder-control-plane-1: generate CA",
+			"kinder-control-plane-1: update cluster-info",
+		},
+		rollNodesSteps,
+		[]string{
+			"kinder-control-plane-1: renew certificates",
+			"kinder-control-plane-1: restart static pods",
+			"kinder-control-plane-2: renew certificates",
+			"kinder-control-plane-2: restart static pods",
+			"kinder-control-plane-1: generate kubelet.conf",
+			"kinder-control-plane-1: remove kubelet client certificate",
+			"kinder-control-plane-1: restart kubelet",
+			"kinder-control-plane-1: kubelet lease renewed",
+			"kinder-control-plane-2: generate kubelet.conf",
+			"kinder-control-plane-2: remove kubelet client certificate",
+			"kinder-control-plane-2: restart kubelet",
+			"kinder-control-plane-2: kubelet lease renewed",
+			"kinder-worker-1: generate kubelet.conf",
+			"kinder-worker-1: remove kubelet client certificate",
+			"kinder-worker-1: restart kubelet",
+			"kinder-worker-1: kubelet lease renewed",
+			"kinder-control-plane-1: update cluster-info",
+		},
+		rollNodesSteps,
+	)
+
+	// without waiting, leases are not checked
+	withoutLeases := func(steps []string) []string {
+		filtered := []string{}
+		for _, s := range steps {
+			if !strings.HasSuffix(s, ": kubelet lease renewed") {
+				filtered = append(filtered, s)
+			}
+		}
+		return filtered
+	}
+
+	oldCA, oldCACert, oldCAKey := fakeCA(t, "old-ca")
+	newCA, newCACert, newCAKey := fakeCA(t, "new-ca")
+
+	tests := []struct {
+		name                         string
+		externalCA                   bool
+		invalidNewCA                 bool
+		keepKubeletClientCertificate bool
+		dryRun                       bool
+		wait                         time.Duration
+		expectedSteps                []string
+		expectedError                string
+	}{
+		{
+			name:          "rotate the CA",
+			wait:          10 * time.Second,
+			expectedSteps: allSteps,
+		},
+		{
+			name:                         "rotate the CA with kubelets using client certificates signed by the old CA",
+			keepKubeletClientCertificate: true,
+			expectedSteps:                withoutLeases(allSteps),
+			expectedError:                "is not signed by the new CA",
+		},
+		{
+			name:          "rotate the CA in external CA mode",
+			externalCA:    true,
+			expectedSteps: []string{},
+			expectedError: "not supported in external CA mode",
+		},
+		{
+			name:         "rotate the CA with an invalid new CA",
+			invalidNewCA: true,
+			expectedSteps: []string{
+				"kinder-control-plane-1: generate CA",
+			},
+			expectedError: "does not contain valid PEM encoded certificates",
+		},
+		{
+			name:          "dry run",
+			dryRun:        true,
+			expectedSteps: []string{},
+			expectedError: "does not support dry run",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeCluster(t,
+				fake.Node{Name: "kinder-control-plane-2", Role: constants.ControlPlaneNodeRoleValue, IPv4: "172.17.0.3"},
+				fake.Node{Name: "kinder-worker-1", Role: constants.WorkerNodeRoleValue, IPv4: "172.17.0.4"},
+			)
+			p.On("", "date +%s", "1767225600")
+			p.On("", "kubectl get leases", "'2026-01-01T00:01:00Z'")
+			p.On("", "kubectl get nodes", "'True'")
+			p.On("", "ls /etc/kubernetes/manifests", "kube-apiserver.yaml", "kube-controller-manager.yaml", "kube-scheduler.yaml")
+			p.On("", "kubectl get pods", "'2026-01-01T00:01:00Z True'")
+			if test.externalCA {
+				p.OnError("kinder-control-plane-1", "test -f /etc/kubernetes/pki/ca.key", errors.New("missing"))
+			}
+			p.SetFile("kinder-control-plane-1", "/etc/kubernetes/pki/ca.crt", oldCA)
+			p.SetFile("kinder-control-plane-1", "/etc/kubernetes/pki/ca.key", []byte("old-ca-key\n"))
+			p.SetFile("kinder-control-plane-1", filepath.Join(rotateCADir, "ca.crt"), newCA)
+			p.SetFile("kinder-control-plane-1", filepath.Join(rotateCADir, "ca.key"), []byte("new-ca-key\n"))
+			if test.invalidNewCA {
+				// e.g. the file is empty because it was not read
+				p.SetFile("kinder-control-plane-1", filepath.Join(rotateCADir, "ca.crt"), []byte("\n"))
+			}
+			for _, n := range []string{"kinder-control-plane-1", "kinder-control-plane-2", "kinder-worker-1"} {
+				node := n
+				p.SetFile(node, kubeletClientCertificate, fakeKubeletClientCertificate(t, node, oldCACert, oldCAKey))
+				// after the client certificate is removed, the kubelet stores the one embedded in kubelet.conf
+				removed := false
+				p.OnFunc(node, "rm -f "+kubeletClientCertificate, func(string) ([]string, error) {
+					removed = !test.keepKubeletClientCertificate
+					return nil, nil
+				})
+				p.OnFunc(node, "systemctl restart kubelet", func(string) ([]string, error) {
+					if removed {
+						p.SetFile(node, kubeletClientCertificate, fakeKubeletClientCertificate(t, node, newCACert, newCAKey))
+						removed = false
+					}
+					return nil, nil
+				})
+			}
+			if test.dryRun {
+				for _, n := range c.AllNodes() {
+					n.DryRun()
+				}
+			}
+
+			err := RotateCA(c, test.wait, 1)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			steps := rotateCASteps(p)
+			if !reflect.DeepEqual(steps, test.expectedSteps) {
+				t.Errorf("expected steps:\n%s\ngot:\n%s", strings.Join(test.expectedSteps, "\n"), strings.Join(steps, "\n"))
+			}
+			if test.keepKubeletClientCertificate {
+				return
+			}
+			if test.expectedError != "" {
+				// the CA on the bootstrap control-plane node should be unchanged
+				if data, _ := p.File("kinder-control-plane-1", "/etc/kubernetes/pki/ca.crt"); !bytes.Equal(data, oldCA) {
+					t.Errorf("expected ca.crt to be the old CA, got %q", data)
+				}
+				return
+			}
+
+			// at the end of the rotation, only the new CA is trusted, and the new CA key is used for signing
+			for _, n := range []string{"kinder-control-plane-1", "kinder-control-plane-2", "kinder-worker-1"} {
+				if data, _ := p.File(n, "/etc/kubernetes/pki/ca.crt"); strings.TrimSpace(string(data)) != strings.TrimSpace(string(newCA)) {
+					t.Errorf("expected ca.crt on node %s to be the new CA, got %q", n, data)
+				}
+			}
+			for _, n := range []string{"kinder-control-plane-1", "kinder-control-plane-2"} {
+				if data, _ := p.File(n, "/etc/kubernetes/pki/ca.key"); strings.TrimSpace(string(data)) != "new-ca-key" {
+					t.Errorf("expected ca.key on node %s to be the new CA key, got %q", n, data)
+				}
+			}
+		})
+	}
+}
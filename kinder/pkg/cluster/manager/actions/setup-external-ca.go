@@ -29,10 +29,13 @@ func SetupExternalCA(c *status.Cluster, vLevel int) error {
 	fmt.Println("Setuping external CA for the cluster...")
 
 	// gets the IP of the load balancer
-	loadBalancerIP, _, err := c.ExternalLoadBalancer().IP()
+	loadBalancerIP, loadBalancerIPv6, err := c.ExternalLoadBalancer().IP()
 	if err != nil {
 		return errors.Wrapf(err, "failed to get IP for node: %s", c.ExternalLoadBalancer().Name())
 	}
+	if c.Settings.IPFamily == status.IPv6Family {
+		loadBalancerIP = loadBalancerIPv6
+	}
 
 	// generate certs on the primary node
 	// ensure that "localhost" is included for the SANs for the kube-apiserver serving certificate,
@@ -56,34 +59,6 @@ func SetupExternalCA(c *status.Cluster, vLevel int) error {
 		return errors.Wrapf(err, "could not generate kubeconfig files on node: %s", c.BootstrapControlPlane().Name())
 	}
 
-	// Create a function to generate a kubelet.conf using the CA on a node.
-	// This is required since without a CA key in the cluster, there is no authority
-	// to sign the CSRs for new joining kubelets. Normally users should install
-	// an external signer or manage the kubelet.conf files manually.
-	generateKubeletConf := func(n *status.Node) error {
-		if err := n.Command(
-			"/bin/sh", "-c",
-			fmt.Sprintf("kubeadm init phase kubeconfig kubelet --control-plane-endpoint=%s --v=%d",
-				loadBalancerIP,
-				vLevel),
-		).RunWithEcho(); err != nil {
-			return errors.Wrapf(err, "could not generate a kubelet.conf on node: %s", n.Name())
-		}
-		return nil
-	}
-
-	generateKubeletConfWorker := func(n *status.Node) error {
-		if err := n.Command(
-			"/bin/sh", "-c",
-			fmt.Sprintf("kubeadm init phase kubeconfig kubelet --control-plane-endpoint=%s --apiserver-advertise-address=%s --v=%d",
-				loadBalancerIP, loadBalancerIP,
-				vLevel),
-		).RunWithEcho(); err != nil {
-			return errors.Wrapf(err, "could not generate a kubelet.conf on node: %s", n.Name())
-		}
-		return nil
-	}
-
 	// iterate secondary CP nodes
 	for _, n := range c.SecondaryControlPlanes() {
 		// copy the shared kubeconfig files
@@ -107,7 +82,7 @@ func SetupExternalCA(c *status.Cluster, vLevel int) error {
 		}
 
 		// generate kubelet.conf
-		if err := generateKubeletConf(n); err != nil {
+		if err := generateKubeletConf(n, loadBalancerIP, vLevel); err != nil {
 			return err
 		}
 	}
@@ -120,7 +95,7 @@ func SetupExternalCA(c *status.Cluster, vLevel int) error {
 		}
 
 		// generate kubelet.conf
-		if err := generateKubeletConfWorker(n); err != nil {
+		if err := generateKubeletConf(n, loadBalancerIP, vLevel); err != nil {
 			return err
 		}
 	}
@@ -134,3 +109,23 @@ func SetupExternalCA(c *status.Cluster, vLevel int) error {
 
 	return nil
 }
+
+// generateKubeletConf generates a kubelet.conf using the CA on a node.
+// In external CA mode this is required since without a CA key in the cluster, there is no authority
+// to sign the CSRs for new joining kubelets. Normally users should install
+// an external signer or manage the kubelet.conf files manually.
+func generateKubeletConf(n *status.Node, controlPlaneEndpoint string, vLevel int) error {
+	flags := fmt.Sprintf("--control-plane-endpoint=%s", controlPlaneEndpoint)
+	if n.IsWorker() {
+		// there is no API server on worker nodes, so the advertise address is set to the control-plane endpoint
+		flags += fmt.Sprintf(" --apiserver-advertise-address=%s", controlPlaneEndpoint)
+	}
+
+	if err := n.Command(
+		"/bin/sh", "-c",
+		fmt.Sprintf("kubeadm init phase kubeconfig kubelet %s --v=%d", flags, vLevel),
+	).RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "could not generate a kubelet.conf on node: %s", n.Name())
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ package actions
 import (
 	"fmt"
 	"math/rand"
+	"net"
 	"regexp"
 	"strings"
 	"time"
@@ -122,6 +123,33 @@ func waitKubeletHasRBAC(c *status.Cluster, n *status.Node, upgradeVersion *K8sVe
 	return nil
 }
 
+// waitStaticPodsRestarted waits for static pods on a node to be restarted after the given time, and to become Ready
+func waitStaticPodsRestarted(c *status.Cluster, n *status.Node, pods []string, since time.Time, wait time.Duration) error {
+	n.Infof("waiting for static Pods to restart and become Ready (timeout %s)", wait)
+	conditions := []try{}
+	for _, pod := range pods {
+		conditions = append(conditions, staticPodRestarted(pod, since))
+	}
+	if pass := waitFor(c, n, wait, conditions...); !pass {
+		return errors.New("timeout: static Pods did not reach target state")
+	}
+	fmt.Println()
+	return nil
+}
+
+// waitKubeletRestarted waits for the kubelet on a node to renew its lease after the given time, and for the node to be Ready
+func waitKubeletRestarted(c *status.Cluster, n *status.Node, since time.Time, wait time.Duration) error {
+	n.Infof("waiting for the kubelet to restart and renew its lease (timeout %s)", wait)
+	if pass := waitFor(c, n, wait,
+		kubeletLeaseRenewed(since),
+		nodeIsReady,
+	); !pass {
+		return errors.New("timeout: Node did not reach target state")
+	}
+	fmt.Println()
+	return nil
+}
+
 // try defines a function that test a condition to be waited for
 type try func(*status.Cluster, *status.Node) bool
 
@@ -236,6 +264,55 @@ func staticPodIsReady(pod string) func(c *status.Cluster, n *status.Node) bool {
 	}
 }
 
+// staticPodRestarted implement a function that test when a static pod is ready after being restarted after the given time
+func staticPodRestarted(pod string, since time.Time) func(c *status.Cluster, n *status.Node) bool {
+	return func(c *status.Cluster, n *status.Node) bool {
+		output := kubectlOutput(c.BootstrapControlPlane(),
+			"get",
+			"pods",
+			"--kubeconfig=/etc/kubernetes/admin.conf",
+			"-n=kube-system",
+			// check for static pods existing on the selected node
+			fmt.Sprintf("%s-%s", pod, n.Name()),
+			// check for the container start time and for status.conditions type:Ready
+			// NB. this assumes the Pod has only one container only
+			// which is true for the control plane pods
+			"-o=jsonpath='{.status.containerStatuses[0].state.running.startedAt} {.status.conditions[?(@.type == \"Ready\")].status}'",
+		)
+		fields := strings.Fields(strings.Trim(output, "'"))
+		if len(fields) != 2 || fields[1] != "True" {
+			return false
+		}
+		startedAt, err := time.Parse(time.RFC3339, fields[0])
+		if err != nil || startedAt.Before(since) {
+			return false
+		}
+		fmt.Printf("Pod %s-%s restarted and is ready\n", pod, n.Name())
+		return true
+	}
+}
+
+// kubeletLeaseRenewed implement a function that test when the kubelet renewed its lease after the given time
+func kubeletLeaseRenewed(since time.Time) func(c *status.Cluster, n *status.Node) bool {
+	return func(c *status.Cluster, n *status.Node) bool {
+		output := kubectlOutput(c.BootstrapControlPlane(),
+			"get",
+			"leases",
+			"--kubeconfig=/etc/kubernetes/admin.conf",
+			"-n=kube-node-lease",
+			// check for the lease of the selected node
+			n.Name(),
+			"-o=jsonpath='{.spec.renewTime}'",
+		)
+		renewTime, err := time.Parse(time.RFC3339, strings.Trim(output, "'"))
+		if err != nil || renewTime.Before(since) {
+			return false
+		}
+		fmt.Printf("Kubelet on node %s renewed its lease\n", n.Name())
+		return true
+	}
+}
+
 func podsAreRunning(n *status.Node, label string, replicas int) func(c *status.Cluster, n *status.Node) bool {
 	return func(c *status.Cluster, n *status.Node) bool {
 		output := kubectlOutput(n,
@@ -273,13 +350,15 @@ func podsAreRunning(n *status.Node, label string, replicas int) func(c *status.C
 func nodePortIsReady(n *status.Node, port string) func(c *status.Cluster, n *status.Node) bool {
 	return func(c *status.Cluster, n *status.Node) bool {
 
-		//TODO: test IPV6
-		ip, _, err := n.IP()
+		ip, ipv6, err := n.IP()
 		if err != nil {
 			return false
 		}
+		if c.Settings.IPFamily == status.IPv6Family {
+			ip = ipv6
+		}
 		lines, err := n.Command(
-			"curl", "-Is", fmt.Sprintf("http://%s:%s", ip, port),
+			"curl", "-Is", fmt.Sprintf("http://%s", net.JoinHostPort(ip, port)),
 		).Silent().RunAndCapture()
 
 		if err != nil || len(lines) < 1 {
@@ -19,6 +19,7 @@ package manager
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,11 +28,15 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	"k8s.io/kubeadm/kinder/pkg/cluster/config"
+	"k8s.io/kubeadm/kinder/pkg/cluster/manager/actions"
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/constants"
 	"k8s.io/kubeadm/kinder/pkg/cri/host"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // CreateOptions holds all the options used at create time
@@ -41,8 +46,14 @@ type CreateOptions struct {
 	image                string
 	externalLoadBalancer bool
 	externalEtcd         bool
+	controlPlaneImage    string
+	workerImage          string
 	retain               bool
 	volumes              []string
+	nodes                []config.Node
+	ipFamily             status.ClusterIPFamily
+	subnet               string
+	kubeadm              status.KubeadmSettings
 }
 
 // CreateOption is a configuration option supplied to Create
@@ -69,6 +80,20 @@ func Image(image string) CreateOption {
 	}
 }
 
+// ControlPlaneImage sets the image for control-plane nodes, overriding the default image
+func ControlPlaneImage(image string) CreateOption {
+	return func(c *CreateOptions) {
+		c.controlPlaneImage = image
+	}
+}
+
+// WorkerImage sets the image for worker nodes, overriding the default image
+func WorkerImage(image string) CreateOption {
+	return func(c *CreateOptions) {
+		c.workerImage = image
+	}
+}
+
 // ExternalEtcd instruct create to add an external etcd to the cluster
 func ExternalEtcd(externalEtcd bool) CreateOption {
 	return func(c *CreateOptions) {
@@ -99,6 +124,49 @@ func Volumes(volumes []string) CreateOption {
 	}
 }
 
+// IPFamily option instructs create cluster to use the given IP family; IPv6 and dual-stack clusters
+// are created on an IPv6 enabled docker network
+func IPFamily(ipFamily status.ClusterIPFamily) CreateOption {
+	return func(c *CreateOptions) {
+		c.ipFamily = ipFamily
+	}
+}
+
+// Subnet option instructs create cluster to use the given subnet for the docker network dedicated to the cluster
+func Subnet(subnet string) CreateOption {
+	return func(c *CreateOptions) {
+		c.subnet = subnet
+	}
+}
+
+// Kubeadm option sets the kubeadm settings to be stored in the cluster; those settings are used
+// by kinder actions as a default when the corresponding flags are not set
+func Kubeadm(settings status.KubeadmSettings) CreateOption {
+	return func(c *CreateOptions) {
+		c.kubeadm = settings
+	}
+}
+
+// Config option instructs create cluster to use the topology defined in a kinder cluster config;
+// the number of control-plane and worker nodes is derived from the config, while the default
+// image, external etcd and external load balancer are set only if defined in the config
+func Config(cfg *config.Cluster) CreateOption {
+	return func(c *CreateOptions) {
+		c.nodes = cfg.Nodes
+		c.controlPlanes = cfg.ControlPlanes()
+		c.workers = cfg.Workers()
+		if cfg.Image != "" {
+			c.image = cfg.Image
+		}
+		if cfg.ExternalEtcd {
+			c.externalEtcd = true
+		}
+		if cfg.ExternalLoadBalancer {
+			c.externalLoadBalancer = true
+		}
+	}
+}
+
 // CreateCluster creates a new kinder cluster
 func CreateCluster(clusterName string, options ...CreateOption) error {
 	flags := &CreateOptions{}
@@ -106,6 +174,10 @@ func CreateCluster(clusterName string, options ...CreateOption) error {
 		o(flags)
 	}
 
+	if flags.ipFamily == "" {
+		flags.ipFamily = status.IPv4Family
+	}
+
 	// Check if the cluster name already exists
 	known, err := status.IsKnown(clusterName)
 	if err != nil {
@@ -115,29 +187,31 @@ func CreateCluster(clusterName string, options ...CreateOption) error {
 		return errors.Errorf("a cluster with the name %q already exists", clusterName)
 	}
 
+	// compute the desired nodes, and checks they have a node image
+	desiredNodes := nodesToCreate(clusterName, flags)
+	images, err := nodeImages(desiredNodes)
+	if err != nil {
+		return err
+	}
+
 	fmt.Printf("Creating cluster %q ...\n", clusterName)
 
-	// attempt to explicitly pull the required node image if it doesn't exist locally
+	// attempt to explicitly pull the required node images if they don't exist locally
 	// we don't care if this errors, we'll still try to run which also pulls
-	ensureNodeImage(flags.image)
+	for _, image := range images {
+		ensureNodeImage(image)
+	}
 
 	handleErr := func(err error) error {
 		// In case of errors nodes are deleted (except if retain is explicitly set)
 		if !flags.retain {
-			if c, err := status.FromDocker(clusterName); err != nil {
+			if c, err := status.Discover(clusterName); err != nil {
 				log.Error(err)
-			} else {
-				for _, n := range c.AllNodes() {
-					if err := exec.NewHostCmd(
-						"docker",
-						"rm",
-						"-f", // force the container to be deleted now
-						"-v", // delete volumes
-						n.Name(),
-					).Run(); err != nil {
-						return errors.Wrapf(err, "failed to delete node %s", n.Name())
-					}
-				}
+			} else if err := deleteNodes(c.AllNodes()); err != nil {
+				return err
+			}
+			if err := common.DeleteNetwork(clusterName); err != nil {
+				return err
 			}
 		}
 		log.Error(err)
@@ -148,6 +222,7 @@ func CreateCluster(clusterName string, options ...CreateOption) error {
 	if err := createNodes(
 		clusterName,
 		flags,
+		desiredNodes,
 	); err != nil {
 		return handleErr(errors.Wrap(err, "error creating nodes"))
 	}
@@ -159,48 +234,177 @@ func CreateCluster(clusterName string, options ...CreateOption) error {
 	return nil
 }
 
-func createNodes(clusterName string, flags *CreateOptions) error {
-	// compute the desired nodes, and inform the user that we are setting them up
-	desiredNodes := nodesToCreate(clusterName, flags)
-	numberOfNodes := len(desiredNodes)
-	if flags.externalEtcd {
-		numberOfNodes++
+// AddNodes adds new nodes to an existing kinder cluster. The number of nodes to add is defined
+// using the ControlPlanes and Workers options; if the Image option is not set, new nodes use the
+// same image of the existing nodes.
+//
+// When adding control-plane nodes to a cluster without an external load balancer, the load balancer
+// is created as well, and configured with the existing control-plane nodes.
+//
+// Please note that new nodes are not joined to the Kubernetes cluster; this can be achieved using
+// the kubeadm-join action with the --only-node flag; the kubeadm-join action takes care also of
+// adding new control-plane nodes to the load balancer configuration.
+func AddNodes(clusterName string, options ...CreateOption) error {
+	flags := &CreateOptions{}
+	for _, o := range options {
+		o(flags)
 	}
-	fmt.Printf("Preparing nodes %s\n", strings.Repeat("📦", numberOfNodes))
 
-	// detect CRI runtime installed into images before actually creating nodes
-	runtime, err := status.InspectCRIinImage(flags.image)
+	// Check if the cluster name exists
+	known, err := status.IsKnown(clusterName)
 	if err != nil {
-		log.Errorf("Error detecting CRI for images %s! %v", flags.image, err)
 		return err
 	}
-	log.Infof("Detected %s container runtime for image %s", runtime, flags.image)
+	if !known {
+		return errors.Errorf("a cluster with the name %q does not exists", clusterName)
+	}
 
-	createHelper, err := nodes.NewCreateHelper(runtime)
+	c, err := status.Discover(clusterName)
 	if err != nil {
-		log.Errorf("Error creating NewCreateHelper for CRI %s! %v", flags.image, err)
 		return err
 	}
 
-	// create all of the node containers
-	log.Info("Creating nodes...")
-	for _, desiredNode := range desiredNodes {
-		var err error
-		switch desiredNode.Role {
-		case constants.ExternalLoadBalancerNodeRoleValue:
-			err = createHelper.CreateExternalLoadBalancer(clusterName, desiredNode.Name)
-		case constants.ControlPlaneNodeRoleValue, constants.WorkerNodeRoleValue:
-			err = createHelper.CreateNode(clusterName, desiredNode.Name, flags.image, desiredNode.Role, flags.volumes)
+	// new nodes are attached to the docker network dedicated to the cluster; this does not exist
+	// for clusters created with older versions of kinder, using the default bridge network
+	exists, err := common.NetworkExists(clusterName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.Errorf("the docker network %s does not exist; adding nodes to clusters created with older versions of kinder is not supported", common.NetworkName(clusterName))
+	}
+
+	// new nodes use the same IP family of the existing nodes
+	if err := c.ReadSettings(); err != nil {
+		return err
+	}
+	flags.ipFamily = c.Settings.IPFamily
+
+	// if not explicitly set, use the same volumes used at create time
+	if len(flags.volumes) == 0 {
+		flags.volumes = c.Settings.Create.Volumes
+	}
+
+	desiredNodes := nodesToAdd(c, flags)
+	if len(desiredNodes) == 0 {
+		return errors.New("please request at least one node to add")
+	}
+
+	// if not explicitly set, use the same image and node settings of the existing nodes with the same role
+	for i := range desiredNodes {
+		if desiredNodes[i].Role == constants.ExternalLoadBalancerNodeRoleValue {
+			continue
 		}
+		n, err := existingNode(c, desiredNodes[i].Role)
 		if err != nil {
-			return errors.Wrapf(err, "error creating node %v", desiredNode)
+			return err
+		}
+		if desiredNodes[i].Image == "" {
+			desiredNodes[i].Image, err = n.Image()
+			if err != nil {
+				return err
+			}
 		}
+		settings, err := n.ReadNodeSettings()
+		if err != nil {
+			return err
+		}
+		inheritNodeSettings(&desiredNodes[i].Node, settings)
+	}
+
+	images, err := nodeImages(desiredNodes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Adding nodes to cluster %q ...\n", clusterName)
+	for _, image := range images {
+		ensureNodeImage(image)
+	}
+	fmt.Printf("Preparing nodes %s\n", strings.Repeat("📦", len(desiredNodes)))
+
+	handleErr := func(err error) error {
+		// In case of errors new nodes are deleted (except if retain is explicitly set)
+		if !flags.retain {
+			for _, desiredNode := range desiredNodes {
+				n, nerr := status.NewNode(desiredNode.Name)
+				if nerr != nil {
+					// the node container was not created
+					continue
+				}
+				if err := n.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		log.Error(err)
+		return err
+	}
+
+	if err := createNodeContainers(clusterName, flags, desiredNodes); err != nil {
+		return handleErr(errors.Wrap(err, "error creating nodes"))
+	}
+
+	if err := waitForNodesRunning(desiredNodes); err != nil {
+		return handleErr(errors.Wrap(err, "error creating nodes"))
+	}
+
+	// write to the new nodes the settings that will be re-used by kinder during the cluster lifecycle
+	if err := writeSettings(c.Settings, desiredNodes); err != nil {
+		return handleErr(err)
+	}
+
+	// if an external load balancer was created, configure it with the existing control-plane nodes
+	if c.ExternalLoadBalancer() == nil && flags.controlPlanes > 0 {
+		if err := configureNewLoadBalancer(clusterName, c.ControlPlanes()); err != nil {
+			return handleErr(errors.Wrap(err, "error configuring the external load balancer"))
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("Nodes creation complete. You can now join the new nodes to the Kubernetes cluster using\n")
+	for _, n := range desiredNodes {
+		if n.Role == constants.ExternalLoadBalancerNodeRoleValue {
+			continue
+		}
+		fmt.Printf("kinder do kubeadm-join --name=%s --only-node=%s\n", clusterName, n.Name)
+	}
+
+	return nil
+}
+
+// configureNewLoadBalancer configures a load balancer added to an existing cluster
+// using the given control-plane nodes as backends
+func configureNewLoadBalancer(clusterName string, controlPlanes status.NodeList) error {
+	c, err := status.Discover(clusterName)
+	if err != nil {
+		return err
+	}
+
+	if err := c.ReadSettings(); err != nil {
+		return err
+	}
+
+	return actions.LoadBalancer(c, controlPlanes...)
+}
+
+func createNodes(clusterName string, flags *CreateOptions, desiredNodes []nodeSpec) error {
+	// inform the user that we are setting up the desired nodes
+	numberOfNodes := len(desiredNodes)
+	if flags.externalEtcd {
+		numberOfNodes++
+	}
+	fmt.Printf("Preparing nodes %s\n", strings.Repeat("📦", numberOfNodes))
+
+	// create all of the node containers
+	if err := createNodeContainers(clusterName, flags, desiredNodes); err != nil {
+		return err
 	}
 
 	// add an external etcd if explicitly requested
 	if flags.externalEtcd {
 		log.Info("Getting required etcd image...")
-		c, err := status.FromDocker(clusterName)
+		c, err := status.Discover(clusterName)
 		if err != nil {
 			return err
 		}
@@ -214,21 +418,135 @@ func createNodes(clusterName string, flags *CreateOptions) error {
 		// we don't care if this errors, we'll still try to run which also pulls
 		_, _ = host.PullImage(etcdImage, 4)
 
+		cri, err := c.BootstrapControlPlane().CRI()
+		if err != nil {
+			return err
+		}
+
+		createHelper, err := nodes.NewCreateHelper(cri)
+		if err != nil {
+			return err
+		}
+
 		log.Info("Creating external etcd...")
-		if err := createHelper.CreateExternalEtcd(clusterName, fmt.Sprintf("%s-etcd", clusterName), etcdImage); err != nil {
+		if err := createHelper.CreateExternalEtcd(clusterName, fmt.Sprintf("%s-etcd", clusterName), etcdImage, flags.ipFamily); err != nil {
 			return err
 		}
 	}
 
 	// wait for all node containers to have a Running status
+	if err := waitForNodesRunning(desiredNodes); err != nil {
+		return err
+	}
+
+	// write to the nodes the settings that will be re-used by kinder during the cluster lifecycle.
+	return writeSettings(clusterSettings(flags), desiredNodes)
+}
+
+// clusterSettings returns the cluster settings for the given create options
+func clusterSettings(flags *CreateOptions) *status.ClusterSettings {
+	return &status.ClusterSettings{
+		IPFamily:      flags.ipFamily,
+		KinderVersion: constants.KinderVersion,
+		Create: status.CreateSettings{
+			ControlPlanes:        flags.controlPlanes,
+			Workers:              flags.workers,
+			Image:                flags.image,
+			ControlPlaneImage:    flags.controlPlaneImage,
+			WorkerImage:          flags.workerImage,
+			ExternalEtcd:         flags.externalEtcd,
+			ExternalLoadBalancer: flags.externalLoadBalancer,
+			Volumes:              flags.volumes,
+			Subnet:               flags.subnet,
+		},
+		Kubeadm: flags.kubeadm,
+	}
+}
+
+// writeSettings writes the cluster settings and the node settings on the K8s nodes in desiredNodes
+func writeSettings(settings *status.ClusterSettings, desiredNodes []nodeSpec) error {
+	for _, desiredNode := range desiredNodes {
+		if desiredNode.Role == constants.ExternalLoadBalancerNodeRoleValue {
+			continue
+		}
+
+		n, err := status.NewNode(desiredNode.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := n.WriteClusterSettings(settings); err != nil {
+			return errors.Wrapf(err, "failed to write cluster settings to node %s", n.Name())
+		}
+
+		if err := n.WriteNodeSettings(&status.NodeSettings{Node: desiredNode.Node}); err != nil {
+			return errors.Wrapf(err, "failed to write node settings to node %s", n.Name())
+		}
+	}
+	return nil
+}
+
+// createNodeContainers creates the node containers for the desired nodes; the container runtime
+// is detected for each node image, so nodes with different container runtimes can coexist
+func createNodeContainers(clusterName string, flags *CreateOptions, desiredNodes []nodeSpec) error {
+	// detect CRI runtime installed into images before actually creating nodes
+	createHelpers := map[string]*nodes.CreateHelper{}
+	for _, desiredNode := range desiredNodes {
+		if desiredNode.Role == constants.ExternalLoadBalancerNodeRoleValue {
+			continue
+		}
+		if _, ok := createHelpers[desiredNode.Image]; ok {
+			continue
+		}
+
+		runtime, err := status.InspectCRIinImage(desiredNode.Image)
+		if err != nil {
+			log.Errorf("Error detecting CRI for images %s! %v", desiredNode.Image, err)
+			return err
+		}
+		log.Infof("Detected %s container runtime for image %s", runtime, desiredNode.Image)
+
+		createHelper, err := nodes.NewCreateHelper(runtime)
+		if err != nil {
+			log.Errorf("Error creating NewCreateHelper for CRI %s! %v", desiredNode.Image, err)
+			return err
+		}
+		createHelpers[desiredNode.Image] = createHelper
+	}
+
+	// ensure the docker network dedicated to the cluster exists
+	if err := common.EnsureNetwork(clusterName, flags.subnet, flags.ipFamily); err != nil {
+		return err
+	}
+
+	log.Info("Creating nodes...")
+	for _, desiredNode := range desiredNodes {
+		var err error
+		switch desiredNode.Role {
+		case constants.ExternalLoadBalancerNodeRoleValue:
+			// NB. the external load balancer does not depend on the container runtime
+			err = (&nodes.CreateHelper{}).CreateExternalLoadBalancer(clusterName, desiredNode.Name, flags.ipFamily)
+		case constants.ControlPlaneNodeRoleValue, constants.WorkerNodeRoleValue:
+			err = createHelpers[desiredNode.Image].CreateNode(clusterName, desiredNode.Name, desiredNode.Node, flags.volumes, flags.ipFamily)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error creating node %v", desiredNode)
+		}
+	}
+
+	return nil
+}
+
+// waitForNodesRunning waits for all the given node containers to have a Running status
+func waitForNodesRunning(desiredNodes []nodeSpec) error {
 	log.Info("Waiting for all nodes to start...")
 	timeout := time.Second * 40
 	for _, n := range desiredNodes {
 		var lastErr error
 		log.Infof("Waiting for node %s to start...", n.Name)
-		err = wait.PollUntilContextTimeout(context.Background(), time.Second*1, timeout, true, func(ctx context.Context) (bool, error) {
+		err := wait.PollUntilContextTimeout(context.Background(), time.Second*1, timeout, true, func(ctx context.Context) (bool, error) {
 			lines, err := exec.NewHostCmd(
-				"docker",
+				provider.Command(),
 				"container",
 				"inspect",
 				"-f",
@@ -245,32 +563,6 @@ func createNodes(clusterName string, flags *CreateOptions) error {
 			return errors.Wrapf(lastErr, "node %s did not start in %v", n.Name, timeout)
 		}
 	}
-
-	// get the cluster
-	c, err := status.FromDocker(clusterName)
-	if err != nil {
-		return err
-	}
-
-	c.Settings = &status.ClusterSettings{
-		IPFamily: status.IPv4Family, // only IPv4 is tested with kinder
-	}
-
-	// TODO: the cluster and node settings are currently unused by kinder
-	// Enable these writes if settings have to stored on the nodes
-	//
-	// // write to the nodes the cluster settings that will be re-used by kinder during the cluster lifecycle.
-	//
-	// if err := c.WriteSettings(); err != nil {
-	// 	return err
-	// }
-	//
-	// for _, n := range c.K8sNodes() {
-	// 	if err := n.WriteNodeSettings(&status.NodeSettings{}); err != nil {
-	// 		return err
-	// 	}
-	// }
-
 	return nil
 }
 
@@ -278,29 +570,34 @@ func createNodes(clusterName string, flags *CreateOptions) error {
 // this does not include eg starting kubernetes (see actions for that)
 type nodeSpec struct {
 	Name string
-	Role string
+	config.Node
 }
 
 // nodesToCreate return the list of nodes to create for the cluster
 func nodesToCreate(clusterName string, flags *CreateOptions) []nodeSpec {
 	var desiredNodes []nodeSpec
 
-	// prepare nodes explicitly
-	for n := 0; n < flags.controlPlanes; n++ {
-		role := constants.ControlPlaneNodeRoleValue
-		desiredNode := nodeSpec{
-			Name: fmt.Sprintf("%s-%s-%d", clusterName, role, n+1),
-			Role: role,
+	// prepare nodes explicitly, using the node config if defined
+	nodes := flags.nodes
+	if len(nodes) == 0 {
+		for n := 0; n < flags.controlPlanes; n++ {
+			nodes = append(nodes, config.Node{Role: constants.ControlPlaneNodeRoleValue})
+		}
+		for n := 0; n < flags.workers; n++ {
+			nodes = append(nodes, config.Node{Role: constants.WorkerNodeRoleValue})
 		}
-		desiredNodes = append(desiredNodes, desiredNode)
 	}
-	for n := 0; n < flags.workers; n++ {
-		role := constants.WorkerNodeRoleValue
-		desiredNode := nodeSpec{
-			Name: fmt.Sprintf("%s-%s-%d", clusterName, role, n+1),
-			Role: role,
+
+	index := map[string]int{}
+	for _, node := range nodes {
+		index[node.Role]++
+		if node.Image == "" {
+			node.Image = nodeImage(flags, node.Role)
 		}
-		desiredNodes = append(desiredNodes, desiredNode)
+		desiredNodes = append(desiredNodes, nodeSpec{
+			Name: nodeName(clusterName, node.Role, index[node.Role]),
+			Node: node,
+		})
 	}
 
 	// add an external load balancer if explicitly requested or if there are multiple control planes
@@ -308,13 +605,157 @@ func nodesToCreate(clusterName string, flags *CreateOptions) []nodeSpec {
 		role := constants.ExternalLoadBalancerNodeRoleValue
 		desiredNodes = append(desiredNodes, nodeSpec{
 			Name: fmt.Sprintf("%s-lb", clusterName),
-			Role: role,
+			Node: config.Node{Role: role},
 		})
 	}
 
 	return desiredNodes
 }
 
+// nodesToAdd return the list of nodes to add to an existing cluster; the index used in
+// node names continues the numbering of the existing nodes with the same role
+func nodesToAdd(c *status.Cluster, flags *CreateOptions) []nodeSpec {
+	var desiredNodes []nodeSpec
+
+	role := constants.ControlPlaneNodeRoleValue
+	first := nextNodeIndex(c.Name(), role, nodeNames(c.ControlPlanes()))
+	for n := 0; n < flags.controlPlanes; n++ {
+		desiredNodes = append(desiredNodes, nodeSpec{
+			Name: nodeName(c.Name(), role, first+n),
+			Node: config.Node{Role: role, Image: nodeImage(flags, role)},
+		})
+	}
+
+	role = constants.WorkerNodeRoleValue
+	first = nextNodeIndex(c.Name(), role, nodeNames(c.Workers()))
+	for n := 0; n < flags.workers; n++ {
+		desiredNodes = append(desiredNodes, nodeSpec{
+			Name: nodeName(c.Name(), role, first+n),
+			Node: config.Node{Role: role, Image: nodeImage(flags, role)},
+		})
+	}
+
+	// add an external load balancer if there will be multiple control planes
+	if flags.controlPlanes > 0 && c.ExternalLoadBalancer() == nil {
+		role := constants.ExternalLoadBalancerNodeRoleValue
+		desiredNodes = append(desiredNodes, nodeSpec{
+			Name: fmt.Sprintf("%s-lb", c.Name()),
+			Node: config.Node{Role: role},
+		})
+	}
+
+	return desiredNodes
+}
+
+// nodeImage returns the image for a node with the given role, giving precedence to the
+// image for the role, if set, over the default image
+func nodeImage(flags *CreateOptions, role string) string {
+	switch {
+	case role == constants.ControlPlaneNodeRoleValue && flags.controlPlaneImage != "":
+		return flags.controlPlaneImage
+	case role == constants.WorkerNodeRoleValue && flags.workerImage != "":
+		return flags.workerImage
+	}
+	return flags.image
+}
+
+// existingNode returns the first existing node with the given role in the cluster,
+// falling back to any other Kubernetes node
+func existingNode(c *status.Cluster, role string) (*status.Node, error) {
+	nodes := c.Workers()
+	if role == constants.ControlPlaneNodeRoleValue {
+		nodes = c.ControlPlanes()
+	}
+	if len(nodes) == 0 {
+		nodes = c.K8sNodes()
+	}
+	if len(nodes) == 0 {
+		return nil, errors.Errorf("failed to detect the node image for cluster %q, please set the image explicitly", c.Name())
+	}
+	return nodes[0], nil
+}
+
+// inheritNodeSettings sets the node settings not explicitly set for a new node using the settings of an
+// existing node, e.g. extra mounts, labels, env variables and resource limits.
+// Extra port mappings are not inherited, because host ports can be used only by one node container
+func inheritNodeSettings(node *config.Node, settings *status.NodeSettings) {
+	if settings.Role != node.Role {
+		return
+	}
+	if len(node.ExtraMounts) == 0 {
+		node.ExtraMounts = settings.ExtraMounts
+	}
+	if len(node.Labels) == 0 {
+		node.Labels = settings.Labels
+	}
+	if len(node.Env) == 0 {
+		node.Env = settings.Env
+	}
+	if node.Resources == nil {
+		node.Resources = settings.Resources
+	}
+}
+
+// nodeImages returns the list of images used by the control-plane and worker nodes, checking
+// that all the nodes have a node image
+func nodeImages(desiredNodes []nodeSpec) ([]string, error) {
+	images := []string{}
+	for _, n := range desiredNodes {
+		if n.Role == constants.ExternalLoadBalancerNodeRoleValue {
+			continue
+		}
+		if n.Image == "" {
+			return nil, errors.Errorf("node image for node %s is not set. Please set the image explicitly", n.Name)
+		}
+		if !contains(images, n.Image) {
+			images = append(images, n.Image)
+		}
+	}
+	return images, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeName returns the name of a node with the given role and index
+func nodeName(clusterName, role string, index int) string {
+	return fmt.Sprintf("%s-%s-%d", clusterName, role, index)
+}
+
+// nextNodeIndex returns the index to be used for the next node with the given role,
+// given the names of the existing nodes
+func nextNodeIndex(clusterName, role string, names []string) int {
+	prefix := fmt.Sprintf("%s-%s-", clusterName, role)
+	next := 1
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+		if err != nil {
+			continue
+		}
+		if index >= next {
+			next = index + 1
+		}
+	}
+	return next
+}
+
+func nodeNames(nodes status.NodeList) []string {
+	names := []string{}
+	for _, n := range nodes {
+		names = append(names, n.Name())
+	}
+	return names
+}
+
 // ensureNodeImage ensures that the node image used by the create is present
 func ensureNodeImage(image string) {
 	fmt.Printf("Ensuring node image (%s) 🖼\n", image)
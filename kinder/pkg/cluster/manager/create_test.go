@@ -0,0 +1,261 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/config"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+func TestNextNodeIndex(t *testing.T) {
+	tests := []struct {
+		name          string
+		role          string
+		names         []string
+		expectedIndex int
+	}{
+		{
+			name:          "no existing nodes",
+			role:          "worker",
+			expectedIndex: 1,
+		},
+		{
+			name:          "continue the existing index",
+			role:          "worker",
+			names:         []string{"kind-worker-1", "kind-worker-2"},
+			expectedIndex: 3,
+		},
+		{
+			name:          "gaps are not filled",
+			role:          "worker",
+			names:         []string{"kind-worker-1", "kind-worker-4"},
+			expectedIndex: 5,
+		},
+		{
+			name:          "nodes with other roles are ignored",
+			role:          "control-plane",
+			names:         []string{"kind-control-plane-1", "kind-worker-3"},
+			expectedIndex: 2,
+		},
+		{
+			name:          "nodes with unexpected names are ignored",
+			role:          "worker",
+			names:         []string{"kind-worker-x", "other-worker-7", "kind-worker-1"},
+			expectedIndex: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			index := nextNodeIndex("kind", test.role, test.names)
+			if index != test.expectedIndex {
+				t.Fatalf("expected index: %d, found: %d", test.expectedIndex, index)
+			}
+		})
+	}
+}
+
+func TestNodesToCreate(t *testing.T) {
+	tests := []struct {
+		name          string
+		flags         *CreateOptions
+		expectedNodes []nodeSpec
+	}{
+		{
+			name:  "nodes from counts",
+			flags: &CreateOptions{controlPlanes: 2, workers: 1, image: "img"},
+			expectedNodes: []nodeSpec{
+				{Name: "kind-control-plane-1", Node: config.Node{Role: "control-plane", Image: "img"}},
+				{Name: "kind-control-plane-2", Node: config.Node{Role: "control-plane", Image: "img"}},
+				{Name: "kind-worker-1", Node: config.Node{Role: "worker", Image: "img"}},
+				{Name: "kind-lb", Node: config.Node{Role: "external-load-balancer"}},
+			},
+		},
+		{
+			name: "nodes from config",
+			flags: &CreateOptions{
+				controlPlanes: 1,
+				workers:       2,
+				image:         "img",
+				nodes: []config.Node{
+					{Role: "worker", Env: map[string]string{"FOO": "bar"}},
+					{Role: "control-plane"},
+					{Role: "worker", Image: "other"},
+				},
+			},
+			expectedNodes: []nodeSpec{
+				{Name: "kind-worker-1", Node: config.Node{Role: "worker", Image: "img", Env: map[string]string{"FOO": "bar"}}},
+				{Name: "kind-control-plane-1", Node: config.Node{Role: "control-plane", Image: "img"}},
+				{Name: "kind-worker-2", Node: config.Node{Role: "worker", Image: "other"}},
+			},
+		},
+		{
+			name: "nodes with role images",
+			flags: &CreateOptions{
+				controlPlanes: 1,
+				workers:       1,
+				image:         "img",
+				workerImage:   "worker-img",
+				nodes: []config.Node{
+					{Role: "control-plane"},
+					{Role: "worker"},
+					{Role: "worker", Image: "other"},
+				},
+			},
+			expectedNodes: []nodeSpec{
+				{Name: "kind-control-plane-1", Node: config.Node{Role: "control-plane", Image: "img"}},
+				{Name: "kind-worker-1", Node: config.Node{Role: "worker", Image: "worker-img"}},
+				{Name: "kind-worker-2", Node: config.Node{Role: "worker", Image: "other"}},
+			},
+		},
+		{
+			name:  "external load balancer",
+			flags: &CreateOptions{controlPlanes: 1, externalLoadBalancer: true, image: "img"},
+			expectedNodes: []nodeSpec{
+				{Name: "kind-control-plane-1", Node: config.Node{Role: "control-plane", Image: "img"}},
+				{Name: "kind-lb", Node: config.Node{Role: "external-load-balancer"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodes := nodesToCreate("kind", test.flags)
+			if !reflect.DeepEqual(nodes, test.expectedNodes) {
+				t.Errorf("expected nodes %+v, got %+v", test.expectedNodes, nodes)
+			}
+		})
+	}
+}
+
+func TestClusterSettings(t *testing.T) {
+	flags := &CreateOptions{
+		controlPlanes:        3,
+		workers:              2,
+		image:                "img",
+		workerImage:          "worker-img",
+		externalEtcd:         true,
+		externalLoadBalancer: true,
+		volumes:              []string{"/tmp:/tmp"},
+		ipFamily:             status.IPv6Family,
+		kubeadm: status.KubeadmSettings{
+			ConfigVersion: "v1beta4",
+			CopyCertsMode: "auto",
+		},
+	}
+
+	expectedSettings := &status.ClusterSettings{
+		IPFamily:      status.IPv6Family,
+		KinderVersion: constants.KinderVersion,
+		Create: status.CreateSettings{
+			ControlPlanes:        3,
+			Workers:              2,
+			Image:                "img",
+			WorkerImage:          "worker-img",
+			ExternalEtcd:         true,
+			ExternalLoadBalancer: true,
+			Volumes:              []string{"/tmp:/tmp"},
+		},
+		Kubeadm: status.KubeadmSettings{
+			ConfigVersion: "v1beta4",
+			CopyCertsMode: "auto",
+		},
+	}
+
+	settings := clusterSettings(flags)
+	if !reflect.DeepEqual(settings, expectedSettings) {
+		t.Errorf("expected settings %+v, got %+v", expectedSettings, settings)
+	}
+}
+
+func TestInheritNodeSettings(t *testing.T) {
+	existing := &status.NodeSettings{
+		Node: config.Node{
+			Role:              constants.WorkerNodeRoleValue,
+			Image:             "existing-img",
+			ExtraMounts:       []config.Mount{{HostPath: "/tmp/data", ContainerPath: "/data"}},
+			ExtraPortMappings: []config.PortMapping{{ContainerPort: 30080, HostPort: 8080, Protocol: "TCP"}},
+			Labels:            map[string]string{"foo": "bar"},
+			Env:               map[string]string{"FOO": "bar"},
+			Resources:         &config.Resources{CPUs: "2"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		node         config.Node
+		settings     *status.NodeSettings
+		expectedNode config.Node
+	}{
+		{
+			name:     "settings are inherited",
+			node:     config.Node{Role: constants.WorkerNodeRoleValue, Image: "img"},
+			settings: existing,
+			expectedNode: config.Node{
+				Role:        constants.WorkerNodeRoleValue,
+				Image:       "img",
+				ExtraMounts: []config.Mount{{HostPath: "/tmp/data", ContainerPath: "/data"}},
+				Labels:      map[string]string{"foo": "bar"},
+				Env:         map[string]string{"FOO": "bar"},
+				Resources:   &config.Resources{CPUs: "2"},
+			},
+		},
+		{
+			name: "explicit settings are preserved",
+			node: config.Node{
+				Role:   constants.WorkerNodeRoleValue,
+				Image:  "img",
+				Labels: map[string]string{"baz": "qux"},
+			},
+			settings: existing,
+			expectedNode: config.Node{
+				Role:        constants.WorkerNodeRoleValue,
+				Image:       "img",
+				ExtraMounts: []config.Mount{{HostPath: "/tmp/data", ContainerPath: "/data"}},
+				Labels:      map[string]string{"baz": "qux"},
+				Env:         map[string]string{"FOO": "bar"},
+				Resources:   &config.Resources{CPUs: "2"},
+			},
+		},
+		{
+			name:         "settings of a node with another role are ignored",
+			node:         config.Node{Role: constants.ControlPlaneNodeRoleValue, Image: "img"},
+			settings:     existing,
+			expectedNode: config.Node{Role: constants.ControlPlaneNodeRoleValue, Image: "img"},
+		},
+		{
+			name:         "node created without settings",
+			node:         config.Node{Role: constants.WorkerNodeRoleValue, Image: "img"},
+			settings:     &status.NodeSettings{},
+			expectedNode: config.Node{Role: constants.WorkerNodeRoleValue, Image: "img"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := test.node
+			inheritNodeSettings(&node, test.settings)
+			if !reflect.DeepEqual(node, test.expectedNode) {
+				t.Errorf("expected node %+v, got %+v", test.expectedNode, node)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
+)
+
+// DeleteCluster deletes all the node containers of a kinder cluster, including the external
+// load balancer and the external etcd, removes the docker network dedicated to the cluster and the
+// cluster kubeconfig file from the host.
+// Deleting a cluster that does not exist is not an error.
+func DeleteCluster(clusterName string) error {
+	c, err := status.Discover(clusterName)
+	if err != nil {
+		return err
+	}
+
+	return deleteCluster(c, common.DeleteNetwork)
+}
+
+// deleteCluster implements DeleteCluster; the function removing the cluster network
+// is passed as a parameter, so this logic can be tested without a container runtime
+func deleteCluster(c *status.Cluster, deleteNetwork func(cluster string) error) error {
+	fmt.Printf("Deleting cluster %q ...\n", c.Name())
+
+	if err := deleteNodes(c.AllNodes()); err != nil {
+		return err
+	}
+
+	// remove the docker network dedicated to the cluster, if any
+	if err := deleteNetwork(c.Name()); err != nil {
+		return err
+	}
+
+	// remove the kubeconfig file created by kubeadm-init, if any
+	kubeConfigPath := c.KubeConfigPath()
+	log.Debugf("Removing kubeconfig file %s...", kubeConfigPath)
+	if err := os.Remove(kubeConfigPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove kubeconfig file %s", kubeConfigPath)
+	}
+
+	return nil
+}
+
+// DeleteAllClusters deletes all the existing kinder clusters
+func DeleteAllClusters() error {
+	clusters, err := status.ListClusters()
+	if err != nil {
+		return err
+	}
+
+	for _, clusterName := range clusters {
+		if err := DeleteCluster(clusterName); err != nil {
+			return errors.Wrapf(err, "failed to delete cluster %s", clusterName)
+		}
+	}
+
+	return nil
+}
+
+// DeleteNodes deletes the node containers in a kinder cluster matching a node name or a node selector.
+// See Cluster.SelectNodes for the list of supported node selectors.
+func DeleteNodes(clusterName, nodeSelector string) error {
+	c, err := status.Discover(clusterName)
+	if err != nil {
+		return err
+	}
+
+	return deleteSelectedNodes(c, nodeSelector)
+}
+
+// deleteSelectedNodes implements DeleteNodes for an already discovered cluster
+func deleteSelectedNodes(c *status.Cluster, nodeSelector string) error {
+	nodes, err := c.SelectNodes(nodeSelector)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.Errorf("no node in cluster %q matches %q", c.Name(), nodeSelector)
+	}
+
+	log.Infof("%d nodes selected for deletion", len(nodes))
+	return deleteNodes(nodes)
+}
+
+// deleteNodes removes the given node containers and their volumes.
+// A node failing to be deleted does not prevent deleting the other nodes, so the
+// cleanup removes as much as possible; failures are then reported all together.
+func deleteNodes(nodes status.NodeList) error {
+	failed := []string{}
+	for _, n := range nodes {
+		fmt.Printf("Deleting node %s ...\n", n.Name())
+		if err := n.Delete(); err != nil {
+			log.Error(err)
+			failed = append(failed, n.Name())
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to delete nodes %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+// newFakeDeleteCluster returns a cluster with an external load balancer, an external etcd,
+// two control-plane nodes and one worker hosted by a fake provider
+func newFakeDeleteCluster(t *testing.T) (*status.Cluster, *fake.Provider) {
+	t.Helper()
+
+	// kubeconfig files for the cluster are written in the home folder
+	t.Setenv("HOME", t.TempDir())
+
+	p := fake.NewProvider(
+		fake.Node{Name: "kinder-lb", Role: constants.ExternalLoadBalancerNodeRoleValue},
+		fake.Node{Name: "kinder-etcd", Role: constants.ExternalEtcdNodeRoleValue},
+		fake.Node{Name: "kinder-control-plane-1", Role: constants.ControlPlaneNodeRoleValue},
+		fake.Node{Name: "kinder-control-plane-2", Role: constants.ControlPlaneNodeRoleValue},
+		fake.Node{Name: "kinder-worker-1", Role: constants.WorkerNodeRoleValue},
+	)
+	p.On("", "cat /kind/version", "v1.31.0")
+
+	c, err := status.DiscoverWithProvider("kinder", p)
+	if err != nil {
+		t.Fatalf("failed to create the fake cluster: %v", err)
+	}
+	return c, p
+}
+
+func remainingNodes(t *testing.T, p *fake.Provider) []string {
+	t.Helper()
+
+	nodes, err := p.ListNodes("kinder")
+	if err != nil {
+		t.Fatalf("failed to list nodes: %v", err)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+func TestDeleteCluster(t *testing.T) {
+	tests := []struct {
+		name              string
+		kubeconfig        bool
+		failingNode       string
+		networkErr        error
+		expectedNetwork   []string
+		expectedError     bool
+		expectsKubeconfig bool
+	}{
+		{
+			name:            "deletes nodes, network and kubeconfig",
+			kubeconfig:      true,
+			expectedNetwork: []string{"kinder"},
+		},
+		{
+			name:            "missing kubeconfig is not an error",
+			expectedNetwork: []string{"kinder"},
+		},
+		{
+			name:              "network cleanup fails",
+			kubeconfig:        true,
+			networkErr:        errors.New("network has active endpoints"),
+			expectedNetwork:   []string{"kinder"},
+			expectedError:     true,
+			expectsKubeconfig: true,
+		},
+		{
+			name:              "a node failing to be deleted does not prevent deleting the other nodes",
+			kubeconfig:        true,
+			failingNode:       "kinder-control-plane-2",
+			expectedError:     true,
+			expectsKubeconfig: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeDeleteCluster(t)
+
+			if test.kubeconfig {
+				if err := os.MkdirAll(filepath.Dir(c.KubeConfigPath()), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(c.KubeConfigPath(), []byte("apiVersion: v1"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if test.failingNode != "" {
+				// removing the container behind the node makes deleting the node fail
+				if err := p.Delete(test.failingNode); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var networks []string
+			deleteNetwork := func(cluster string) error {
+				networks = append(networks, cluster)
+				return test.networkErr
+			}
+
+			err := deleteCluster(c, deleteNetwork)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error %t, got %v", test.expectedError, err)
+			}
+
+			if nodes := remainingNodes(t, p); len(nodes) != 0 {
+				t.Errorf("expected all nodes to be deleted, got %v", nodes)
+			}
+			if !reflect.DeepEqual(networks, test.expectedNetwork) {
+				t.Errorf("expected network cleanup for %v, got %v", test.expectedNetwork, networks)
+			}
+			if _, err := os.Stat(c.KubeConfigPath()); (err == nil) != test.expectsKubeconfig {
+				t.Errorf("expected kubeconfig to exist %t, got stat error %v", test.expectsKubeconfig, err)
+			}
+		})
+	}
+}
+
+func TestDeleteSelectedNodes(t *testing.T) {
+	tests := []struct {
+		name          string
+		nodeSelector  string
+		expectedNodes []string
+		expectedError bool
+	}{
+		{
+			name:         "node name",
+			nodeSelector: "worker-1",
+			expectedNodes: []string{
+				"kinder-control-plane-1", "kinder-control-plane-2", "kinder-etcd", "kinder-lb",
+			},
+		},
+		{
+			name:         "secondary control planes",
+			nodeSelector: "@cpn",
+			expectedNodes: []string{
+				"kinder-control-plane-1", "kinder-etcd", "kinder-lb", "kinder-worker-1",
+			},
+		},
+		{
+			name:         "external load balancer",
+			nodeSelector: "@lb",
+			expectedNodes: []string{
+				"kinder-control-plane-1", "kinder-control-plane-2", "kinder-etcd", "kinder-worker-1",
+			},
+		},
+		{
+			name:         "no matching node",
+			nodeSelector: "worker-2",
+			expectedNodes: []string{
+				"kinder-control-plane-1", "kinder-control-plane-2", "kinder-etcd", "kinder-lb", "kinder-worker-1",
+			},
+			expectedError: true,
+		},
+		{
+			name:         "invalid selector",
+			nodeSelector: "@foo",
+			expectedNodes: []string{
+				"kinder-control-plane-1", "kinder-control-plane-2", "kinder-etcd", "kinder-lb", "kinder-worker-1",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, p := newFakeDeleteCluster(t)
+
+			err := deleteSelectedNodes(c, test.nodeSelector)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error %t, got %v", test.expectedError, err)
+			}
+
+			if nodes := remainingNodes(t, p); !reflect.DeepEqual(nodes, test.expectedNodes) {
+				t.Errorf("expected remaining nodes %v, got %v", test.expectedNodes, nodes)
+			}
+		})
+	}
+}
@@ -18,13 +18,17 @@ package manager
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"k8s.io/kubeadm/kinder/pkg/exec"
 
 	"k8s.io/kubeadm/kinder/pkg/cluster/manager/actions"
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // ClusterManager manages kind(er) clusters
@@ -45,7 +49,7 @@ func NewClusterManager(clusterName string) (c *ClusterManager, err error) {
 	}
 
 	// Gets the all the cluster nodes from docker
-	x, err := status.FromDocker(clusterName)
+	x, err := status.Discover(clusterName)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +116,7 @@ func (c *ClusterManager) ExecCommand(nodeSelector string, args []string) error {
 			node.Name(),
 		}, args...)
 
-		err := exec.NewHostCmd("docker", cmdArgs...).RunWithEcho()
+		err := exec.NewHostCmd(provider.Command(), cmdArgs...).RunWithEcho()
 		if err != nil {
 			return errors.Wrapf(err, "failed to execute command on node %s", node.Name())
 		}
@@ -153,9 +157,7 @@ func (c *ClusterManager) CopyFile(source, target string) error {
 	}
 
 	if sourceNodes != nil && targetNodes != nil {
-		// create tmp folder
-		// cp locally
-		return errors.Errorf("copy between nodes not implemented yet!")
+		return copyBetweenNodes(sourceNodes[0], sourcePath, targetNodes, targetPath)
 	}
 
 	if targetNodes == nil {
@@ -173,3 +175,42 @@ func (c *ClusterManager) CopyFile(source, target string) error {
 	}
 	return nil
 }
+
+// copyBetweenNodes copies files/folders from a source node to one or more target nodes;
+// the source is copied once in a temporary folder on the host and then copied to all the
+// target nodes, preserving the same semantic and file modes of docker cp
+func copyBetweenNodes(sourceNode *status.Node, sourcePath string, targetNodes status.NodeList, targetPath string) error {
+	tmpDir, err := os.MkdirTemp("", "kinder-cp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary folder")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// the source is staged in the temporary folder using its own name; if the source path is in
+	// the form "path/." (copy the content of a folder), the same form is preserved when copying to targets
+	tmpPath := filepath.Join(tmpDir, stagingName(sourcePath))
+	fmt.Printf("Copying from %s ...\n", sourceNode.Name())
+	if err := sourceNode.CopyFrom(sourcePath, tmpPath); err != nil {
+		return err
+	}
+	if strings.HasSuffix(sourcePath, "/.") {
+		tmpPath += "/."
+	}
+
+	for _, n := range targetNodes {
+		fmt.Printf("Copying to %s ...\n", n.Name())
+		if err := n.CopyTo(tmpPath, targetPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stagingName returns the name to be used when staging a source path on the host
+func stagingName(sourcePath string) string {
+	name := filepath.Base(filepath.Clean(sourcePath))
+	if name == "/" || name == "." {
+		return "content"
+	}
+	return name
+}
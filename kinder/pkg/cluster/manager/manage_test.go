@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"testing"
+)
+
+func TestStagingName(t *testing.T) {
+	tests := []struct {
+		name         string
+		inputPath    string
+		expectedName string
+	}{
+		{
+			name:         "file",
+			inputPath:    "/var/lib/kubelet/config.yaml",
+			expectedName: "config.yaml",
+		},
+		{
+			name:         "folder",
+			inputPath:    "/etc/kubernetes/pki",
+			expectedName: "pki",
+		},
+		{
+			name:         "folder with trailing slash",
+			inputPath:    "/etc/kubernetes/pki/",
+			expectedName: "pki",
+		},
+		{
+			name:         "folder content",
+			inputPath:    "/etc/kubernetes/pki/.",
+			expectedName: "pki",
+		},
+		{
+			name:         "root",
+			inputPath:    "/",
+			expectedName: "content",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := stagingName(test.inputPath)
+			if name != test.expectedName {
+				t.Errorf("expected name %q, got %q", test.expectedName, name)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"k8s.io/kubeadm/kinder/pkg/constants"
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // Cluster represents an existing kind(er) clusters
@@ -52,6 +53,39 @@ type ClusterSettings struct {
 	// kind configuration settings that are used to configure the cluster when
 	// generating the kubeadm config file.
 	IPFamily ClusterIPFamily `json:"ipFamily,omitempty"`
+
+	// KinderVersion is the version of kinder used for creating the cluster
+	KinderVersion string `json:"kinderVersion,omitempty"`
+
+	// Create holds the options used for creating the cluster
+	Create CreateSettings `json:"create,omitempty"`
+
+	// Kubeadm holds the kubeadm settings chosen at create time; those settings are used
+	// by kinder actions as a default when the corresponding flags are not set
+	Kubeadm KubeadmSettings `json:"kubeadm,omitempty"`
+}
+
+// CreateSettings defines the options used for creating the cluster
+type CreateSettings struct {
+	ControlPlanes        int      `json:"controlPlanes,omitempty"`
+	Workers              int      `json:"workers,omitempty"`
+	Image                string   `json:"image,omitempty"`
+	ControlPlaneImage    string   `json:"controlPlaneImage,omitempty"`
+	WorkerImage          string   `json:"workerImage,omitempty"`
+	ExternalEtcd         bool     `json:"externalEtcd,omitempty"`
+	ExternalLoadBalancer bool     `json:"externalLoadBalancer,omitempty"`
+	Volumes              []string `json:"volumes,omitempty"`
+	Subnet               string   `json:"subnet,omitempty"`
+}
+
+// KubeadmSettings defines the kubeadm settings chosen at create time
+type KubeadmSettings struct {
+	ConfigVersion       string `json:"configVersion,omitempty"`
+	FeatureGate         string `json:"featureGate,omitempty"`
+	EncryptionAlgorithm string `json:"encryptionAlgorithm,omitempty"`
+	CopyCertsMode       string `json:"copyCertsMode,omitempty"`
+	DiscoveryMode       string `json:"discoveryMode,omitempty"`
+	PatchesDir          string `json:"patchesDir,omitempty"`
 }
 
 // ClusterIPFamily defines cluster network IP family
@@ -62,20 +96,36 @@ const (
 	IPv4Family ClusterIPFamily = "ipv4"
 	// IPv6Family sets ClusterIPFamily to ipv6
 	IPv6Family ClusterIPFamily = "ipv6"
+	// DualStackFamily sets ClusterIPFamily to dual; in dual-stack clusters IPv4 is the primary IP family
+	DualStackFamily ClusterIPFamily = "dual"
 )
 
 // ListClusters is part of the providers.Provider interface
 func ListClusters() ([]string, error) {
-	cmd := exec.NewHostCmd("docker",
+	cmd := exec.NewHostCmd(provider.Command(),
 		"ps",
 		"-a",         // show stopped nodes
 		"--no-trunc", // don't truncate
 		// filter for nodes with the cluster label
 		"--filter", "label="+constants.DeprecatedClusterLabelKey,
-		// format to include the cluster name
-		"--format", fmt.Sprintf(`{{.Label "%s"}}`, constants.DeprecatedClusterLabelKey),
+		// format to include the node name
+		"--format", `{{.Names}}`,
 	)
-	lines, err := cmd.RunAndCapture()
+	nodes, err := cmd.RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list clusters: %s", nodes)
+	}
+	if len(nodes) == 0 {
+		return []string{}, nil
+	}
+
+	// gets the cluster name from the node labels; NB. inspect is used because the format
+	// for printing labels with ps is not consistent across providers
+	args := append([]string{
+		"inspect",
+		"-f", fmt.Sprintf(`{{index .Config.Labels "%s"}}`, constants.DeprecatedClusterLabelKey),
+	}, nodes...)
+	lines, err := exec.NewHostCmd(provider.Command(), args...).RunAndCapture()
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to list clusters: %s", lines)
 	}
@@ -105,23 +155,29 @@ func KubeConfigPath(name string) string {
 	return c.KubeConfigPath()
 }
 
-// FromDocker returns a new cluster status created by discovering
-// and inspecting existing containers nodes
-func FromDocker(name string) (c *Cluster, err error) {
+// Discover returns a new cluster status created by discovering
+// and inspecting existing containers nodes, using the selected provider
+func Discover(name string) (c *Cluster, err error) {
+	return DiscoverWithProvider(name, DefaultProvider())
+}
+
+// DiscoverWithProvider returns a new cluster status created by discovering
+// and inspecting existing containers nodes, using the given provider
+func DiscoverWithProvider(name string, p Provider) (c *Cluster, err error) {
 	// create a cluster context from current nodes
 	c = &Cluster{
 		name: name,
 	}
 
 	log.Debugf("Reading container list for cluster %s", name)
-	nodes, err := c.listNodes()
+	nodes, err := p.ListNodes(name)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, n := range nodes {
 		log.Debugf("Adding node %s to the cluster", n)
-		node, err := NewNode(n)
+		node, err := NewNodeWithProvider(n, p)
 		if err != nil {
 			return nil, err
 		}
@@ -155,24 +211,6 @@ func (c *Cluster) KubeConfigPath() string {
 	return filepath.Join(configDir, fileName)
 }
 
-// ListNodes is part of the providers.Provider interface
-func (c *Cluster) listNodes() ([]string, error) {
-	cmd := exec.NewHostCmd("docker",
-		"ps",
-		"-a",         // show stopped nodes
-		"--no-trunc", // don't truncate
-		// filter for nodes with the cluster label
-		"--filter", fmt.Sprintf("label=%s=%s", constants.DeprecatedClusterLabelKey, c.name),
-		// format to include the cluster name
-		"--format", `{{.Names}}`,
-	)
-	nodes, err := cmd.RunAndCapture()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to list nodes for cluster %s", c.name)
-	}
-	return nodes, nil
-}
-
 // Validate the cluster has a consistent set of nodes
 func (c *Cluster) Validate() error {
 
@@ -196,6 +234,9 @@ func (c *Cluster) ReadSettings() (err error) {
 	if err != nil {
 		return errors.Wrapf(err, "failed to read cluster settings from node %s", c.BootstrapControlPlane().name)
 	}
+	if c.Settings.KinderVersion != "" && c.Settings.KinderVersion != constants.KinderVersion {
+		log.Warnf("The cluster was created with kinder %s, while the current kinder version is %s", c.Settings.KinderVersion, constants.KinderVersion)
+	}
 	return nil
 }
 
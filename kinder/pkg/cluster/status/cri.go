@@ -22,6 +22,7 @@ import (
 
 	"k8s.io/kubeadm/kinder/pkg/cri/host"
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // NB. code implemented in this package ideally should be in the CRI package, but ATM it is
@@ -35,8 +36,14 @@ const (
 	DockerRuntime ContainerRuntime = "docker"
 	// ContainerdRuntime refers to the containerd container runtime
 	ContainerdRuntime ContainerRuntime = "containerd"
+	// CRIORuntime refers to the CRI-O container runtime
+	CRIORuntime ContainerRuntime = "crio"
 )
 
+// DetectCRICommand is the shell command that prints the name of the container runtime installed in a container,
+// if it is docker or CRI-O; containerd is assumed otherwise
+const DetectCRICommand = `if which docker > /dev/null 2>&1; then echo docker; elif which crio > /dev/null 2>&1; then echo crio; fi`
+
 // InspectCRIinImage inspect an image and detects the installed container runtime
 func InspectCRIinImage(image string) (ContainerRuntime, error) {
 	// define docker default args
@@ -52,7 +59,7 @@ func InspectCRIinImage(image string) (ContainerRuntime, error) {
 		return "", errors.Wrap(err, "error creating a temporary container for CRI detection")
 	}
 	defer func() {
-		exec.NewHostCmd("docker", "rm", "-f", id).Run()
+		exec.NewHostCmd(provider.Command(), "rm", "-f", id).Run()
 	}()
 
 	return InspectCRIinContainer(id)
@@ -62,14 +69,24 @@ func InspectCRIinImage(image string) (ContainerRuntime, error) {
 // NB. this method use raw kinddocker/kindexec commands because it is used also during "alter" and "create"
 // (before an actual Cluster status exist)
 func InspectCRIinContainer(id string) (ContainerRuntime, error) {
-	lines, err := exec.NewNodeCmd(id, "/bin/sh", "-c", `which docker || true`).Silent().RunAndCapture()
+	return inspectCRI(exec.NewNodeCmd(id, "/bin/sh", "-c", DetectCRICommand).Silent())
+}
+
+// inspectCRI detects the installed container runtime using the output of the given detection command
+func inspectCRI(cmd *exec.NodeCmd) (ContainerRuntime, error) {
+	lines, err := cmd.RunAndCapture()
 
 	if err != nil {
 		return ContainerRuntime(""), errors.Wrap(err, "error detecting CRI")
 	}
 
 	if len(lines) > 0 {
-		return DockerRuntime, nil
+		switch ContainerRuntime(lines[0]) {
+		case DockerRuntime:
+			return DockerRuntime, nil
+		case CRIORuntime:
+			return CRIORuntime, nil
+		}
 	}
 
 	return ContainerdRuntime, nil
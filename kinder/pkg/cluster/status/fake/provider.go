@@ -0,0 +1,331 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package fake implements an in-memory status.Provider that can be used for unit testing
+kinder actions without actual node containers.
+
+The fake provider records all the commands executed on nodes and returns scripted output, e.g.
+
+	p := fake.NewProvider(
+		fake.Node{Name: "kinder-control-plane-1", Role: "control-plane", IPv4: "172.17.0.2"},
+	)
+	p.On("", "cat /kind/version", "v1.31.0")
+
+	c, _ := status.DiscoverWithProvider("kinder", p)
+	...
+	for _, cmd := range p.Commands() {
+		fmt.Printf("%s: %s\n", cmd.Node, cmd.Text)
+	}
+
+Files copied or written to nodes are stored in memory, and they are returned by
+not scripted "cat <path>" commands.
+*/
+package fake
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+// Node defines a node container hosted by the fake provider
+type Node struct {
+	// Name of the node container, e.g. kinder-control-plane-1
+	Name string
+
+	// Role of the node, e.g. control-plane
+	Role string
+
+	// Image used for creating the node container
+	Image string
+
+	// IPv4 address of the node
+	IPv4 string
+
+	// IPv6 address of the node
+	IPv6 string
+
+	// Ports maps container ports to host ports
+	Ports map[int32]int32
+}
+
+// Command defines a command executed on a node and recorded by the fake provider
+type Command struct {
+	// Node is the name of node where the command was executed
+	Node string
+
+	// Text of the command, including args
+	Text string
+}
+
+// script defines the scripted result for commands starting with a prefix
+type script struct {
+	node   string
+	prefix string
+	output []string
+	err    error
+	fn     func(text string) ([]string, error)
+}
+
+// Provider is an in-memory status.Provider
+type Provider struct {
+	mu       sync.Mutex
+	nodes    []Node
+	scripts  []script
+	commands []Command
+	files    map[string][]byte
+}
+
+var _ status.Provider = &Provider{}
+
+// NewProvider returns a new fake provider hosting the given nodes
+func NewProvider(nodes ...Node) *Provider {
+	return &Provider{
+		nodes: nodes,
+		files: map[string][]byte{},
+	}
+}
+
+// On scripts the output for commands starting with prefix; if node is empty, the script applies to all the nodes.
+// When more than one script matches a command, the script added last wins.
+func (p *Provider) On(node, prefix string, output ...string) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.scripts = append(p.scripts, script{node: node, prefix: prefix, output: output})
+	return p
+}
+
+// OnError scripts a failure for commands starting with prefix; if node is empty, the script applies to all the nodes.
+// When more than one script matches a command, the script added last wins.
+func (p *Provider) OnError(node, prefix string, err error, output ...string) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.scripts = append(p.scripts, script{node: node, prefix: prefix, output: output, err: err})
+	return p
+}
+
+// OnFunc scripts the result for commands starting with prefix using fn, that is invoked with the text of the command;
+// if node is empty, the script applies to all the nodes. fn is invoked without holding the provider lock, so it can
+// e.g. change files on nodes using SetFile. When more than one script matches a command, the script added last wins.
+func (p *Provider) OnFunc(node, prefix string, fn func(text string) ([]string, error)) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.scripts = append(p.scripts, script{node: node, prefix: prefix, fn: fn})
+	return p
+}
+
+// SetFile stores a file on a node, as if it was copied or written to the node
+func (p *Provider) SetFile(node, path string, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.files[fileKey(node, path)] = data
+}
+
+// Commands returns the commands executed on nodes, in order of execution
+func (p *Provider) Commands() []Command {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Command{}, p.commands...)
+}
+
+// File returns a file copied or written to a node
+func (p *Provider) File(node, path string) ([]byte, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	data, ok := p.files[fileKey(node, path)]
+	return data, ok
+}
+
+// Exec records a command executed on a node and returns the scripted output
+func (p *Provider) Exec(node, command string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if _, err := p.node(node); err != nil {
+		return err
+	}
+
+	// consumes stdin, as the real command would do
+	if stdin != nil {
+		if _, err := io.Copy(io.Discard, stdin); err != nil {
+			return errors.Wrap(err, "failed to read stdin")
+		}
+	}
+
+	text := strings.TrimSpace(strings.Join(append([]string{command}, args...), " "))
+
+	p.mu.Lock()
+	p.commands = append(p.commands, Command{Node: node, Text: text})
+	output, fn, err := p.result(node, text, command, args)
+	p.mu.Unlock()
+
+	if fn != nil {
+		output, err = fn(text)
+	}
+
+	if stdout != nil && len(output) > 0 {
+		if _, werr := io.WriteString(stdout, strings.Join(output, "\n")+"\n"); werr != nil {
+			return werr
+		}
+	}
+	return err
+}
+
+// result returns the output for a command, or the func computing it; it must be called holding the lock
+func (p *Provider) result(node, text, command string, args []string) ([]string, func(string) ([]string, error), error) {
+	for i := len(p.scripts) - 1; i >= 0; i-- {
+		s := p.scripts[i]
+		if s.node != "" && s.node != node {
+			continue
+		}
+		if strings.HasPrefix(text, s.prefix) {
+			return s.output, s.fn, s.err
+		}
+	}
+
+	// not scripted cat commands return files copied or written to the node, if any
+	if command == "cat" && len(args) == 1 {
+		if data, ok := p.files[fileKey(node, args[0])]; ok {
+			return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), nil, nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// ListNodes returns the nodes with a name starting with the cluster name
+func (p *Provider) ListNodes(cluster string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := []string{}
+	for _, n := range p.nodes {
+		if strings.HasPrefix(n.Name, cluster+"-") {
+			names = append(names, n.Name)
+		}
+	}
+	return names, nil
+}
+
+// hostPortRE matches the container port in the format used for inspecting port mappings
+var hostPortRE = regexp.MustCompile(`"(\d+)/tcp"`)
+
+// Inspect returns the node information for the formats used by status.Node
+func (p *Provider) Inspect(container, format string) ([]string, error) {
+	n, err := p.node(container)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.Contains(format, constants.DeprecatedNodeRoleLabelKey):
+		return []string{n.Role}, nil
+	case strings.Contains(format, ".Config.Image"):
+		return []string{n.Image}, nil
+	case strings.Contains(format, ".NetworkSettings.Networks"):
+		return []string{n.IPv4 + "," + n.IPv6}, nil
+	case strings.Contains(format, "HostPort"):
+		match := hostPortRE.FindStringSubmatch(format)
+		if len(match) != 2 {
+			return nil, errors.Errorf("unsupported format %q", format)
+		}
+		containerPort, err := strconv.ParseInt(match[1], 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid port in format %q", format)
+		}
+		hostPort, ok := n.Ports[int32(containerPort)]
+		if !ok {
+			return nil, errors.Errorf("port %d is not mapped on container %s", containerPort, container)
+		}
+		return []string{strconv.Itoa(int(hostPort))}, nil
+	}
+	return nil, errors.Errorf("unsupported format %q", format)
+}
+
+// CopyTo stores a file from the host in memory
+func (p *Provider) CopyTo(container, source, dest string) error {
+	if _, err := p.node(container); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", source)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.files[fileKey(container, dest)] = data
+	return nil
+}
+
+// CopyFrom writes a file stored in memory to the host
+func (p *Provider) CopyFrom(container, source, dest string) error {
+	data, ok := p.File(container, source)
+	if !ok {
+		return errors.Errorf("file %s does not exist on container %s", source, container)
+	}
+	return os.WriteFile(dest, data, 0644)
+}
+
+// Signal is a no-op for the fake provider
+func (p *Provider) Signal(container, signal string) error {
+	_, err := p.node(container)
+	return err
+}
+
+// Delete removes a node from the fake provider
+func (p *Provider) Delete(container string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, n := range p.nodes {
+		if n.Name == container {
+			p.nodes = append(p.nodes[:i], p.nodes[i+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("no such container: %s", container)
+}
+
+func (p *Provider) node(name string) (Node, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, n := range p.nodes {
+		if n.Name == name {
+			return n, nil
+		}
+	}
+	return Node{}, errors.Errorf("no such container: %s", name)
+}
+
+func fileKey(node, path string) string {
+	return node + ":" + path
+}
@@ -22,16 +22,18 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	K8sVersion "k8s.io/apimachinery/pkg/util/version"
 
+	"k8s.io/kubeadm/kinder/pkg/cluster/config"
 	"k8s.io/kubeadm/kinder/pkg/constants"
-	"k8s.io/kubeadm/kinder/pkg/cri/host"
 	"k8s.io/kubeadm/kinder/pkg/exec"
 	"k8s.io/kubeadm/kinder/pkg/exec/colors"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 	ksigsyaml "sigs.k8s.io/yaml"
 )
 
@@ -51,7 +53,9 @@ type Node struct {
 	cri             ContainerRuntime
 	etcdImage       string
 	skip            bool
+	dryRun          bool
 	commandMutators []commandMutator
+	provider        Provider
 }
 
 // NodeSettings defines a set of settings that will be stored in the node and re-used
@@ -61,13 +65,21 @@ type Node struct {
 // and actions for setting up a working cluster can happen at different time
 // (while in kind everything happen within an atomic operation).
 type NodeSettings struct {
-	// NB Currently there are no persistent node settings used by kind, but we are preserving this feature for future changes
+	// Node holds the node settings used for creating the node, like role, image, extra mounts,
+	// extra port mappings, labels, env variables and resource limits
+	config.Node `json:",inline"`
 }
 
 // NewNode returns a new kinder.Node wrapper
 func NewNode(name string) (n *Node, err error) {
+	return NewNodeWithProvider(name, DefaultProvider())
+}
+
+// NewNodeWithProvider returns a new kinder.Node wrapper using the given provider
+// for interacting with the node container
+func NewNodeWithProvider(name string, p Provider) (n *Node, err error) {
 	// retrive the role the node using docker inspect
-	lines, err := host.InspectContainer(name, fmt.Sprintf("{{index .Config.Labels %q}}", constants.DeprecatedNodeRoleLabelKey))
+	lines, err := p.Inspect(name, fmt.Sprintf("{{index .Config.Labels %q}}", constants.DeprecatedNodeRoleLabelKey))
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get %q label", constants.DeprecatedNodeRoleLabelKey)
 	}
@@ -77,8 +89,9 @@ func NewNode(name string) (n *Node, err error) {
 	role := strings.Trim(lines[0], "'")
 
 	return &Node{
-		name: name,
-		role: role,
+		name:     name,
+		role:     role,
+		provider: p,
 	}, nil
 }
 
@@ -93,6 +106,18 @@ func (n *Node) Role() string {
 	return n.role
 }
 
+// Image returns the image used for creating the node container
+func (n *Node) Image() (string, error) {
+	lines, err := n.provider.Inspect(n.name, provider.Get().ImageFormat())
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the image for node %s", n.name)
+	}
+	if len(lines) != 1 {
+		return "", errors.Errorf("image should only be one line, got %d lines: %v", len(lines), lines)
+	}
+	return lines[0], nil
+}
+
 // IsControlPlane returns true if the node hosts a control plane instance
 // NB. in single node clusters, control-plane nodes act also as a worker nodes
 func (n *Node) IsControlPlane() bool {
@@ -139,7 +164,7 @@ func (n *Node) provisioningOrder() int {
 // Command returns a ProxyCmd that allows to run commands on the node
 func (n *Node) Command(command string, args ...string) *exec.NodeCmd {
 	// creates new ProxyCmd to run a command on a kind(er) node
-	cmd := exec.NewNodeCmd(n.Name(), command, args...)
+	cmd := exec.NewNodeCmd(n.Name(), command, args...).WithCommander(n.provider)
 
 	// applies command mutators
 	for _, m := range n.commandMutators {
@@ -158,6 +183,8 @@ func (n *Node) SkipActions() {
 // DryRun differs from SkipRun, because in case of DryRun kinder prints all the details for running
 // the command manually.
 func (n *Node) DryRun() {
+	n.dryRun = true
+
 	if n.commandMutators == nil {
 		n.commandMutators = []commandMutator{}
 	}
@@ -169,6 +196,12 @@ func (n *Node) DryRun() {
 	)
 }
 
+// IsDryRun returns true if the node was instructed to dry run all the commands; in this case
+// commands do not return any output.
+func (n *Node) IsDryRun() bool {
+	return n.dryRun
+}
+
 // Infof print an information message in the same format of commands on the node;
 // the message is print after the prompt containing the kind (er) node name.
 func (n *Node) Infof(message string, args ...interface{}) {
@@ -189,7 +222,9 @@ func (n *Node) MustKubeadmVersion() *K8sVersion.Version {
 
 // KubeadmVersion returns the kubeadm version installed on the node
 func (n *Node) KubeadmVersion() (*K8sVersion.Version, error) {
-	lines, err := n.Command("kubeadm", "version", "-o=short").Silent().RunAndCapture()
+	// NB. kubeadm version does not change the node, so it is executed also when dry running; this
+	// allows to print the commands for the kubeadm version installed on the node
+	lines, err := exec.NewNodeCmd(n.Name(), "kubeadm", "version", "-o=short").WithCommander(n.provider).Silent().RunAndCapture()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get kubeadm version")
 	}
@@ -252,30 +287,55 @@ func (n *Node) WriteClusterSettings(settings *ClusterSettings) error {
 	return nil
 }
 
+// readClusterSettingsAttempts and readClusterSettingsInterval define how reading cluster settings is retried
+const (
+	readClusterSettingsAttempts = 5
+	readClusterSettingsInterval = 500 * time.Millisecond
+)
+
 // ReadClusterSettings reads from the node a set of cluster-wide settings that
 // are going to be re-used by kinder during the cluster lifecycle (after create)
 func (n *Node) ReadClusterSettings() (*ClusterSettings, error) {
-	/*
-		Temporarily disabled because we are observing flakes related to this operation
-
-			lines, err := n.Command(
-				"cat", clusterSettingsPath,
-			).Silent().RunAndCapture()
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to read %s", clusterSettingsPath)
-			}
-
-			var settings ClusterSettings
-			err = ksigsyaml.Unmarshal([]byte(strings.Join(lines, "\n")), &settings)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to decode %s", clusterSettingsPath)
-			}
-
-			return &settings, nil
-	*/
-	return &ClusterSettings{
-		IPFamily: IPv4Family,
-	}, nil
+	// NB. reading cluster settings was temporarily disabled in the past because of flakes when exec-ing
+	// into the node container; the file is now read with a single command that is retried, so a transient
+	// error neither fails the action nor silently falls back to the default settings.
+	// Clusters created with older versions of kinder do not have cluster settings; in this case the
+	// output is empty and default settings are used
+	var lines []string
+	var err error
+	for i := 0; i < readClusterSettingsAttempts; i++ {
+		if i > 0 {
+			time.Sleep(readClusterSettingsInterval)
+		}
+		lines, err = n.Command(
+			"/bin/sh", "-c", fmt.Sprintf("if [ -f %[1]s ]; then cat %[1]s; fi", clusterSettingsPath),
+		).Silent().RunAndCapture()
+		if err == nil {
+			break
+		}
+		log.Debugf("Could not read %s (attempt %d/%d): %v", clusterSettingsPath, i+1, readClusterSettingsAttempts, err)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", clusterSettingsPath)
+	}
+	if len(lines) == 0 {
+		log.Debugf("%s does not exist, using default cluster settings", clusterSettingsPath)
+		return &ClusterSettings{
+			IPFamily: IPv4Family,
+		}, nil
+	}
+
+	var settings ClusterSettings
+	err = ksigsyaml.Unmarshal([]byte(strings.Join(lines, "\n")), &settings)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s", clusterSettingsPath)
+	}
+
+	if settings.IPFamily == "" {
+		settings.IPFamily = IPv4Family
+	}
+
+	return &settings, nil
 }
 
 const nodeSettingsPath = "/kinder/node-settings.yaml"
@@ -306,6 +366,15 @@ func (n *Node) WriteNodeSettings(settings *NodeSettings) error {
 // ReadNodeSettings reads from the node specific settings that
 // are going to be re-used by kinder during the cluster lifecycle (after create)
 func (n *Node) ReadNodeSettings() (*NodeSettings, error) {
+	// nodes created with older versions of kinder do not have node settings;
+	// in this case empty settings are used
+	if err := n.Command(
+		"test", "-f", nodeSettingsPath,
+	).Silent().Run(); err != nil {
+		log.Debugf("%s does not exist, using empty node settings", nodeSettingsPath)
+		return &NodeSettings{}, nil
+	}
+
 	lines, err := n.Command(
 		"cat", nodeSettingsPath,
 	).Silent().RunAndCapture()
@@ -329,7 +398,7 @@ func (n *Node) CRI() (cri ContainerRuntime, err error) {
 		return n.cri, nil
 	}
 
-	n.cri, err = InspectCRIinContainer(n.Name())
+	n.cri, err = inspectCRI(n.Command("/bin/sh", "-c", DetectCRICommand).Silent())
 	if err != nil {
 		return "", err
 	}
@@ -346,7 +415,7 @@ func (n *Node) Ports(containerPort int32) (hostPort int32, err error) {
 		return hostPort, nil
 	}
 	// retrive the specific port mapping using docker inspect
-	lines, err := host.InspectContainer(n.name, fmt.Sprintf("{{(index (index .NetworkSettings.Ports \"%d/tcp\") 0).HostPort}}", containerPort))
+	lines, err := n.provider.Inspect(n.name, fmt.Sprintf("{{(index (index .NetworkSettings.Ports \"%d/tcp\") 0).HostPort}}", containerPort))
 	if err != nil {
 		return -1, errors.Wrap(err, "failed to get file")
 	}
@@ -374,7 +443,7 @@ func (n *Node) IP() (ipv4 string, ipv6 string, err error) {
 		return n.ipv4, n.ipv6, nil
 	}
 	// retrive the IP address of the node using docker inspect
-	lines, err := host.InspectContainer(n.name, "{{range .NetworkSettings.Networks}}{{.IPAddress}},{{.GlobalIPv6Address}}{{end}}")
+	lines, err := n.provider.Inspect(n.name, "{{range .NetworkSettings.Networks}}{{.IPAddress}},{{.GlobalIPv6Address}}{{end}}")
 	if err != nil {
 		return "", "", errors.Wrap(err, "failed to get container details")
 	}
@@ -392,25 +461,33 @@ func (n *Node) IP() (ipv4 string, ipv6 string, err error) {
 	return ips[0], ips[1], nil
 }
 
+// Delete removes the node container, including its anonymous volumes
+func (n *Node) Delete() error {
+	if n.dryRun {
+		log.Infof("Dry run, skipping deletion of node %s", n.name)
+		return nil
+	}
+
+	if err := n.provider.Delete(n.name); err != nil {
+		return errors.Wrapf(err, "failed to delete node %s", n.name)
+	}
+	return nil
+}
+
 // CopyFrom copies the source file on the node to dest on the host.
 // Please note that this have limitations around symlinks.
 func (n *Node) CopyFrom(source, dest string) error {
-	cmd := exec.NewHostCmd(
-		"docker", "cp",
-		n.name+":"+source, // from the node, at source
-		dest,              // to the host, at dest
-	)
-	return cmd.RunWithEcho()
+	return n.provider.CopyFrom(n.name, source, dest)
 }
 
 // CopyTo copies the source file on the host to dest on the node
 func (n *Node) CopyTo(source, dest string) error {
-	cmd := exec.NewHostCmd(
-		"docker", "cp",
-		source,          // from the host, at source
-		n.name+":"+dest, // to the node, at dest
-	)
-	return cmd.RunWithEcho()
+	return n.provider.CopyTo(n.name, source, dest)
+}
+
+// Signal sends the named signal to the node container
+func (n *Node) Signal(signal string) error {
+	return n.provider.Signal(n.name, signal)
 }
 
 // WriteFile writes a temporary file with the given contents and copies the file to the node container
@@ -439,7 +516,8 @@ func (n *Node) WriteFile(containerPath string, contents []byte) error {
 // KubeVersion returns the Kubernetes version installed on the node
 func (n *Node) KubeVersion() (version string, err error) {
 	// grab kubernetes version from the node image
-	lines, err := n.Command("cat", "/kind/version").RunAndCapture()
+	// NB. reading the file does not change the node, so it is executed also when dry running
+	lines, err := exec.NewNodeCmd(n.Name(), "cat", "/kind/version").WithCommander(n.provider).RunAndCapture()
 	if err != nil {
 		return "", errors.Wrap(err, "failed to get file")
 	}
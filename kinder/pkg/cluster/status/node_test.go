@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status/fake"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+)
+
+func TestReadClusterSettings(t *testing.T) {
+	const readSettings = "/bin/sh -c if [ -f /kinder/cluster-settings.yaml ]; then cat /kinder/cluster-settings.yaml; fi"
+
+	tests := []struct {
+		name             string
+		output           []string
+		failures         int
+		expectedSettings *status.ClusterSettings
+		expectedReads    int
+	}{
+		{
+			name:   "settings file",
+			output: []string{"ipFamily: ipv6", "kinderVersion: v0.1.0"},
+			expectedSettings: &status.ClusterSettings{
+				IPFamily:      status.IPv6Family,
+				KinderVersion: "v0.1.0",
+			},
+			expectedReads: 1,
+		},
+		{
+			name:             "cluster created without settings file",
+			expectedSettings: &status.ClusterSettings{IPFamily: status.IPv4Family},
+			expectedReads:    1,
+		},
+		{
+			name:             "transient failure is retried",
+			output:           []string{"ipFamily: dual"},
+			failures:         1,
+			expectedSettings: &status.ClusterSettings{IPFamily: status.DualStackFamily},
+			expectedReads:    2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := fake.NewProvider(fake.Node{Name: "kinder-control-plane-1", Role: constants.ControlPlaneNodeRoleValue})
+			reads := 0
+			p.OnFunc("", readSettings, func(string) ([]string, error) {
+				reads++
+				if reads <= test.failures {
+					return nil, errors.New("error: container is not running")
+				}
+				return test.output, nil
+			})
+
+			c, err := status.DiscoverWithProvider("kinder", p)
+			if err != nil {
+				t.Fatalf("failed to create the fake cluster: %v", err)
+			}
+
+			if err := c.ReadSettings(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(c.Settings, test.expectedSettings) {
+				t.Errorf("expected settings %+v, got %+v", test.expectedSettings, c.Settings)
+			}
+			if reads != test.expectedReads {
+				t.Errorf("expected %d reads, got %d", test.expectedReads, reads)
+			}
+		})
+	}
+}
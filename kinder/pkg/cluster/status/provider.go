@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/cri/host"
+	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
+)
+
+// Provider defines the operations on the containers hosting kind(er) nodes that are
+// used by Cluster and Node.
+//
+// The default Provider uses the CLI of the selected container engine, but alternative
+// implementations can be used e.g. for unit testing actions without actual node containers.
+type Provider interface {
+	// Commander executes commands on the node containers
+	exec.Commander
+
+	// ListNodes returns the name of the containers hosting the nodes of a cluster
+	ListNodes(cluster string) ([]string, error)
+
+	// Inspect returns low-level information on a container, formatted with the given go template
+	Inspect(container, format string) ([]string, error)
+
+	// CopyTo copies the source file on the host to dest on the container
+	CopyTo(container, source, dest string) error
+
+	// CopyFrom copies the source file on the container to dest on the host
+	CopyFrom(container, source, dest string) error
+
+	// Signal sends the named signal to the container
+	Signal(container, signal string) error
+
+	// Delete removes the container, including its anonymous volumes
+	Delete(container string) error
+}
+
+// cliProvider implements Provider using the CLI of the selected container engine
+type cliProvider struct {
+	exec.CLICommander
+}
+
+var _ Provider = cliProvider{}
+
+// DefaultProvider returns the Provider using the CLI of the selected container engine
+func DefaultProvider() Provider {
+	return cliProvider{}
+}
+
+func (cliProvider) ListNodes(cluster string) ([]string, error) {
+	cmd := exec.NewHostCmd(provider.Command(),
+		"ps",
+		"-a",         // show stopped nodes
+		"--no-trunc", // don't truncate
+		// filter for nodes with the cluster label
+		"--filter", fmt.Sprintf("label=%s=%s", constants.DeprecatedClusterLabelKey, cluster),
+		// format to include the cluster name
+		"--format", `{{.Names}}`,
+	)
+	nodes, err := cmd.RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list nodes for cluster %s", cluster)
+	}
+	return nodes, nil
+}
+
+func (cliProvider) Inspect(container, format string) ([]string, error) {
+	return host.InspectContainer(container, format)
+}
+
+func (cliProvider) CopyTo(container, source, dest string) error {
+	cmd := exec.NewHostCmd(
+		provider.Command(), "cp",
+		source,             // from the host, at source
+		container+":"+dest, // to the node, at dest
+	)
+	return cmd.RunWithEcho()
+}
+
+func (cliProvider) CopyFrom(container, source, dest string) error {
+	cmd := exec.NewHostCmd(
+		provider.Command(), "cp",
+		container+":"+source, // from the node, at source
+		dest,                 // to the host, at dest
+	)
+	return cmd.RunWithEcho()
+}
+
+func (cliProvider) Signal(container, signal string) error {
+	return host.SendSignal(signal, container)
+}
+
+func (cliProvider) Delete(container string) error {
+	return exec.NewHostCmd(
+		provider.Command(),
+		"rm",
+		"-f", // force the container to be deleted now
+		"-v", // delete volumes
+		container,
+	).Run()
+}
@@ -18,11 +18,12 @@ package host
 
 import (
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // InspectContainer return low-level information on containers
 func InspectContainer(containerNameOrID, format string) ([]string, error) {
-	cmd := exec.NewHostCmd("docker", "inspect",
+	cmd := exec.NewHostCmd(provider.Command(), "inspect",
 		"-f", format,
 		containerNameOrID, // ... against the "node" container
 	)
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // PullImage will pull an image if it is not present locally
@@ -28,16 +29,16 @@ import (
 func PullImage(image string, retries int) (bool, error) {
 	// once we have configurable log levels
 	// if this did not return an error, then the image exists locally
-	if err := exec.NewHostCmd("docker", "inspect", "--type=image", image).Run(); err == nil {
+	if err := exec.NewHostCmd(provider.Command(), "image", "inspect", image).Run(); err == nil {
 		return false, nil
 	}
 
 	// otherwise try to pull it
 	var err error
-	if err = exec.NewHostCmd("docker", "pull", image).Run(); err != nil {
+	if err = exec.NewHostCmd(provider.Command(), "pull", image).Run(); err != nil {
 		for i := 0; i < retries; i++ {
 			time.Sleep(time.Second * time.Duration(i+1))
-			if err = exec.NewHostCmd("docker", "pull", image).Run(); err == nil {
+			if err = exec.NewHostCmd(provider.Command(), "pull", image).Run(); err == nil {
 				break
 			}
 		}
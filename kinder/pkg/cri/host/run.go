@@ -22,9 +22,10 @@ import (
 	"github.com/pkg/errors"
 
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
-// Run creates a container with "docker run", with some error handling
+// Run creates a container with "docker run" (or the equivalent command of the selected provider), with some error handling
 func Run(image string, runArgs, containerArgs []string) error {
 	// construct the actual docker run argv
 	args := []string{"run"}
@@ -32,8 +33,8 @@ func Run(image string, runArgs, containerArgs []string) error {
 	args = append(args, image)
 	args = append(args, containerArgs...)
 
-	if output, err := exec.NewHostCmd("docker", args...).RunAndCapture(); err != nil {
-		return errors.Wrapf(err, "failed to execute docker run: %s", strings.Join(output, " "))
+	if output, err := exec.NewHostCmd(provider.Command(), args...).RunAndCapture(); err != nil {
+		return errors.Wrapf(err, "failed to execute %s run: %s", provider.Command(), strings.Join(output, " "))
 	}
 	return nil
 }
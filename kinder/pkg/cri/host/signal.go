@@ -18,12 +18,13 @@ package host
 
 import (
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // SendSignal sends the named signal to the container
 func SendSignal(signal, containerNameOrID string) error {
 	cmd := exec.NewHostCmd(
-		"docker", "kill",
+		provider.Command(), "kill",
 		"-s", signal,
 		containerNameOrID,
 	)
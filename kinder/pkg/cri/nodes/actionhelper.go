@@ -21,6 +21,7 @@ import (
 
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/containerd"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/crio"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/docker"
 )
 
@@ -43,6 +44,8 @@ func (h *ActionHelper) PreLoadUpgradeImages(n *status.Node, srcFolder string) er
 		return containerd.PreLoadUpgradeImages(n, srcFolder)
 	case status.DockerRuntime:
 		return docker.PreLoadUpgradeImages(n, srcFolder)
+	case status.CRIORuntime:
+		return crio.PreLoadUpgradeImages(n, srcFolder)
 	}
 	return errors.Errorf("unknown cri: %s", h.cri)
 }
@@ -54,6 +57,36 @@ func (h *ActionHelper) GetImages(n *status.Node) ([]string, error) {
 		return containerd.GetImages(n)
 	case status.DockerRuntime:
 		return docker.GetImages(n)
+	case status.CRIORuntime:
+		return crio.GetImages(n)
+	}
+	return nil, errors.Errorf("unknown cri: %s", h.cri)
+}
+
+// StopContainers stops the containers with the given name in the node, e.g. kube-apiserver;
+// containers of static pods are then restarted by the kubelet
+func (h *ActionHelper) StopContainers(n *status.Node, name string) error {
+	switch h.cri {
+	case status.ContainerdRuntime:
+		return containerd.StopContainers(n, name)
+	case status.DockerRuntime:
+		return docker.StopContainers(n, name)
+	case status.CRIORuntime:
+		return crio.StopContainers(n, name)
+	}
+	return errors.Errorf("unknown cri: %s", h.cri)
+}
+
+// ListContainers returns the IDs of the containers with the given name in the node, e.g. kube-apiserver,
+// including stopped containers
+func (h *ActionHelper) ListContainers(n *status.Node, name string) ([]string, error) {
+	switch h.cri {
+	case status.ContainerdRuntime:
+		return containerd.ListContainers(n, name)
+	case status.DockerRuntime:
+		return docker.ListContainers(n, name)
+	case status.CRIORuntime:
+		return crio.ListContainers(n, name)
 	}
 	return nil, errors.Errorf("unknown cri: %s", h.cri)
 }
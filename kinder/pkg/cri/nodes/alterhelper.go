@@ -23,6 +23,7 @@ import (
 	"k8s.io/kubeadm/kinder/pkg/build/bits"
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/containerd"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/crio"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/docker"
 )
 
@@ -45,6 +46,8 @@ func (h *AlterHelper) GetAlterContainerArgs() ([]string, []string) {
 		return containerd.GetAlterContainerArgs()
 	case status.DockerRuntime:
 		return docker.GetAlterContainerArgs()
+	case status.CRIORuntime:
+		return crio.GetAlterContainerArgs()
 	}
 	return []string{}, []string{}
 }
@@ -57,6 +60,8 @@ func (h *AlterHelper) StartCRI(bc *bits.BuildContext) error {
 		return containerd.StartRuntime(bc)
 	case status.DockerRuntime:
 		return docker.StartRuntime(bc)
+	case status.CRIORuntime:
+		return crio.StartRuntime(bc)
 	}
 	return errors.Errorf("unknown cri: %s", h.cri)
 }
@@ -68,6 +73,8 @@ func (h *AlterHelper) SetupCRI(bc *bits.BuildContext) error {
 		return containerd.SetupRuntime(bc)
 	case status.DockerRuntime:
 		return docker.SetupRuntime(bc)
+	case status.CRIORuntime:
+		return crio.SetupRuntime(bc)
 	}
 	return errors.Errorf("unknown cri: %s", h.cri)
 }
@@ -79,6 +86,8 @@ func (h *AlterHelper) PreLoadInitImages(bc *bits.BuildContext, srcFolder string)
 		return containerd.PreLoadInitImages(bc, srcFolder)
 	case status.DockerRuntime:
 		return docker.PreLoadInitImages(bc, srcFolder)
+	case status.CRIORuntime:
+		return crio.PreLoadInitImages(bc, srcFolder)
 	}
 	return errors.Errorf("unknown cri: %s", h.cri)
 }
@@ -91,6 +100,8 @@ func (h *AlterHelper) StopCRI(bc *bits.BuildContext) error {
 		return containerd.StopRuntime(bc)
 	case status.DockerRuntime:
 		return docker.StopRuntime(bc)
+	case status.CRIORuntime:
+		return crio.StopRuntime(bc)
 	}
 	return errors.Errorf("unknown cri: %s", h.cri)
 }
@@ -102,6 +113,8 @@ func (h *AlterHelper) ImportImage(bc *bits.BuildContext, tar string) error {
 		return containerd.ImportImage(bc, tar)
 	case status.DockerRuntime:
 		return docker.ImportImage(bc, tar)
+	case status.CRIORuntime:
+		return crio.ImportImage(bc, tar)
 	}
 	return errors.Errorf("unknown cri: %s", h.cri)
 }
@@ -128,6 +141,8 @@ func (h *AlterHelper) Commit(containerID, targetImage string) error {
 		return containerd.Commit(containerID, targetImage)
 	case status.DockerRuntime:
 		return docker.Commit(containerID, targetImage)
+	case status.CRIORuntime:
+		return crio.Commit(containerID, targetImage)
 	}
 	return errors.Errorf("unknown cri: %s", h.cri)
 }
@@ -0,0 +1,156 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/constants"
+	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
+)
+
+// ipv6NetworkPrefix is the prefix of the IPv6 subnets generated for cluster networks;
+// this is a randomly generated unique local address
+const ipv6NetworkPrefix = "fc00:f853:ccd"
+
+// NetworkName returns the name of the docker network dedicated to a cluster
+func NetworkName(cluster string) string {
+	return fmt.Sprintf("kinder-%s", cluster)
+}
+
+// NetworkExists returns true if the docker network dedicated to a cluster exists
+func NetworkExists(cluster string) (bool, error) {
+	name := NetworkName(cluster)
+	lines, err := exec.NewHostCmd(
+		provider.Command(), "network", "ls", "--format", "{{.Name}}",
+	).RunAndCapture()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list docker networks")
+	}
+	for _, l := range lines {
+		if strings.TrimSpace(l) == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// EnsureNetwork creates the docker network dedicated to a cluster, if it does not exist yet.
+// The subnet of the network is chosen by docker, unless a subnet is explicitly set; IPv6 and dual-stack clusters
+// get an IPv6 enabled network, with an IPv6 subnet derived from the cluster name if not explicitly set.
+func EnsureNetwork(cluster, subnet string, ipFamily status.ClusterIPFamily) error {
+	exists, err := NetworkExists(cluster)
+	if err != nil {
+		return err
+	}
+
+	name := NetworkName(cluster)
+	if exists {
+		log.Debugf("Docker network %s already exists", name)
+		return nil
+	}
+
+	args, err := networkCreateArgs(cluster, subnet, ipFamily)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Creating docker network %s...", name)
+	if err := exec.NewHostCmd(provider.Command(), args...).Run(); err != nil {
+		return errors.Wrapf(err, "failed to create docker network %s", name)
+	}
+	return nil
+}
+
+// networkCreateArgs computes the docker arguments for creating the network dedicated to a cluster
+func networkCreateArgs(cluster, subnet string, ipFamily status.ClusterIPFamily) ([]string, error) {
+	args := []string{
+		"network", "create",
+		"--driver=bridge",
+		// label the network with the cluster ID
+		"--label", fmt.Sprintf("%s=%s", constants.ClusterLabelKey, cluster),
+	}
+
+	ipv6Subnet := ""
+	if subnet != "" {
+		ip, _, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid subnet %s", subnet)
+		}
+		if ip.To4() == nil {
+			ipv6Subnet = subnet
+		} else {
+			args = append(args, fmt.Sprintf("--subnet=%s", subnet))
+		}
+	}
+
+	if ipFamily == status.IPv6Family || ipFamily == status.DualStackFamily {
+		if ipv6Subnet == "" {
+			ipv6Subnet = clusterIPv6Subnet(cluster)
+		}
+		args = append(args, "--ipv6", fmt.Sprintf("--subnet=%s", ipv6Subnet))
+	} else if ipv6Subnet != "" {
+		return nil, errors.Errorf("IPv6 subnet %s can't be used for an %s cluster", ipv6Subnet, ipFamily)
+	}
+
+	return append(args, NetworkName(cluster)), nil
+}
+
+// clusterIPv6Subnet returns an IPv6 subnet derived from the cluster name, so clusters with different names
+// get, with high probability, different subnets
+func clusterIPv6Subnet(cluster string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(cluster))
+	return fmt.Sprintf("%s:%x::/64", ipv6NetworkPrefix, h.Sum32()&0xffff)
+}
+
+// DeleteNetwork deletes the docker network dedicated to a cluster, if it exists
+func DeleteNetwork(cluster string) error {
+	exists, err := NetworkExists(cluster)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	name := NetworkName(cluster)
+	log.Debugf("Deleting docker network %s...", name)
+	if err := exec.NewHostCmd(provider.Command(), "network", "rm", name).Run(); err != nil {
+		return errors.Wrapf(err, "failed to delete docker network %s", name)
+	}
+	return nil
+}
+
+// getSubnets returns the subnets of a docker network
+func getSubnets(networkName string) ([]string, error) {
+	format := provider.Get().NetworkSubnetsFormat()
+	cmd := exec.NewHostCmd(provider.Command(), "network", "inspect", "-f", format, networkName)
+	lines, err := cmd.RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get subnets")
+	}
+	return strings.Split(strings.TrimSpace(lines[0]), " "), nil
+}
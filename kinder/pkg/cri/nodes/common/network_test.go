@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+func TestNetworkCreateArgs(t *testing.T) {
+	baseArgs := []string{"network", "create", "--driver=bridge", "--label", "io.x-k8s.kind.cluster=kind"}
+	ipv6Subnet := clusterIPv6Subnet("kind")
+
+	tests := []struct {
+		name          string
+		subnet        string
+		ipFamily      status.ClusterIPFamily
+		expectedArgs  []string
+		expectedError bool
+	}{
+		{
+			name:         "ipv4 without subnet",
+			ipFamily:     status.IPv4Family,
+			expectedArgs: append(baseArgs, "kinder-kind"),
+		},
+		{
+			name:         "ipv4 with subnet",
+			subnet:       "172.30.0.0/16",
+			ipFamily:     status.IPv4Family,
+			expectedArgs: append(baseArgs, "--subnet=172.30.0.0/16", "kinder-kind"),
+		},
+		{
+			name:         "ipv6 without subnet",
+			ipFamily:     status.IPv6Family,
+			expectedArgs: append(baseArgs, "--ipv6", "--subnet="+ipv6Subnet, "kinder-kind"),
+		},
+		{
+			name:         "ipv6 with subnet",
+			subnet:       "fd00:1::/64",
+			ipFamily:     status.IPv6Family,
+			expectedArgs: append(baseArgs, "--ipv6", "--subnet=fd00:1::/64", "kinder-kind"),
+		},
+		{
+			name:         "dual with ipv4 subnet",
+			subnet:       "172.30.0.0/16",
+			ipFamily:     status.DualStackFamily,
+			expectedArgs: append(baseArgs, "--subnet=172.30.0.0/16", "--ipv6", "--subnet="+ipv6Subnet, "kinder-kind"),
+		},
+		{
+			name:          "ipv4 with ipv6 subnet",
+			subnet:        "fd00:1::/64",
+			ipFamily:      status.IPv4Family,
+			expectedError: true,
+		},
+		{
+			name:          "invalid subnet",
+			subnet:        "172.30.0.0",
+			ipFamily:      status.IPv4Family,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := networkCreateArgs("kind", test.subnet, test.ipFamily)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(args, test.expectedArgs) {
+				t.Errorf("expected args %v, got %v", test.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestClusterIPv6Subnet(t *testing.T) {
+	if clusterIPv6Subnet("kind") != clusterIPv6Subnet("kind") {
+		t.Error("expected the same subnet for the same cluster name")
+	}
+	if clusterIPv6Subnet("kind") == clusterIPv6Subnet("other") {
+		t.Error("expected different subnets for different cluster names")
+	}
+}
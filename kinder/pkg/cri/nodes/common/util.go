@@ -20,17 +20,22 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"k8s.io/kubeadm/kinder/pkg/cluster/config"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/constants"
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // BaseRunArgs computes docker arguments that apply to all containers
-func BaseRunArgs(cluster, name, role string) ([]string, error) {
+func BaseRunArgs(cluster, name, role string, ipFamily status.ClusterIPFamily) ([]string, error) {
 	// standard arguments all nodes containers need, computed once
 	args := []string{
 		"run",
@@ -46,11 +51,20 @@ func BaseRunArgs(cluster, name, role string) ([]string, error) {
 		"--label", fmt.Sprintf("%s=%s", constants.DeprecatedNodeRoleLabelKey, role),
 	}
 
-	// TODO: enable IPv6 if necessary
-	// args = append(args, "--sysctl=net.ipv6.conf.all.disable_ipv6=0", "--sysctl=net.ipv6.conf.all.forwarding=1")
+	// attach the container to the cluster network
+	network := NetworkName(cluster)
+	args = append(args, "--network", network)
+
+	// IPv6 and dual-stack clusters use an IPv6 enabled docker network, and IPv6 must be enabled in the containers
+	if ipFamily == status.IPv6Family || ipFamily == status.DualStackFamily {
+		args = append(args,
+			"--sysctl=net.ipv6.conf.all.disable_ipv6=0",
+			"--sysctl=net.ipv6.conf.all.forwarding=1",
+		)
+	}
 
 	// pass proxy environment variables
-	proxyEnv, err := getProxyEnvs()
+	proxyEnv, err := getProxyEnvs(network)
 	if err != nil {
 		return nil, errors.Wrap(err, "proxy setup error")
 	}
@@ -67,6 +81,10 @@ func BaseRunArgs(cluster, name, role string) ([]string, error) {
 
 // UsernsRemap checks if userns-remap is enabled in dockerd
 func UsernsRemap() bool {
+	// userns-remap is a dockerd specific feature
+	if provider.Get() != provider.Docker {
+		return false
+	}
 	cmd := exec.NewHostCmd("docker", "info", "--format", "'{{json .SecurityOptions}}'")
 	lines, err := cmd.RunAndCapture()
 	if err != nil {
@@ -81,13 +99,12 @@ func UsernsRemap() bool {
 }
 
 const (
-	defaultNetwork = "bridge"
-	httpProxy      = "HTTP_PROXY"
-	httpsProxy     = "HTTPS_PROXY"
-	noProxy        = "NO_PROXY"
+	httpProxy  = "HTTP_PROXY"
+	httpsProxy = "HTTPS_PROXY"
+	noProxy    = "NO_PROXY"
 )
 
-func getProxyEnvs() (map[string]string, error) {
+func getProxyEnvs(network string) (map[string]string, error) {
 	envs := make(map[string]string)
 	for _, name := range []string{httpProxy, httpsProxy, noProxy} {
 		val := os.Getenv(name)
@@ -112,8 +129,7 @@ func getProxyEnvs() (map[string]string, error) {
 
 	// Specifically add the docker network subnets to NO_PROXY if we are using a proxy
 	if len(envs) > 0 {
-		// Docker default bridge network is named "bridge" (https://docs.docker.com/network/bridge/#use-the-default-bridge-network)
-		subnets, err := getSubnets(defaultNetwork)
+		subnets, err := getSubnets(network)
 		if err != nil {
 			return nil, err
 		}
@@ -125,16 +141,6 @@ func getProxyEnvs() (map[string]string, error) {
 	return envs, nil
 }
 
-func getSubnets(networkName string) ([]string, error) {
-	format := `{{range (index (index . "IPAM") "Config")}}{{index . "Subnet"}} {{end}}`
-	cmd := exec.NewHostCmd("docker", "network", "inspect", "-f", format, networkName)
-	lines, err := cmd.RunAndCapture()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get subnets")
-	}
-	return strings.Split(strings.TrimSpace(lines[0]), " "), nil
-}
-
 // RunArgsForNode computes docker run arguments that apply to containers that should host K8s nodes
 func RunArgsForNode(role string, volumes []string, args []string) ([]string, error) {
 	args = append(args,
@@ -174,6 +180,62 @@ func RunArgsForNode(role string, volumes []string, args []string) ([]string, err
 	return args, nil
 }
 
+// RunArgsForNodeConfig computes docker run arguments for the additional settings defined in the node config,
+// like extra mounts, extra port mappings, labels, env variables and resource limits
+func RunArgsForNodeConfig(node config.Node, args []string) []string {
+	for _, m := range node.ExtraMounts {
+		v := fmt.Sprintf("%s:%s", m.HostPath, m.ContainerPath)
+		if m.ReadOnly {
+			v += ":ro"
+		}
+		args = append(args, "--volume", v)
+	}
+
+	for _, p := range node.ExtraPortMappings {
+		// IPv6 listen addresses must be enclosed in brackets, e.g. [::1]:8080:80
+		var publish string
+		switch {
+		case p.ListenAddress != "" && p.HostPort != 0:
+			publish = fmt.Sprintf("%s:%d", net.JoinHostPort(p.ListenAddress, strconv.Itoa(int(p.HostPort))), p.ContainerPort)
+		case p.ListenAddress != "":
+			publish = fmt.Sprintf("%s:%d", net.JoinHostPort(p.ListenAddress, ""), p.ContainerPort)
+		case p.HostPort != 0:
+			publish = fmt.Sprintf("%d:%d", p.HostPort, p.ContainerPort)
+		default:
+			publish = fmt.Sprintf("%d", p.ContainerPort)
+		}
+		args = append(args, fmt.Sprintf("--publish=%s/%s", publish, p.Protocol))
+	}
+
+	for _, k := range sortedKeys(node.Labels) {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", k, node.Labels[k]))
+	}
+
+	for _, k := range sortedKeys(node.Env) {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", k, node.Env[k]))
+	}
+
+	if node.Resources != nil {
+		if node.Resources.CPUs != "" {
+			args = append(args, fmt.Sprintf("--cpus=%s", node.Resources.CPUs))
+		}
+		if node.Resources.Memory != "" {
+			args = append(args, fmt.Sprintf("--memory=%s", node.Resources.Memory))
+		}
+	}
+
+	return args
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // helper used to get a free TCP port for the API server
 func getPort() (int32, error) {
 	dummyListener, err := net.Listen("tcp", ":0")
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/config"
+)
+
+func TestRunArgsForNodeConfigPortMappings(t *testing.T) {
+	tests := []struct {
+		name         string
+		mapping      config.PortMapping
+		expectedArgs []string
+	}{
+		{
+			name:         "container port only",
+			mapping:      config.PortMapping{ContainerPort: 80, Protocol: "TCP"},
+			expectedArgs: []string{"--publish=80/TCP"},
+		},
+		{
+			name:         "host port",
+			mapping:      config.PortMapping{ContainerPort: 80, HostPort: 8080, Protocol: "TCP"},
+			expectedArgs: []string{"--publish=8080:80/TCP"},
+		},
+		{
+			name:         "ipv4 listen address",
+			mapping:      config.PortMapping{ContainerPort: 80, HostPort: 8080, ListenAddress: "127.0.0.1", Protocol: "TCP"},
+			expectedArgs: []string{"--publish=127.0.0.1:8080:80/TCP"},
+		},
+		{
+			name:         "ipv4 listen address without host port",
+			mapping:      config.PortMapping{ContainerPort: 80, ListenAddress: "127.0.0.1", Protocol: "UDP"},
+			expectedArgs: []string{"--publish=127.0.0.1::80/UDP"},
+		},
+		{
+			name:         "ipv6 listen address",
+			mapping:      config.PortMapping{ContainerPort: 80, HostPort: 8080, ListenAddress: "::1", Protocol: "TCP"},
+			expectedArgs: []string{"--publish=[::1]:8080:80/TCP"},
+		},
+		{
+			name:         "ipv6 listen address without host port",
+			mapping:      config.PortMapping{ContainerPort: 80, ListenAddress: "::1", Protocol: "TCP"},
+			expectedArgs: []string{"--publish=[::1]::80/TCP"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := config.Node{ExtraPortMappings: []config.PortMapping{test.mapping}}
+			args := RunArgsForNodeConfig(node, nil)
+			if !reflect.DeepEqual(args, test.expectedArgs) {
+				t.Errorf("expected args %v, got %v", test.expectedArgs, args)
+			}
+		})
+	}
+}
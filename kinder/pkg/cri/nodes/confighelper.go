@@ -44,6 +44,8 @@ func (h *ConfigHelper) GetKubeadmConfigPatches(kubeadmConfigVersion string, cont
 		return []string{}, nil
 	case status.DockerRuntime:
 		return kubeadm.GetDockerPatch(kubeadmConfigVersion, controlPlane)
+	case status.CRIORuntime:
+		return kubeadm.GetCRIOPatch(kubeadmConfigVersion)
 	}
 	return nil, errors.Errorf("unknown cri: %s", h.cri)
 }
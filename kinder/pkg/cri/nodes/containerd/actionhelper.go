@@ -17,6 +17,9 @@ limitations under the License.
 package containerd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/pkg/errors"
 
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
@@ -43,3 +46,27 @@ func GetImages(n *status.Node) ([]string, error) {
 
 	return current, nil
 }
+
+// StopContainers stops the containers with the given name in the node
+func StopContainers(n *status.Node, name string) error {
+	return n.Command(
+		"/bin/sh", "-c", "crictl ps --name="+name+" -q | xargs -r crictl stop",
+	).RunWithEcho()
+}
+
+// ListContainers returns the IDs of the containers with the given name in the node, including stopped containers
+func ListContainers(n *status.Node, name string) ([]string, error) {
+	lines, err := n.Command(
+		"crictl", "ps", "-a", "-q", fmt.Sprintf("--name=^%s$", name),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list %s containers", name)
+	}
+	ids := []string{}
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			ids = append(ids, l)
+		}
+	}
+	return ids, nil
+}
@@ -29,6 +29,7 @@ import (
 	"k8s.io/kubeadm/kinder/pkg/build/bits"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/containerd/config"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // GetAlterContainerArgs returns arguments for the alter container for containerd
@@ -152,7 +153,7 @@ func Commit(containerID, targetImage string) error {
 	// NB. this code is an extract from "sigs.k8s.io/kind/pkg/build/node"
 
 	// Save the image changes to a new image
-	cmd := exec.Command("docker", "commit",
+	cmd := exec.Command(provider.Command(), provider.Get().CommitArgs(containerID, targetImage,
 		/*
 			The snapshot storage must be a volume to avoid overlay on overlay
 
@@ -161,10 +162,10 @@ func Commit(containerID, targetImage string) error {
 
 			See: https://docs.docker.com/engine/reference/builder/#volume
 		*/
-		"--change", `VOLUME [ "/var/lib/containerd" ]`,
+		`VOLUME [ "/var/lib/containerd" ]`,
 		// we need to put this back after changing it when running the image
-		"--change", `ENTRYPOINT [ "/usr/local/bin/entrypoint", "/sbin/init" ]`,
-		containerID, targetImage)
+		`ENTRYPOINT [ "/usr/local/bin/entrypoint", "/sbin/init" ]`,
+	)...)
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -19,12 +19,15 @@ package nodes
 import (
 	"github.com/pkg/errors"
 
+	"k8s.io/kubeadm/kinder/pkg/cluster/config"
 	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/constants"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/containerd"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/crio"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/docker"
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // CreateHelper provides CRI specific methods for node create
@@ -40,19 +43,21 @@ func NewCreateHelper(cri status.ContainerRuntime) (*CreateHelper, error) {
 }
 
 // CreateNode creates a container that internally hosts the selected cri runtime
-func (h *CreateHelper) CreateNode(cluster, name, image, role string, volumes []string) error {
+func (h *CreateHelper) CreateNode(cluster, name string, node config.Node, volumes []string, ipFamily status.ClusterIPFamily) error {
 	switch h.cri {
 	case status.ContainerdRuntime:
-		return containerd.CreateNode(cluster, name, image, role, volumes)
+		return containerd.CreateNode(cluster, name, node, volumes, ipFamily)
 	case status.DockerRuntime:
-		return docker.CreateNode(cluster, name, image, role, volumes)
+		return docker.CreateNode(cluster, name, node, volumes, ipFamily)
+	case status.CRIORuntime:
+		return crio.CreateNode(cluster, name, node, volumes, ipFamily)
 	}
 	return errors.Errorf("unknown cri: %s", h.cri)
 }
 
 // CreateExternalEtcd creates a container hosting a single node, insecure, external etcd cluster
-func (h *CreateHelper) CreateExternalEtcd(cluster, name, image string) error {
-	args, err := common.BaseRunArgs(cluster, name, constants.ExternalEtcdNodeRoleValue)
+func (h *CreateHelper) CreateExternalEtcd(cluster, name, image string, ipFamily status.ClusterIPFamily) error {
+	args, err := common.BaseRunArgs(cluster, name, constants.ExternalEtcdNodeRoleValue, ipFamily)
 	if err != nil {
 		return err
 	}
@@ -67,12 +72,12 @@ func (h *CreateHelper) CreateExternalEtcd(cluster, name, image string) error {
 	args = common.ContainerArgsForExternalEtcd(cluster, args)
 
 	// creates the container
-	return exec.NewHostCmd("docker", args...).Run()
+	return exec.NewHostCmd(provider.Command(), args...).Run()
 }
 
 // CreateExternalLoadBalancer creates a container hosting an external load balancer
-func (h *CreateHelper) CreateExternalLoadBalancer(cluster, name string) error {
-	args, err := common.BaseRunArgs(cluster, name, constants.ExternalLoadBalancerNodeRoleValue)
+func (h *CreateHelper) CreateExternalLoadBalancer(cluster, name string, ipFamily status.ClusterIPFamily) error {
+	args, err := common.BaseRunArgs(cluster, name, constants.ExternalLoadBalancerNodeRoleValue, ipFamily)
 	if err != nil {
 		return err
 	}
@@ -87,5 +92,5 @@ func (h *CreateHelper) CreateExternalLoadBalancer(cluster, name string) error {
 	args = append(args, constants.LoadBalancerImage)
 
 	// creates the container
-	return exec.NewHostCmd("docker", args...).Run()
+	return exec.NewHostCmd(provider.Command(), args...).Run()
 }
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crio
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+)
+
+// PreLoadUpgradeImages preload images required by kubeadm-upgrade into the CRI-O runtime that exists inside a kind(er) node
+func PreLoadUpgradeImages(n *status.Node, srcFolder string) error {
+	// NB. CRI-O and podman share the same image storage, so podman is used for loading images
+	return n.Command(
+		"bash", "-c",
+		`find `+srcFolder+` -name *.tar -print0 | xargs -0 -n 1 podman load -i`,
+	).Silent().Run()
+}
+
+// GetImages returns the list of images available in the node
+func GetImages(n *status.Node) ([]string, error) {
+	lines, err := n.Command(
+		"crictl", "images", "-o", "json",
+	).Silent().RunAndCapture()
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read current images from %s", n.Name())
+	}
+
+	return parseImages(lines)
+}
+
+// crictlImages defines the subset of the crictl images json output used by kinder
+type crictlImages struct {
+	Images []struct {
+		RepoTags []string `json:"repoTags"`
+	} `json:"images"`
+}
+
+// parseImages parses the crictl images json output into a list of image tags
+func parseImages(lines []string) ([]string, error) {
+	var list crictlImages
+	if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), &list); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the crictl images output")
+	}
+
+	images := []string{}
+	for _, i := range list.Images {
+		images = append(images, i.RepoTags...)
+	}
+	return images, nil
+}
+
+// StopContainers stops the containers with the given name in the node
+func StopContainers(n *status.Node, name string) error {
+	return n.Command(
+		"/bin/sh", "-c", "crictl ps --name="+name+" -q | xargs -r crictl stop",
+	).RunWithEcho()
+}
+
+// ListContainers returns the IDs of the containers with the given name in the node, including stopped containers
+func ListContainers(n *status.Node, name string) ([]string, error) {
+	lines, err := n.Command(
+		"crictl", "ps", "-a", "-q", fmt.Sprintf("--name=^%s$", name),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list %s containers", name)
+	}
+	ids := []string{}
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			ids = append(ids, l)
+		}
+	}
+	return ids, nil
+}
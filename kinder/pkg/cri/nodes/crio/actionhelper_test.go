@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseImages(t *testing.T) {
+	tests := []struct {
+		name           string
+		lines          []string
+		expectedImages []string
+		expectedError  bool
+	}{
+		{
+			name: "images with tags",
+			lines: []string{
+				`{"images": [`,
+				`  {"id": "1", "repoTags": ["registry.k8s.io/pause:3.10"], "repoDigests": []},`,
+				`  {"id": "2", "repoTags": ["registry.k8s.io/kube-apiserver:v1.31.0", "kube-apiserver:latest"]}`,
+				`]}`,
+			},
+			expectedImages: []string{
+				"registry.k8s.io/pause:3.10",
+				"registry.k8s.io/kube-apiserver:v1.31.0",
+				"kube-apiserver:latest",
+			},
+		},
+		{
+			name:           "images without tags",
+			lines:          []string{`{"images": [{"id": "1", "repoTags": []}]}`},
+			expectedImages: []string{},
+		},
+		{
+			name:          "invalid output",
+			lines:         []string{"FATA[0000] connect: connection refused"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			images, err := parseImages(test.lines)
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+			}
+			if err == nil && !reflect.DeepEqual(images, test.expectedImages) {
+				t.Errorf("expected images %v, got %v", test.expectedImages, images)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crio
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/kubeadm/kinder/pkg/build/bits"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
+	"k8s.io/kubeadm/kinder/pkg/kubeadm"
+	"k8s.io/kubeadm/kinder/pkg/provider"
+)
+
+// sandboxImageConfigPath is the CRI-O drop-in config file used by kinder for setting the sandbox image
+const sandboxImageConfigPath = "/etc/crio/crio.conf.d/99-kinder-pause.conf"
+
+// GetAlterContainerArgs returns arguments for the alter container for CRI-O
+func GetAlterContainerArgs() ([]string, []string) {
+	runArgs := []string{
+		// privileged is required for running crio and for loading images
+		"--privileged",
+		// override the entrypoint
+		"--entrypoint=/bin/sleep",
+	}
+	runCommands := []string{
+		// pass this to the entrypoint
+		"infinity",
+	}
+	return runArgs, runCommands
+}
+
+// SetupRuntime setups the runtime
+func SetupRuntime(bc *bits.BuildContext) error {
+	// Rewrite the crictl config, so crictl invoked by kinder or by the user on the node targets CRI-O
+	if err := bc.RunInContainer("bash", "-c",
+		fmt.Sprintf("printf 'runtime-endpoint: %[1]s\\nimage-endpoint: %[1]s\\n' > /etc/crictl.yaml", kubeadm.CRIOSocket),
+	); err != nil {
+		return errors.Wrap(err, "could not overwrite /etc/crictl.yaml")
+	}
+	if err := setupCRISandboxImage(bc); err != nil {
+		return err
+	}
+	return nil
+}
+
+// setupCRISandboxImage writes a CRI-O drop-in config file for using the sandbox image recommended by kubeadm.
+func setupCRISandboxImage(bc *bits.BuildContext) error {
+	binaryPath := "/kind/bin/kubeadm"
+	cmd := fmt.Sprintf(
+		`%[1]s config images list --kubernetes-version=$(%[1]s version -o short) 2> /dev/null | grep pause`,
+		binaryPath,
+	)
+	images, err := bc.CombinedOutputLinesInContainer("bash", "-c", cmd)
+	if err != nil {
+		return errors.Wrapf(err, "failed to execute command %q, output %v", cmd, images)
+	}
+	if len(images) != 1 {
+		return errors.Errorf("expected the output of command %q to have 1 line, got: %v", cmd, images)
+	}
+	if len(images[0]) == 0 {
+		return nil
+	}
+
+	log.Infof("updating the config file %s to use the recommended sandbox image %s", sandboxImageConfigPath, images[0])
+	if err := bc.RunInContainer("bash", "-c",
+		fmt.Sprintf("mkdir -p $(dirname %[1]s) && printf '[crio.image]\\npause_image = \"%[2]s\"\\n' > %[1]s", sandboxImageConfigPath, images[0]),
+	); err != nil {
+		return errors.Wrapf(err, "failed to setup the sandbox image %s for the CRI-O runtime", images[0])
+	}
+	log.Infof("configured the CRI-O runtime to use the sandbox image %s", images[0])
+	return nil
+}
+
+// StartRuntime starts the runtime
+func StartRuntime(bc *bits.BuildContext) error {
+	log.Info("starting crio")
+	go func() {
+		bc.RunInContainer("bash", "-c", "nohup crio > /dev/null 2>&1 &")
+	}()
+
+	duration := 10 * time.Second
+	result := common.TryUntil(time.Now().Add(duration), func() bool {
+		return bc.RunInContainer("bash", "-c", fmt.Sprintf("crictl --runtime-endpoint=%s ps &> /dev/null", kubeadm.CRIOSocket)) == nil
+	})
+	if !result {
+		return errors.Errorf("crio did not start in %v", duration)
+	}
+	log.Info("crio started")
+	return nil
+}
+
+// StopRuntime stops the runtime
+func StopRuntime(bc *bits.BuildContext) error {
+	return bc.RunInContainer("pkill", "-x", "crio")
+}
+
+// ImportImage import a TAR file into the CR and delete it
+func ImportImage(bc *bits.BuildContext, tar string) error {
+	// NB. CRI-O and podman share the same image storage, so podman is used for importing images
+	if err := bc.RunInContainer("podman", "load", "-i", tar); err != nil {
+		return errors.Wrapf(err, "could not import image file %q", tar)
+	}
+	if err := bc.RunInContainer("rm", tar); err != nil {
+		return errors.Wrapf(err, "could not delete the file %q", tar)
+	}
+	return nil
+}
+
+// PreLoadInitImages preload images required by kubeadm-init into the CRI-O runtime that exists inside a kind(er) node
+func PreLoadInitImages(bc *bits.BuildContext, srcFolder string) error {
+	// NB. images are loaded sequentially, because podman serializes access to the image storage anyway
+	return bc.RunInContainer(
+		"bash", "-c",
+		`find `+srcFolder+` -name *.tar -print0 | xargs -0 -n 1 podman load -i && rm -rf `+srcFolder+`/*.tar`,
+	)
+}
+
+// Commit a kind(er) node image that uses the CRI-O runtime internally
+func Commit(containerID, targetImage string) error {
+	// Save the image changes to a new image
+	cmd := exec.Command(provider.Command(), provider.Get().CommitArgs(containerID, targetImage,
+		// the image storage must be a volume to avoid overlay on overlay; see containerd.Commit
+		`VOLUME [ "/var/lib/containers" ]`,
+		// we need to put this back after changing it when running the image
+		`ENTRYPOINT [ "/usr/local/bin/entrypoint", "/sbin/init" ]`,
+	)...)
+
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
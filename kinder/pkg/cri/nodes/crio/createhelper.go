@@ -0,0 +1,51 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crio
+
+import (
+	"k8s.io/kubeadm/kinder/pkg/cluster/config"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
+	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
+	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
+)
+
+// CreateNode creates a container that internally hosts the CRI-O runtime
+func CreateNode(cluster, name string, node config.Node, volumes []string, ipFamily status.ClusterIPFamily) error {
+	args, err := common.BaseRunArgs(cluster, name, node.Role, ipFamily)
+	if err != nil {
+		return err
+	}
+
+	args, err = common.RunArgsForNode(node.Role, volumes, args)
+	if err != nil {
+		return err
+	}
+
+	// Add run args for the settings in the node config
+	args = common.RunArgsForNodeConfig(node, args)
+
+	// Specify the image to run
+	args = append(args, node.Image)
+
+	// creates the container
+	if err := exec.NewHostCmd(provider.Command(), args...).Run(); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package docker
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -48,3 +50,29 @@ func GetImages(n *status.Node) ([]string, error) {
 
 	return current, nil
 }
+
+// StopContainers stops the containers with the given name in the node
+func StopContainers(n *status.Node, name string) error {
+	// NB. containers created by the kubelet are named k8s_<container>_<pod>_<namespace>_...
+	return n.Command(
+		"/bin/sh", "-c", "docker ps --filter=name=k8s_"+name+"_ -q | xargs -r docker stop",
+	).RunWithEcho()
+}
+
+// ListContainers returns the IDs of the containers with the given name in the node, including stopped containers
+func ListContainers(n *status.Node, name string) ([]string, error) {
+	// NB. containers created by the kubelet are named k8s_<container>_<pod>_<namespace>_...
+	lines, err := n.Command(
+		"docker", "ps", "-a", "-q", fmt.Sprintf("--filter=name=k8s_%s_", name),
+	).Silent().RunAndCapture()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list %s containers", name)
+	}
+	ids := []string{}
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l != "" {
+			ids = append(ids, l)
+		}
+	}
+	return ids, nil
+}
@@ -26,6 +26,7 @@ import (
 
 	"k8s.io/kubeadm/kinder/pkg/build/bits"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // GetAlterContainerArgs returns arguments for alter container for Docker
@@ -105,7 +106,7 @@ func ImportImage(bc *bits.BuildContext, tar string) error {
 // Commit a kind(er) node image that uses the docker runtime internally
 func Commit(containerID, targetImage string) error {
 	// Save the image changes to a new image
-	cmd := exec.Command("docker", "commit", containerID, targetImage)
+	cmd := exec.Command(provider.Command(), provider.Get().CommitArgs(containerID, targetImage)...)
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
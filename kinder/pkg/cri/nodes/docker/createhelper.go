@@ -22,33 +22,39 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"k8s.io/kubeadm/kinder/pkg/cluster/config"
+	"k8s.io/kubeadm/kinder/pkg/cluster/status"
 	"k8s.io/kubeadm/kinder/pkg/cri/nodes/common"
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // CreateNode creates a container that internally hosts the docker cri runtime
-func CreateNode(cluster, name, image, role string, volumes []string) error {
-	args, err := common.BaseRunArgs(cluster, name, role)
+func CreateNode(cluster, name string, node config.Node, volumes []string, ipFamily status.ClusterIPFamily) error {
+	args, err := common.BaseRunArgs(cluster, name, node.Role, ipFamily)
 	if err != nil {
 		return err
 	}
 
-	args, err = common.RunArgsForNode(role, volumes, args)
+	args, err = common.RunArgsForNode(node.Role, volumes, args)
 	if err != nil {
 		return err
 	}
 
+	// Add run args for the settings in the node config
+	args = common.RunArgsForNodeConfig(node, args)
+
 	// Add run args for docker in docker
 	args = runArgsForDocker(args)
 
 	// Specify the image to run
-	args = append(args, image)
+	args = append(args, node.Image)
 
 	// dd container args for docker in docker
 	args = containerArgsForDocker(args)
 
 	// creates the container
-	if err := exec.NewHostCmd("docker", args...).Run(); err != nil {
+	if err := exec.NewHostCmd(provider.Command(), args...).Run(); err != nil {
 		return err
 	}
 
@@ -149,7 +155,7 @@ func fixMounts(name string) error {
 // see images/node/entrypoint
 func signalStart(name string) error {
 	cmd := exec.NewHostCmd(
-		"docker", "kill",
+		provider.Command(), "kill",
 		"-s", "SIGUSR1",
 		name,
 	)
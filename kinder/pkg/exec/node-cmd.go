@@ -28,6 +28,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"k8s.io/kubeadm/kinder/pkg/exec/colors"
+	"k8s.io/kubeadm/kinder/pkg/provider"
 )
 
 // NodeCmd allows to run a command on a kind(er) node
@@ -38,24 +39,85 @@ import (
 // By default, when the command is run it does not print any output generated during execution.
 // See Silent, Stdin, RunWithEcho, RunAndCapture, Skip and DryRun for possible variations to the default behavior.
 type NodeCmd struct {
-	node    string
-	command string
-	args    []string
-	silent  bool
-	dryRun  bool
-	stdin   io.Reader
-	stdout  io.Writer
-	stderr  io.Writer
+	node      string
+	command   string
+	args      []string
+	silent    bool
+	dryRun    bool
+	stdin     io.Reader
+	stdout    io.Writer
+	stderr    io.Writer
+	commander Commander
+}
+
+// Commander defines the interface for executing commands on kind(er) nodes.
+//
+// The default Commander uses the CLI of the selected provider, but alternative implementations
+// can be used e.g. for unit testing without actual node containers.
+type Commander interface {
+	// Exec executes a command on a node; stdin, stdout and stderr are optional
+	Exec(node, command string, args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// CLICommander is a Commander executing commands on kind(er) nodes with the exec command
+// of the selected provider CLI, e.g. docker exec
+type CLICommander struct{}
+
+var _ Commander = CLICommander{}
+
+// Exec executes a command on a node
+func (CLICommander) Exec(node, command string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	// prepare the args
+	proxyArgs := []string{
+		"exec",
+		// "--privileged"
+	}
+
+	// if it is requested to pipe data to the command itself, instruct docker exec to Keep STDIN open even if not attached
+	if stdin != nil {
+		proxyArgs = append(proxyArgs, "-i")
+	}
+
+	// add args for defining the target node container and the command to be executed
+	proxyArgs = append(
+		proxyArgs,
+		node,
+		command,
+	)
+
+	// adds the args for the command to be executed
+	proxyArgs = append(
+		proxyArgs,
+		args...,
+	)
+
+	// create the proxy commands
+	cmd := exec.Command(provider.Command(), proxyArgs...)
+
+	// redirects flows if requested
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	if stderr != nil {
+		cmd.Stderr = stderr
+	}
+
+	log.Debugf("Running: %s", strings.Join(cmd.Args, " "))
+	return cmd.Run()
 }
 
 // NewNodeCmd returns a new ProxyCmd to run a command on a kind(er) node
 func NewNodeCmd(node, command string, args ...string) *NodeCmd {
 	return &NodeCmd{
-		node:    node,
-		command: command,
-		args:    args,
-		silent:  false,
-		dryRun:  false,
+		node:      node,
+		command:   command,
+		args:      args,
+		silent:    false,
+		dryRun:    false,
+		commander: CLICommander{},
 	}
 }
 
@@ -98,6 +160,12 @@ func (c *NodeCmd) Silent() *NodeCmd {
 	return c
 }
 
+// WithCommander instructs the proxy command to use the given Commander for executing the inner command
+func (c *NodeCmd) WithCommander(commander Commander) *NodeCmd {
+	c.commander = commander
+	return c
+}
+
 // DryRun instruct the proxy command to print the inner command text instead of running it.
 func (c *NodeCmd) DryRun() *NodeCmd {
 	c.dryRun = true
@@ -105,47 +173,6 @@ func (c *NodeCmd) DryRun() *NodeCmd {
 }
 
 func (c *NodeCmd) runInnnerCommand() error {
-	// define the proxy command used to pass the command to the node container
-	command := "docker"
-
-	// prepare the args
-	args := []string{
-		"exec",
-		// "--privileged"
-	}
-
-	// if it is requested to pipe data to the command itself, instruct docker exec to Keep STDIN open even if not attached
-	if c.stdin != nil {
-		args = append(args, "-i")
-	}
-
-	// add args for defining the target node container and the command to be executed
-	args = append(
-		args,
-		c.node,
-		c.command,
-	)
-
-	// adds the args for the command to be executed
-	args = append(
-		args,
-		c.args...,
-	)
-
-	// create the proxy commands
-	cmd := exec.Command(command, args...)
-
-	// redirects flows if requested
-	if c.stdin != nil {
-		cmd.Stdin = c.stdin
-	}
-	if c.stdout != nil {
-		cmd.Stdout = c.stdout
-	}
-	if c.stderr != nil {
-		cmd.Stderr = c.stderr
-	}
-
 	// if not silent, prints the screen echo for the command to be executed
 	if !c.silent {
 		prompt := colors.Prompt(fmt.Sprintf("%s:$ ", c.node))
@@ -153,13 +180,12 @@ func (c *NodeCmd) runInnnerCommand() error {
 		fmt.Printf("\n%s%s\n", prompt, command)
 	}
 
-	// if we are dry running, eventually print the proxy command and then exit
+	// if we are dry running, eventually print the command and then exit
 	if c.dryRun {
-		log.Debugf("Running: %s", strings.Join(cmd.Args, " "))
+		log.Debugf("Dry run: %s %s", c.command, strings.Join(c.args, " "))
 		return nil
 	}
 
-	// eventually print the proxy command, and then run the command to be executed
-	log.Debugf("Running: %s", strings.Join(cmd.Args, " "))
-	return cmd.Run()
+	// run the command to be executed using the commander
+	return c.commander.Exec(c.node, c.command, c.args, c.stdin, c.stdout, c.stderr)
 }
@@ -0,0 +1,302 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	kindfs "sigs.k8s.io/kind/pkg/fs"
+)
+
+// bundleSourcePrefix is the prefix for sources pointing to an artifact bundle
+const bundleSourcePrefix = "bundle://"
+
+// KubeadmImagesLister returns the images required by a kubeadm binary that are not Kubernetes images,
+// e.g. etcd, coredns and pause
+type KubeadmImagesLister func(kubeadm string) ([]string, error)
+
+// CreateBundle gets Kubernetes artifacts from src and packs them into a bundle, that is a tar.gz file
+// that can be used as a source in air-gapped environments using bundle://FILE.
+//
+// The bundle contains Kubernetes binaries, images tarballs and the version file, as well as tarballs for the
+// additional images required by kubeadm, e.g. etcd, coredns and pause, as returned by listImages,
+// and the SHA256SUMS file for all the files in the bundle.
+func CreateBundle(src, bundle string, listImages KubeadmImagesLister, options ...Option) error {
+	tmpDir, err := kindfs.TempDir("", "kinder-bundle")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// get all the Kubernetes artifacts, ignoring options for reading only a subset of files
+	e := NewExtractor(src, tmpDir, options...)
+	e.files = make([]string, 0, len(allKubernetesBinaries)+len(AllKubernetesImages))
+	e.files = append(e.files, allKubernetesBinaries...)
+	e.files = append(e.files, AllKubernetesImages...)
+	e.addVersionFileToDst = true
+	e.dstMutator = fileNameMutator{}
+
+	paths, err := e.Extract()
+	if err != nil {
+		return err
+	}
+
+	// get the additional images required by kubeadm
+	images, err := listImages(paths[kubeadmBinary])
+	if err != nil {
+		return errors.Wrap(err, "failed to list the images required by kubeadm")
+	}
+
+	pullDir := filepath.Join(tmpDir, ".pull")
+	if err := os.Mkdir(pullDir, 0755); err != nil {
+		return err
+	}
+	c := newRegistryClient()
+	o := e.download
+	o.os = DefaultOS
+	for _, image := range images {
+		ref, err := parseImageReference(image)
+		if err != nil {
+			return err
+		}
+		name, err := additionalImageFileName(image)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Pulling %s", image)
+		if err := pullImageArchive(c, ref, o, pullDir, filepath.Join(tmpDir, name), image); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(pullDir); err != nil {
+		return err
+	}
+
+	// record digests of all the files in the bundle
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return err
+	}
+	digests := map[string]string{}
+	for _, entry := range entries {
+		if entry.Name() == ChecksumSHA256.checksumFileName() {
+			continue
+		}
+		if digests[entry.Name()], err = fileDigest(filepath.Join(tmpDir, entry.Name()), ChecksumSHA256); err != nil {
+			return err
+		}
+	}
+	if err := saveChecksumFile(tmpDir, ChecksumSHA256, digests); err != nil {
+		return err
+	}
+
+	if err := writeBundle(tmpDir, bundle); err != nil {
+		return err
+	}
+
+	log.Infof("Bundle saved into %s", bundle)
+	return nil
+}
+
+// imageNameRE splits image names, e.g. registry.k8s.io/etcd:3.5.15-0
+var imageNameRE = regexp.MustCompile("[/:]")
+
+// additionalImageFileName returns the name of the tarball for an image, e.g. etcd.tar for registry.k8s.io/etcd:3.5.15-0,
+// using the same naming used for the images pre-pulled in node images
+func additionalImageFileName(image string) (string, error) {
+	s := imageNameRE.Split(image, -1)
+	if len(s) < 3 {
+		return "", errors.Errorf("unsupported image URL: %s", image)
+	}
+	return s[len(s)-2] + ".tar", nil
+}
+
+// writeBundle writes all the files in the src folder into a tar.gz file
+func writeBundle(src, bundle string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	tmp := bundle + ".download"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return errors.Wrapf(err, "error creating %s", tmp)
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, name := range names {
+		if err := addFileToBundle(tw, filepath.Join(src, name)); err != nil {
+			return errors.Wrapf(err, "failed to add %s to the bundle", name)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, bundle)
+}
+
+func addFileToBundle(tw *tar.Writer, file string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	r, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(tw, r)
+	return err
+}
+
+// extractFromBundle extracts artifacts from a bundle created by CreateBundle, with src in the bundle://FILE format.
+// All the files in the bundle are verified against the SHA256SUMS file in the bundle; when Kubernetes images are
+// requested, also the tarballs for the additional images required by kubeadm are extracted.
+func extractFromBundle(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool, o downloadOptions) (paths map[string]string, err error) {
+	bundle, _ := filepath.Abs(strings.TrimPrefix(src, bundleSourcePrefix))
+	if _, err := os.Stat(bundle); os.IsNotExist(err) {
+		return nil, errors.Errorf("bundle %s does not exists", bundle)
+	}
+
+	tmpDir, err := kindfs.TempDir("", "kinder-bundle")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	log.Infof("Reading bundle %s", bundle)
+	if err := readBundle(bundle, tmpDir); err != nil {
+		return nil, errors.Wrapf(err, "failed to read bundle %s", bundle)
+	}
+
+	// verify all the files in the bundle
+	digests, err := readChecksumFile(tmpDir, ChecksumSHA256)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read checksums from bundle %s", bundle)
+	}
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Name() == ChecksumSHA256.checksumFileName() {
+			continue
+		}
+		digest, err := fileDigest(filepath.Join(tmpDir, entry.Name()), ChecksumSHA256)
+		if err != nil {
+			return nil, err
+		}
+		if expected, ok := digests[entry.Name()]; !ok || digest != expected {
+			return nil, errors.Errorf("sha256 checksum mismatch for %s in bundle %s", entry.Name(), bundle)
+		}
+	}
+
+	// if Kubernetes images are requested, extract also the other image tarballs
+	files = append([]string{}, files...)
+	requested := map[string]bool{}
+	for _, f := range files {
+		requested[f] = true
+	}
+	for _, f := range AllKubernetesImages {
+		if !requested[f] {
+			continue
+		}
+		for _, entry := range entries {
+			if strings.HasSuffix(entry.Name(), ".tar") && !requested[entry.Name()] {
+				files = append(files, entry.Name())
+				requested[entry.Name()] = true
+			}
+		}
+		break
+	}
+
+	return extractFromLocalDir(tmpDir, files, dst, m, addVersionFileToDst, o)
+}
+
+// readBundle reads all the files in the bundle into the dst folder
+func readBundle(bundle, dst string) error {
+	f, err := os.Open(bundle)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// bundles contain only regular files in the root folder
+		if hdr.Typeflag != tar.TypeReg || hdr.Name != filepath.Base(hdr.Name) || hdr.Name == ".." {
+			return errors.Errorf("unexpected entry %s", hdr.Name)
+		}
+		w, err := os.OpenFile(filepath.Join(dst, hdr.Name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, tr)
+		w.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
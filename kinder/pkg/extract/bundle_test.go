@@ -0,0 +1,165 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addImage(t, "etcd", "3.5.15-0", []layerFile{file("usr/local/bin/etcd", "etcd")})
+	registry.addImage(t, "pause", "3.10", []layerFile{file("pause", "pause")})
+
+	// a local repository with Kubernetes artifacts
+	src := t.TempDir()
+	artifacts := map[string]string{"kubeadm": "kubeadm", "version": "v1.31.0"}
+	for _, f := range append([]string{kubeletBinary, kubectlBinary}, AllKubernetesImages...) {
+		artifacts[f] = f
+	}
+	for f, content := range artifacts {
+		if err := os.WriteFile(filepath.Join(src, f), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	listImages := func(kubeadm string) ([]string, error) {
+		if b, err := os.ReadFile(kubeadm); err != nil || string(b) != "kubeadm" {
+			return nil, fmt.Errorf("unexpected kubeadm binary %s", kubeadm)
+		}
+		return []string{registry.host() + "/etcd:3.5.15-0", registry.host() + "/pause:3.10"}, nil
+	}
+	if err := CreateBundle(src, bundle, listImages, WithCacheDir("")); err != nil {
+		t.Fatalf("unexpected error creating the bundle: %v", err)
+	}
+
+	t.Run("all artifacts", func(t *testing.T) {
+		dst := t.TempDir()
+		paths, err := NewExtractor("bundle://"+bundle, dst).Extract()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		names := []string{}
+		for f := range paths {
+			names = append(names, f)
+		}
+		sort.Strings(names)
+		expected := []string{
+			"etcd.tar", "kube-apiserver.tar", "kube-controller-manager.tar", "kube-proxy.tar", "kube-scheduler.tar",
+			"kubeadm", "kubectl", "kubelet", "pause.tar", "version",
+		}
+		if !reflect.DeepEqual(names, expected) {
+			t.Fatalf("expected files %v, got %v", expected, names)
+		}
+		for f, content := range artifacts {
+			b, err := os.ReadFile(paths[f])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != content {
+				t.Errorf("expected %s to be %q, got %q", f, content, string(b))
+			}
+		}
+	})
+
+	t.Run("only kubeadm", func(t *testing.T) {
+		paths, err := NewExtractor("bundle://"+bundle, t.TempDir(), OnlyKubeadm(true)).Extract()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(paths) != 1 || paths[kubeadmBinary] == "" {
+			t.Errorf("expected only kubeadm to be extracted, got %v", paths)
+		}
+	})
+
+	t.Run("tampered bundle", func(t *testing.T) {
+		// rewrites the bundle, changing the content of kubelet
+		tampered := filepath.Join(t.TempDir(), "tampered.tar.gz")
+		if err := rewriteBundle(bundle, tampered, func(name string, content []byte) []byte {
+			if name == kubeletBinary {
+				return []byte("tampered")
+			}
+			return content
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := NewExtractor("bundle://"+tampered, t.TempDir()).Extract()
+		if err == nil || !strings.Contains(err.Error(), "sha256 checksum mismatch for kubelet") {
+			t.Fatalf("expected a checksum mismatch, got %v", err)
+		}
+	})
+}
+
+// rewriteBundle copies a bundle, allowing to change the content of files
+func rewriteBundle(src, dst string, fn func(string, []byte) []byte) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		content = fn(hdr.Name, content)
+		hdr.Size = int64(len(content))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+)
+
+// DefaultLabelTTL is the default time for which a label resolved to a version is cached,
+// e.g. ci/latest resolved to v1.32.0-alpha.1.100+5e4c8a1bdf3b5c
+const DefaultLabelTTL = time.Hour
+
+const (
+	// labelsCacheDir is the folder in the cache where resolved labels are stored
+	labelsCacheDir = "labels"
+
+	// remoteCacheDir is the folder in the cache where artifacts from remote repositories are stored
+	remoteCacheDir = "remote"
+)
+
+// DefaultCacheDir returns the default folder for the local artifact cache, that is
+// $XDG_CACHE_HOME/kinder/artifacts or $HOME/.cache/kinder/artifacts on linux;
+// if a user cache folder can't be determined, an empty string is returned and the cache is disabled.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		log.Debugf("local artifact cache disabled: %v", err)
+		return ""
+	}
+	return filepath.Join(dir, "kinder", "artifacts")
+}
+
+// cache implements a local cache for the artifacts downloaded from release or ci builds and from remote repositories.
+//
+// The cache is organized in folders:
+// - <root>/<version>/<arch> for artifacts from release or ci builds, e.g. <root>/v1.31.0/amd64/kubeadm
+// - <root>/remote/<hash of the source url> for artifacts from remote repositories
+// - <root>/labels/<hash of the label url> for resolved labels
+//
+// Artifacts from release or ci builds never change, and once in the cache, they are used without
+// accessing the remote source. Instead, artifacts from remote repositories are downloaded again if
+// their size changes. When checksum verification is enabled, cached artifacts are used only if
+// their digest still matches the one recorded when they were downloaded.
+type cache struct {
+	// root folder of the cache
+	root string
+	// only instructs to use only artifacts already in the cache, without accessing remote sources
+	only bool
+	// labelTTL is the time for which a resolved label is cached
+	labelTTL time.Duration
+}
+
+// buildDir returns the folder in the cache where artifacts for a release or ci build are stored
+func (c *cache) buildDir(version *K8sVersion.Version, goos, goarch string) string {
+	platform := goarch
+	if goos != DefaultOS {
+		platform = fmt.Sprintf("%s-%s", goos, goarch)
+	}
+	return filepath.Join(c.root, fmt.Sprintf("v%s", version), platform)
+}
+
+// remoteDir returns the folder in the cache where artifacts from a remote repository are stored
+func (c *cache) remoteDir(src string) string {
+	return filepath.Join(c.root, remoteCacheDir, hashKey(src))
+}
+
+// fetch returns the path of a file in the cache folder dir, downloading it from src if required.
+// If immutable is true, and the file is already in the cache, it is used without accessing src.
+func (c *cache) fetch(dir, src, name string, immutable bool, checksum ChecksumAlgorithm, p *progress) (path, digest string, err error) {
+	path = filepath.Join(dir, name)
+	verify := checksum != ChecksumNone && name != "version"
+
+	if immutable || c.only {
+		if digest, ok := c.lookup(dir, name, checksum, verify); ok {
+			log.Infof("Using %s from the cache", path)
+			touch(dir)
+			return path, digest, nil
+		}
+		if c.only {
+			return "", "", errors.Errorf("%s is not available in the cache at %s", src, dir)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", errors.Wrapf(err, "failed to make cache dir %s", dir)
+	}
+
+	log.Infof("Downloading %s\n", src)
+	if err := copyFromURI(src, path, p); err != nil {
+		return "", "", errors.Wrapf(err, "failed to copy %s to %s", src, path)
+	}
+
+	if verify {
+		digest, err = verifyChecksum(src, path, checksum)
+		if err != nil {
+			return "", "", err
+		}
+		if err := saveChecksumFile(dir, checksum, map[string]string{name: digest}); err != nil {
+			return "", "", errors.Wrapf(err, "error recording checksum in %s", dir)
+		}
+	}
+
+	touch(dir)
+	return path, digest, nil
+}
+
+// touch keeps track of the last usage of a cache folder, so unused folders can be pruned
+func touch(dir string) {
+	now := time.Now()
+	if err := os.Chtimes(dir, now, now); err != nil {
+		log.Debugf("failed to update the modification time of %s: %v", dir, err)
+	}
+}
+
+// lookup checks if a file exists in the cache folder dir; if verify is true, the file is considered
+// in the cache only if its digest matches the one recorded when the file was downloaded.
+func (c *cache) lookup(dir, name string, checksum ChecksumAlgorithm, verify bool) (string, bool) {
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	if !verify {
+		return "", true
+	}
+
+	recorded, err := readChecksumFile(dir, checksum)
+	if err != nil || recorded[name] == "" {
+		log.Debugf("no %s checksum recorded for %s", checksum, path)
+		return "", false
+	}
+	actual, err := fileDigest(path, checksum)
+	if err != nil || actual != recorded[name] {
+		log.Warnf("%s checksum mismatch for cached %s, ignoring the cached file", checksum, path)
+		return "", false
+	}
+	return actual, true
+}
+
+// lookupLabel returns the version for a label from the cache, if the label was resolved within labelTTL;
+// when using only the cache, the label is used no matter of when it was resolved
+func (c *cache) lookupLabel(uri string) (*K8sVersion.Version, bool) {
+	path := filepath.Join(c.root, labelsCacheDir, hashKey(uri))
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if !c.only && time.Since(info.ModTime()) > c.labelTTL {
+		log.Debugf("cached label %s is expired", uri)
+		return nil, false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	version, err := readVersion(f)
+	if err != nil {
+		return nil, false
+	}
+	return version, true
+}
+
+// saveLabel saves the version for a label in the cache
+func (c *cache) saveLabel(uri string, version *K8sVersion.Version) error {
+	dir := filepath.Join(c.root, labelsCacheDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, hashKey(uri)), []byte(fmt.Sprintf("v%s", version)), 0644)
+}
+
+// hashKey returns a key for storing in the cache items identified by an url
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// PruneCache removes from the cache in dir the artifacts not used and the labels not resolved
+// in the last olderThan; if olderThan is zero, the whole cache is removed.
+// PruneCache returns the list of removed folders and files.
+func PruneCache(dir string, olderThan time.Duration) (removed []string, err error) {
+	if dir == "" {
+		return nil, errors.New("the cache folder is not set")
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if olderThan == 0 {
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, errors.Wrapf(err, "failed to remove %s", dir)
+		}
+		return []string{dir}, nil
+	}
+
+	// collects cache items, that are the version/arch folders, the remote repository folders, and the label files
+	items, err := filepath.Glob(filepath.Join(dir, "v*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range []string{remoteCacheDir, labelsCacheDir} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern, "*"))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, matches...)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	for _, item := range items {
+		info, err := os.Stat(item)
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(item); err != nil {
+			return removed, errors.Wrapf(err, "failed to remove %s", item)
+		}
+		removed = append(removed, item)
+	}
+
+	// removes version folders left empty
+	versions, err := filepath.Glob(filepath.Join(dir, "v*"))
+	if err != nil {
+		return removed, err
+	}
+	for _, v := range versions {
+		if entries, err := os.ReadDir(v); err == nil && len(entries) == 0 {
+			if err := os.Remove(v); err != nil {
+				return removed, errors.Wrapf(err, "failed to remove %s", v)
+			}
+		}
+	}
+
+	return removed, nil
+}
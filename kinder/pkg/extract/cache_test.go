@@ -0,0 +1,244 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// fakeReleaseRepository is an http server hosting a release build for v1.31.0, and counting requests by path
+type fakeReleaseRepository struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func newFakeReleaseRepository(t *testing.T) *fakeReleaseRepository {
+	t.Helper()
+
+	kubeadm := []byte("kubeadm binary")
+	sum := sha256.Sum256(kubeadm)
+	files := map[string]string{
+		"/release/stable.txt":                             "v1.31.0",
+		"/release/v1.31.0/bin/linux/amd64/kubeadm":        string(kubeadm),
+		"/release/v1.31.0/bin/linux/amd64/kubeadm.sha256": hex.EncodeToString(sum[:]),
+		"/release/v1.31.0/bin/linux/arm64/kubeadm":        string(kubeadm),
+		"/release/v1.31.0/bin/linux/arm64/kubeadm.sha256": hex.EncodeToString(sum[:]),
+	}
+
+	r := &fakeReleaseRepository{requests: map[string]int{}}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.requests[req.URL.Path]++
+		r.mu.Unlock()
+
+		if c, ok := files[req.URL.Path]; ok {
+			w.Write([]byte(c))
+			return
+		}
+		http.NotFound(w, req)
+	}))
+	t.Cleanup(r.Close)
+
+	// points release builds to the fake repository, and do not retry failed downloads
+	repository, backoff := releaseBuildURepository, httpGetBackoff
+	t.Cleanup(func() {
+		releaseBuildURepository = repository
+		httpGetBackoff = backoff
+	})
+	releaseBuildURepository = r.URL + "/release"
+	httpGetBackoff = wait.Backoff{Steps: 1}
+
+	return r
+}
+
+// count returns the number of requests for a path, and resets the counter
+func (r *fakeReleaseRepository) count(path string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := r.requests[path]
+	delete(r.requests, path)
+	return n
+}
+
+func TestExtractWithCache(t *testing.T) {
+	r := newFakeReleaseRepository(t)
+	cacheDir := t.TempDir()
+
+	extract := func(src string, options ...Option) error {
+		e := NewExtractor(src, t.TempDir(),
+			append([]Option{OnlyKubeadm(true), WithCacheDir(cacheDir)}, options...)...,
+		)
+		_, err := e.Extract()
+		return err
+	}
+
+	// first extraction resolves the label and downloads kubeadm into the cache
+	if err := extract("release/stable"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := r.count("/release/stable.txt"); n != 1 {
+		t.Errorf("expected the label to be resolved, got %d requests", n)
+	}
+	if n := r.count("/release/v1.31.0/bin/linux/amd64/kubeadm"); n != 1 {
+		t.Errorf("expected kubeadm to be downloaded, got %d requests", n)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "v1.31.0", "amd64", "kubeadm")); err != nil {
+		t.Errorf("expected kubeadm to be stored in the cache: %v", err)
+	}
+	if sums, err := os.ReadFile(filepath.Join(cacheDir, "v1.31.0", "amd64", "SHA256SUMS")); err != nil || !strings.HasSuffix(string(sums), "  kubeadm\n") {
+		t.Errorf("expected the kubeadm checksum to be recorded in the cache, got %q, %v", sums, err)
+	}
+
+	// second extraction uses both the label and kubeadm from the cache
+	if err := extract("release/stable"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := r.count("/release/stable.txt"); n != 0 {
+		t.Errorf("expected the label to be read from the cache, got %d requests", n)
+	}
+	if n := r.count("/release/v1.31.0/bin/linux/amd64/kubeadm"); n != 0 {
+		t.Errorf("expected kubeadm to be read from the cache, got %d requests", n)
+	}
+
+	// expired labels are resolved again, but artifacts are still read from the cache
+	if err := extract("release/stable", WithLabelTTL(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := r.count("/release/stable.txt"); n != 1 {
+		t.Errorf("expected the expired label to be resolved again, got %d requests", n)
+	}
+	if n := r.count("/release/v1.31.0/bin/linux/amd64/kubeadm"); n != 0 {
+		t.Errorf("expected kubeadm to be read from the cache, got %d requests", n)
+	}
+
+	// artifacts for other architectures are cached separately
+	if err := extract("v1.31.0", WithArch("arm64")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := r.count("/release/v1.31.0/bin/linux/arm64/kubeadm"); n != 1 {
+		t.Errorf("expected kubeadm for arm64 to be downloaded, got %d requests", n)
+	}
+
+	// corrupted artifacts in the cache are downloaded again
+	if err := os.WriteFile(filepath.Join(cacheDir, "v1.31.0", "amd64", "kubeadm"), []byte("corrupted"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := extract("v1.31.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := r.count("/release/v1.31.0/bin/linux/amd64/kubeadm"); n != 1 {
+		t.Errorf("expected corrupted kubeadm to be downloaded again, got %d requests", n)
+	}
+
+	// when using only the cache, the remote repository is not accessed
+	r.Close()
+	if err := extract("release/stable", WithCacheOnly(true), WithLabelTTL(0)); err != nil {
+		t.Fatalf("unexpected error using only the cache: %v", err)
+	}
+	if err := extract("v1.31.0", WithCacheOnly(true), WithArch("s390x")); err == nil || !strings.Contains(err.Error(), "not available in the cache") {
+		t.Errorf("expected error for artifacts not in the cache, got %v", err)
+	}
+	if err := extract("release/latest", WithCacheOnly(true)); err == nil || !strings.Contains(err.Error(), "not available in the cache") {
+		t.Errorf("expected error for labels not in the cache, got %v", err)
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+
+	tests := []struct {
+		name      string
+		olderThan time.Duration
+		expected  []string
+	}{
+		{
+			name:      "prune items older than 24h",
+			olderThan: 24 * time.Hour,
+			expected:  []string{"labels/recent", "remote/recent", "v1.31.0/amd64"},
+		},
+		{
+			name:      "prune all",
+			olderThan: 0,
+			expected:  []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "artifacts")
+			items := map[string]bool{
+				"v1.30.0/amd64": true,
+				"v1.31.0/amd64": false,
+				"v1.31.0/arm64": true,
+				"remote/old":    true,
+				"remote/recent": false,
+				"labels/old":    true,
+				"labels/recent": false,
+			}
+			for item, isOld := range items {
+				path := filepath.Join(dir, item)
+				if strings.HasPrefix(item, "labels/") {
+					if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(path, []byte("v1.31.0"), 0644); err != nil {
+						t.Fatal(err)
+					}
+				} else if err := os.MkdirAll(path, 0755); err != nil {
+					t.Fatal(err)
+				}
+				if isOld {
+					if err := os.Chtimes(path, old, old); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			if _, err := PruneCache(dir, test.olderThan); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			remaining := []string{}
+			for _, pattern := range []string{"labels/*", "remote/*", "v*/*"} {
+				matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+				for _, m := range matches {
+					rel, _ := filepath.Rel(dir, m)
+					remaining = append(remaining, rel)
+				}
+			}
+			if strings.Join(remaining, ",") != strings.Join(test.expected, ",") {
+				t.Errorf("expected %v to remain in the cache, got %v", test.expected, remaining)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "v1.30.0")); !os.IsNotExist(err) {
+				t.Errorf("expected empty version folders to be removed")
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ChecksumAlgorithm defines the algorithm used for verifying the checksum of downloaded files
+type ChecksumAlgorithm string
+
+const (
+	// ChecksumAuto verifies SHA256 checksums when reading from release or ci builds, and skips verification otherwise
+	ChecksumAuto ChecksumAlgorithm = ""
+
+	// ChecksumNone disables checksum verification
+	ChecksumNone ChecksumAlgorithm = "none"
+
+	// ChecksumSHA256 verifies downloaded files against the .sha256 files published alongside
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+
+	// ChecksumSHA512 verifies downloaded files against the .sha512 files published alongside
+	ChecksumSHA512 ChecksumAlgorithm = "sha512"
+)
+
+// ParseChecksumAlgorithm returns the ChecksumAlgorithm with the given name
+func ParseChecksumAlgorithm(name string) (ChecksumAlgorithm, error) {
+	switch a := ChecksumAlgorithm(strings.ToLower(name)); a {
+	case ChecksumAuto, ChecksumNone, ChecksumSHA256, ChecksumSHA512:
+		return a, nil
+	}
+	return "", errors.Errorf("invalid checksum algorithm %q, must be one of %s, %s or %s", name, ChecksumSHA256, ChecksumSHA512, ChecksumNone)
+}
+
+// orDefault returns the given default algorithm if the algorithm is ChecksumAuto
+func (a ChecksumAlgorithm) orDefault(d ChecksumAlgorithm) ChecksumAlgorithm {
+	if a == ChecksumAuto {
+		return d
+	}
+	return a
+}
+
+func (a ChecksumAlgorithm) newHash() (hash.Hash, error) {
+	switch a {
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	}
+	return nil, errors.Errorf("unsupported checksum algorithm %q", a)
+}
+
+// checksumFileName returns the name of the file where the verified digests are recorded, e.g. SHA256SUMS;
+// the file uses the same format of the sha256sum/sha512sum utilities, so it can be used for checking files again.
+func (a ChecksumAlgorithm) checksumFileName() string {
+	return fmt.Sprintf("%sSUMS", strings.ToUpper(string(a)))
+}
+
+// verifyChecksum verifies the file downloaded from src into dst against the checksum file published alongside src,
+// and returns the verified digest. If the verification fails the dst file is deleted, so files that can't be
+// trusted are not used and they will be downloaded again.
+func verifyChecksum(src, dst string, a ChecksumAlgorithm) (digest string, err error) {
+	defer func() {
+		if err != nil {
+			if rerr := os.Remove(dst); rerr != nil {
+				log.Warnf("failed to remove %s: %v", dst, rerr)
+			}
+		}
+	}()
+
+	expected, err := readChecksum(fmt.Sprintf("%s.%s", src, a))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the %s checksum for %s", a, src)
+	}
+
+	actual, err := fileDigest(dst, a)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to compute the %s checksum for %s", a, dst)
+	}
+
+	if actual != expected {
+		return "", errors.Errorf("%s checksum mismatch for %s: expected %s, got %s", a, src, expected, actual)
+	}
+
+	log.Debugf("%s checksum for %s verified: %s", a, dst, actual)
+	return actual, nil
+}
+
+// readChecksum reads a checksum file; checksum files contain the hex encoded digest, eventually followed by the file name
+func readChecksum(uri string) (string, error) {
+	_, r, err := httpGet(uri)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return "", errors.Wrapf(err, "error reading %s", uri)
+	}
+
+	fields := strings.Fields(string(buf))
+	if len(fields) == 0 {
+		return "", errors.Errorf("checksum file %s is empty", uri)
+	}
+
+	digest := strings.ToLower(fields[0])
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", errors.Errorf("checksum file %s does not contain a valid digest", uri)
+	}
+	return digest, nil
+}
+
+func fileDigest(file string, a ChecksumAlgorithm) (string, error) {
+	h, err := a.newHash()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checksumFileMu serializes updates to checksum files, that might be executed by parallel downloads
+var checksumFileMu sync.Mutex
+
+// saveChecksumFile records the verified digests in the dst folder, merging them with the
+// digests recorded by previous extractions in the same folder, if any
+func saveChecksumFile(dst string, a ChecksumAlgorithm, digests map[string]string) error {
+	if len(digests) == 0 {
+		return nil
+	}
+
+	checksumFileMu.Lock()
+	defer checksumFileMu.Unlock()
+
+	checksumFile := filepath.Join(dst, a.checksumFileName())
+
+	all, err := readChecksumFile(dst, a)
+	if err != nil && !os.IsNotExist(errors.Cause(err)) {
+		return err
+	}
+	for name, digest := range digests {
+		all[name] = digest
+	}
+
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", all[name], name)
+	}
+	if err := os.WriteFile(checksumFile, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+
+	log.Infof("%s file created", a.checksumFileName())
+	return nil
+}
+
+// readChecksumFile returns the digests recorded in the dir folder, by file name
+func readChecksumFile(dir string, a ChecksumAlgorithm) (map[string]string, error) {
+	digests := map[string]string{}
+
+	f, err := os.Open(filepath.Join(dir, a.checksumFileName()))
+	if err != nil {
+		return digests, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			digests[fields[1]] = fields[0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return digests, errors.Wrapf(err, "error reading %s", f.Name())
+	}
+	return digests, nil
+}
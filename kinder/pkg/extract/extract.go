@@ -32,7 +32,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -43,13 +45,27 @@ import (
 	kindfs "sigs.k8s.io/kind/pkg/fs"
 )
 
-const (
+// repositories hosting release and ci builds; those are variables only for allowing unit tests
+// to use a local http server
+var (
 	ciBuildRepository       = "https://storage.googleapis.com/k8s-release-dev/ci"
 	releaseBuildURepository = "https://dl.k8s.io/release"
+)
 
+const (
 	kubeadmBinary = "kubeadm"
 	kubeletBinary = "kubelet"
 	kubectlBinary = "kubectl"
+
+	// DefaultOS is the default OS for the artifacts retrieved from release or ci builds
+	DefaultOS = "linux"
+
+	// DefaultParallelism is the default number of files downloaded in parallel
+	DefaultParallelism = 4
+
+	// DefaultArch is the default architecture for the artifacts retrieved from release or ci builds;
+	// it defaults to the architecture of the host where kinder is running
+	DefaultArch = runtime.GOARCH
 )
 
 var (
@@ -77,12 +93,27 @@ const (
 
 	// LocalRepositorySource describe a src that is hosted in local repository
 	LocalRepositorySource
+
+	// ImageSource describe a src that is hosted in a container image registry
+	ImageSource
+
+	// KubeRootSource describe a src that is built from a Kubernetes source checkout
+	KubeRootSource
+
+	// BundleSource describe a src that is packed in a bundle created with CreateBundle
+	BundleSource
 )
 
 // GetSourceType returns the src type descriptor
 func GetSourceType(src string) SourceType {
 	if strings.HasPrefix(src, "file://") {
 		return LocalRepositorySource
+	} else if strings.HasPrefix(src, imageSourcePrefix) {
+		return ImageSource
+	} else if strings.HasPrefix(src, kubeRootSourcePrefix) {
+		return KubeRootSource
+	} else if strings.HasPrefix(src, bundleSourcePrefix) {
+		return BundleSource
 	} else if strings.HasPrefix(src, "release/") {
 		return ReleaseLabelOrVersionSource
 	} else if strings.HasPrefix(src, "ci/") {
@@ -173,6 +204,103 @@ func WithVersionFolder(versionFolder bool) Option {
 	}
 }
 
+// WithOS option instructs the Extractor to retrieve artifacts for the given OS when reading from release or ci builds
+func WithOS(goos string) Option {
+	return func(b *Extractor) {
+		if goos != "" {
+			b.download.os = goos
+		}
+	}
+}
+
+// WithArch option instructs the Extractor to retrieve artifacts for the given architecture when reading from release or ci builds
+func WithArch(goarch string) Option {
+	return func(b *Extractor) {
+		if goarch != "" {
+			b.download.arch = goarch
+		}
+	}
+}
+
+// WithChecksum option instructs the Extractor to verify the checksum of downloaded files using the given algorithm;
+// by default, SHA256 checksums are verified only when reading from release or ci builds
+func WithChecksum(algorithm ChecksumAlgorithm) Option {
+	return func(b *Extractor) {
+		b.download.checksum = algorithm
+	}
+}
+
+// WithCacheDir option instructs the Extractor to use dir for the local artifact cache;
+// if dir is empty, the local artifact cache is disabled
+func WithCacheDir(dir string) Option {
+	return func(b *Extractor) {
+		b.cache.root = dir
+	}
+}
+
+// WithCacheOnly option instructs the Extractor to use only artifacts and labels already in the local artifact cache,
+// without accessing release or ci builds or remote repositories
+func WithCacheOnly(cacheOnly bool) Option {
+	return func(b *Extractor) {
+		b.cache.only = cacheOnly
+	}
+}
+
+// WithLabelTTL option instructs the Extractor for how long labels resolved to a version are cached
+func WithLabelTTL(ttl time.Duration) Option {
+	return func(b *Extractor) {
+		b.cache.labelTTL = ttl
+	}
+}
+
+// WithBuild option instructs the Extractor to build missing artifacts when reading from a Kubernetes source checkout
+func WithBuild(build bool) Option {
+	return func(b *Extractor) {
+		b.download.build = build
+	}
+}
+
+// WithParallelism option instructs the Extractor for how many files to download in parallel
+func WithParallelism(n int) Option {
+	return func(b *Extractor) {
+		if n > 0 {
+			b.download.parallelism = n
+		}
+	}
+}
+
+// WithInsecureRegistry option instructs the Extractor to access the registry of image sources via http instead of https;
+// registries on localhost are always accessed via http
+func WithInsecureRegistry(insecure bool) Option {
+	return func(b *Extractor) {
+		b.download.insecureRegistry = insecure
+	}
+}
+
+// downloadOptions defines options for the artifacts downloaded from release or ci builds
+// or from remote repositories, or built from a Kubernetes source checkout
+type downloadOptions struct {
+	// os and arch of the artifacts to download from release or ci builds, or to build
+	os   string
+	arch string
+	// checksum is the algorithm used for verifying downloaded files
+	checksum ChecksumAlgorithm
+	// cache is the local artifact cache; if nil, the cache is disabled
+	cache *cache
+	// cacheDir is the folder in the cache for the artifacts of the current source
+	cacheDir string
+	// immutable is true when artifacts in the current source never change, like in release or ci builds
+	immutable bool
+	// parallelism is the number of files downloaded in parallel
+	parallelism int
+	// progress reports the progress of downloads
+	progress *progress
+	// build instructs to build missing artifacts from a Kubernetes source checkout
+	build bool
+	// insecureRegistry instructs to access the registry of image sources via http
+	insecureRegistry bool
+}
+
 // Extractor defines attributes for a Kubernetes artifact extractor
 type Extractor struct {
 	// src is the source from where to extract file
@@ -185,6 +313,10 @@ type Extractor struct {
 	dstMutator fileNameMutator
 	// add version file to dst
 	addVersionFileToDst bool
+	// options for downloading artifacts
+	download downloadOptions
+	// local artifact cache
+	cache cache
 }
 
 // NewExtractor returns a new extractor configured with the given options
@@ -195,6 +327,8 @@ func NewExtractor(src, dst string, options ...Option) (extractor *Extractor) {
 		dst:                 dst,
 		dstMutator:          fileNameMutator{},
 		addVersionFileToDst: true,
+		download:            downloadOptions{os: DefaultOS, arch: DefaultArch, checksum: ChecksumAuto, parallelism: DefaultParallelism},
+		cache:               cache{root: DefaultCacheDir(), labelTTL: DefaultLabelTTL},
 	}
 
 	// apply user options
@@ -214,38 +348,67 @@ func (e *Extractor) SetFiles(files []string) {
 func (e *Extractor) Extract() (paths map[string]string, err error) {
 	var f extractFunc
 
+	o := e.download
+	if e.cache.root != "" {
+		c := e.cache
+		o.cache = &c
+	} else if e.cache.only {
+		return nil, errors.New("the local artifact cache is disabled, it can't be used as the only source")
+	}
+
 	switch GetSourceType(e.src) {
 	case ReleaseLabelOrVersionSource:
 		f = extractFromReleaseBuild
+		o.checksum = o.checksum.orDefault(ChecksumSHA256)
 	case CILabelOrVersionSource:
 		f = extractFromCIBuild
+		o.checksum = o.checksum.orDefault(ChecksumSHA256)
 	case RemoteRepositorySource:
 		f = extractFromHTTP
+		o.checksum = o.checksum.orDefault(ChecksumNone)
+		if o.cache != nil {
+			o.cacheDir = o.cache.remoteDir(e.src)
+		}
 	case LocalRepositorySource:
 		f = extractFromLocalDir
+	case ImageSource:
+		f = extractFromImage
+		if e.cache.only {
+			return nil, errors.New("image sources are not stored in the local artifact cache")
+		}
+	case KubeRootSource:
+		f = extractFromKubeRoot
+	case BundleSource:
+		f = extractFromBundle
 	default:
 		return nil, errors.Errorf("source %s did not resolve to a valid source type", e.src)
 	}
 
-	return f(e.src, e.files, e.dst, e.dstMutator, e.addVersionFileToDst)
+	return f(e.src, e.files, e.dst, e.dstMutator, e.addVersionFileToDst, o)
 }
 
 // extractFunc define a function that implements an extractor method
-type extractFunc func(string, []string, string, fileNameMutator, bool) (map[string]string, error)
+type extractFunc func(string, []string, string, fileNameMutator, bool, downloadOptions) (map[string]string, error)
 
-func extractFromCIBuild(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool) (paths map[string]string, err error) {
+func extractFromCIBuild(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool, o downloadOptions) (paths map[string]string, err error) {
 	// cleanup the src from the prefix, if any
 	src = strings.TrimPrefix(src, "ci/")
 
 	// gets the Kubernetes version from the src
 	version, err := K8sVersion.ParseSemantic(src)
 	if err != nil {
-		version, err = resolveLabel(ciBuildRepository, src)
+		version, err = resolveLabel(ciBuildRepository, src, o.cache)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// artifacts for a version never change, so they can be cached by version and arch
+	if o.cache != nil {
+		o.cacheDir = o.cache.buildDir(version, o.os, o.arch)
+		o.immutable = true
+	}
+
 	// saves the version file (if requested)
 	// nb. version file is created so the target folder can be eventually used as a source
 	if err := saveVersionFile(addVersionFileToDst, dst, version, m); err != nil {
@@ -260,22 +423,28 @@ func extractFromCIBuild(src string, files []string, dst string, m fileNameMutato
 	src = fmt.Sprintf("%s/v%s", ciBuildRepository, version)
 
 	// read from the src via http, taking care of setting addVersionFileToDst (because it was already saved above)
-	return extractFromHTTP(src, files, dst, m, false)
+	return extractFromHTTP(src, files, dst, m, false, o)
 }
 
-func extractFromReleaseBuild(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool) (paths map[string]string, err error) {
+func extractFromReleaseBuild(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool, o downloadOptions) (paths map[string]string, err error) {
 	// cleanup the source src the prefix, if any
 	src = strings.TrimPrefix(src, "release/")
 
 	// gets the Kubernetes version from the src
 	version, err := K8sVersion.ParseSemantic(src)
 	if err != nil {
-		version, err = resolveLabel(releaseBuildURepository, src)
+		version, err = resolveLabel(releaseBuildURepository, src, o.cache)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// artifacts for a version never change, so they can be cached by version and arch
+	if o.cache != nil {
+		o.cacheDir = o.cache.buildDir(version, o.os, o.arch)
+		o.immutable = true
+	}
+
 	// saves the version file (if requested)
 	// nb. version file is created so the target folder can be eventually used as a source
 	if err := saveVersionFile(addVersionFileToDst, dst, version, m); err != nil {
@@ -290,10 +459,10 @@ func extractFromReleaseBuild(src string, files []string, dst string, m fileNameM
 	src = fmt.Sprintf("%s/v%s", releaseBuildURepository, version)
 
 	// read from the src via http, taking care of setting addVersionFileToDst (because it was already saved above)
-	return extractFromHTTP(src, files, dst, m, false)
+	return extractFromHTTP(src, files, dst, m, false, o)
 }
 
-func extractFromHTTP(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool) (paths map[string]string, err error) {
+func extractFromHTTP(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool, o downloadOptions) (paths map[string]string, err error) {
 	dst, _ = filepath.Abs(dst)
 	if _, err := os.Stat(dst); os.IsNotExist(err) {
 		return nil, errors.Errorf("destination path %s does not exists", dst)
@@ -313,29 +482,90 @@ func extractFromHTTP(src string, files []string, dst string, m fileNameMutator,
 
 	// in case the source is a Kubernetes build, add bin/OS/ARCH to the src uri
 	if strings.HasPrefix(src, releaseBuildURepository) || strings.HasPrefix(src, ciBuildRepository) {
-		src = fmt.Sprintf("%s/bin/linux/amd64", src)
+		src = fmt.Sprintf("%s/bin/%s/%s", src, o.os, o.arch)
 	}
 
-	// Download the files.
+	// Download the files, with at most o.parallelism downloads running at the same time.
+	o.progress = newProgress(len(files))
 	paths = map[string]string{}
+	digests := map[string]string{}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	sem := make(chan struct{}, max(o.parallelism, 1))
 	for _, f := range files {
-		srcFilePath := fmt.Sprintf("%s/%s", src, f)
-		log.Infof("Downloading %s\n", srcFilePath)
-		dstFilePath := path.Join(dst, m.Mutate(f))
-		if err := copyFromURI(srcFilePath, dstFilePath); err != nil {
-			return nil, errors.Wrapf(err, "failed to copy %s to %s", srcFilePath, dstFilePath)
-		}
-		if f == kubeadmBinary || f == kubeletBinary || f == kubectlBinary {
-			os.Chmod(dstFilePath, 0755)
-		}
-		paths[f] = dstFilePath
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(f string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			srcFilePath := fmt.Sprintf("%s/%s", src, f)
+			dstFilePath := path.Join(dst, m.Mutate(f))
+			digest, err := download(srcFilePath, dstFilePath, f, o)
+			o.progress.fileCompleted()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if digest != "" {
+				digests[m.Mutate(f)] = digest
+			}
+			if f == kubeadmBinary || f == kubeletBinary || f == kubectlBinary {
+				os.Chmod(dstFilePath, 0755)
+			}
+			paths[f] = dstFilePath
+		}(f)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
 	}
 	log.Infof("Downloaded files saved into %s", dst)
 
+	// record the verified digests in the dst folder, next to the version file
+	if err := saveChecksumFile(dst, o.checksum, digests); err != nil {
+		return nil, errors.Wrapf(err, "error creating checksum file in %s", dst)
+	}
+
 	return paths, nil
 }
 
-func extractFromLocalDir(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool) (paths map[string]string, err error) {
+// download copies a file from src to dst, going through the local artifact cache if enabled,
+// and returns the verified digest if checksum verification is enabled
+func download(src, dst, name string, o downloadOptions) (digest string, err error) {
+	if o.cacheDir != "" {
+		cached, digest, err := o.cache.fetch(o.cacheDir, src, name, o.immutable, o.checksum, o.progress)
+		if err != nil {
+			return "", err
+		}
+		if err := kindfs.CopyFile(cached, dst); err != nil {
+			return "", errors.Wrapf(err, "failed to copy %s to %s", cached, dst)
+		}
+		return digest, nil
+	}
+
+	log.Infof("Downloading %s\n", src)
+	if err := copyFromURI(src, dst, o.progress); err != nil {
+		return "", errors.Wrapf(err, "failed to copy %s to %s", src, dst)
+	}
+
+	// verify the checksum of the downloaded file, if required
+	// nb. the version file is generated by kinder, and no checksum are published for it
+	if o.checksum == ChecksumNone || name == "version" {
+		return "", nil
+	}
+	return verifyChecksum(src, dst, o.checksum)
+}
+
+func extractFromLocalDir(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool, o downloadOptions) (paths map[string]string, err error) {
 	// checks if source folder exists
 	src, _ = filepath.Abs(src)
 	if _, err := os.Stat(src); os.IsNotExist(err) {
@@ -434,7 +664,7 @@ func expandWildcards(src string, files []string) (expandedFiles []string, err er
 	return expandedFiles, nil
 }
 
-func resolveLabel(repository, label string) (version *K8sVersion.Version, err error) {
+func resolveLabel(repository, label string, c *cache) (version *K8sVersion.Version, err error) {
 	// labels are .txt file containing a release version
 
 	// Gets the uri of the label file
@@ -442,6 +672,17 @@ func resolveLabel(repository, label string) (version *K8sVersion.Version, err er
 	if !strings.HasSuffix(uri, ".txt") {
 		uri = uri + ".txt"
 	}
+
+	// checks if the label was recently resolved, if the local artifact cache is enabled
+	if c != nil {
+		if version, ok := c.lookupLabel(uri); ok {
+			log.Debugf("Label %s resolves to v%s (cached)\n", uri, version)
+			return version, nil
+		}
+		if c.only {
+			return nil, errors.Errorf("label %s is not available in the cache at %s", uri, c.root)
+		}
+	}
 	log.Debugf("Resolving label %s\n", uri)
 
 	// Do an HTTP GET and read the version from the txt file.
@@ -456,6 +697,12 @@ func resolveLabel(repository, label string) (version *K8sVersion.Version, err er
 		return nil, errors.Wrapf(err, "error reading version from %s", uri)
 	}
 
+	if c != nil {
+		if err := c.saveLabel(uri, version); err != nil {
+			log.Warnf("failed to cache label %s: %v", uri, err)
+		}
+	}
+
 	log.Debugf("Label %s resolves to v%s\n", uri, version)
 	return version, nil
 }
@@ -497,7 +744,7 @@ func saveVersionFile(addVersionFileToDst bool, dst string, version *K8sVersion.V
 	return nil
 }
 
-// Exponential backoff for httpGet (values exclude jitter):
+// Exponential backoff for HTTP requests (values exclude jitter):
 // 0, 2, 5, 8 ... 322 s
 var httpGetBackoff = wait.Backoff{
 	Steps:    20,
@@ -506,10 +753,68 @@ var httpGetBackoff = wait.Backoff{
 	Jitter:   0.1,
 }
 
-func httpGet(uri string) (int64, io.ReadCloser, error) {
+// httpStatusError is returned when an HTTP GET receives a response with an unexpected status code
+type httpStatusError struct {
+	uri    string
+	status string
+	code   int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP GET %s failed: %s", e.uri, e.status)
+}
+
+// isTerminalHTTPError returns true for errors that are not going to be fixed by retrying, like client errors (4xx),
+// except for request timeouts and rate limiting
+func isTerminalHTTPError(err error) bool {
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return statusErr.code >= 400 && statusErr.code < 500
+}
+
+// retryHTTP executes fn with exponential backoff, until it succeeds, it fails with a terminal error,
+// or the backoff is exhausted; in the last two cases the last error is returned
+func retryHTTP(fn func() error) error {
 	var lastError error
+	err := wait.ExponentialBackoff(httpGetBackoff, func() (bool, error) {
+		if err := fn(); err != nil {
+			lastError = err
+			if isTerminalHTTPError(err) {
+				return false, err
+			}
+			log.Warnf("%v. Retry in few seconds", err)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return lastError
+	}
+	return nil
+}
+
+func httpGet(uri string) (int64, io.ReadCloser, error) {
 	var resp *http.Response
+	if err := retryHTTP(func() (err error) {
+		resp, err = httpGetFrom(uri, 0, "")
+		return err
+	}); err != nil {
+		return 0, nil, err
+	}
+	return resp.ContentLength, resp.Body, nil
+}
 
+// httpGetFrom executes an HTTP GET; if offset is greater than zero, only the content starting from offset is
+// requested using an HTTP Range request, conditional to the content still matching validator (If-Range).
+// The response has status code 206 if it contains only the requested range, otherwise the whole content is returned
+// with status code 200, e.g. if the server does not support ranges or the content changed.
+func httpGetFrom(uri string, offset int64, validator string) (*http.Response, error) {
 	// Create a custom http.Client with redirect behavior
 	client := &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -518,54 +823,118 @@ func httpGet(uri string) (int64, io.ReadCloser, error) {
 		},
 	}
 
-	err := wait.ExponentialBackoff(httpGetBackoff, func() (bool, error) {
-		var err error
-		resp, err = client.Get(uri)
-		if err != nil {
-			log.Warnf("HTTP GET %s failed. Retry in few seconds", uri)
-			lastError = errors.Wrapf(err, "HTTP GET %s failed", uri)
-			return false, nil
-		}
-		if resp.StatusCode != http.StatusOK {
-			log.Warnf("HTTP GET %s failed: %s. Retry in few seconds", uri, resp.Status)
-			lastError = errors.Errorf("HTTP GET %s failed: %s", uri, resp.Status)
-			return false, nil
-		}
-		return true, nil
-	})
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
 	if err != nil {
-		return 0, nil, lastError
+		return nil, errors.Wrapf(err, "invalid request for %s", uri)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
 	}
 
-	return resp.ContentLength, resp.Body, nil
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "HTTP GET %s failed", uri)
+	}
+	if resp.StatusCode != http.StatusOK && !(offset > 0 && resp.StatusCode == http.StatusPartialContent) {
+		resp.Body.Close()
+		return nil, &httpStatusError{uri: uri, status: resp.Status, code: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// responseValidator returns the value identifying the version of the content in a response, that can
+// be used in If-Range requests; this is the ETag, if it is a strong one, or the Last-Modified date
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
 }
 
-func copyFromURI(src, dst string) error {
-	size, r, err := httpGet(src)
+// copyFromURI downloads src to dst; interrupted downloads are retried with exponential backoff,
+// resuming the transfer from where it was interrupted if the remote content did not change.
+// If the download fails, the partially downloaded file is removed.
+func copyFromURI(src, dst string, p *progress) error {
+	// validator identifies the version of the remote content being downloaded, if known
+	var validator string
+	if err := retryHTTP(func() error {
+		return transferFromURI(src, dst, &validator, p)
+	}); err != nil {
+		os.Remove(dst + ".download")
+		return err
+	}
+	return nil
+}
+
+// transferFromURI downloads src to a temporary file, and then moves it to dst.
+// If the temporary file already exists because a previous transfer was interrupted, and the validator for
+// the remote content of the previous transfer is known, only the missing part of the content is downloaded.
+func transferFromURI(src, dst string, validator *string, p *progress) error {
+	tmp := dst + ".download"
+
+	var offset int64
+	if info, err := os.Stat(tmp); err == nil && *validator != "" {
+		offset = info.Size()
+	}
+
+	resp, err := httpGetFrom(src, offset, *validator)
 	if err != nil {
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) && statusErr.code == http.StatusRequestedRangeNotSatisfiable {
+			// the range is not valid anymore, so the next transfer starts from zero
+			os.Remove(tmp)
+			*validator = ""
+			return errors.Errorf("HTTP GET %s failed: %s, restarting the download", src, statusErr.status)
+		}
 		return errors.Wrapf(err, "error getting reader for %s", src)
 	}
-	defer r.Close()
+	defer resp.Body.Close()
+	*validator = responseValidator(resp)
+
+	size := resp.ContentLength
+	partial := resp.StatusCode == http.StatusPartialContent
+	if partial {
+		size = -1
+		if resp.ContentLength >= 0 {
+			size = offset + resp.ContentLength
+		}
+	}
 
 	// If the file already exists and has the same size as the remote
 	// content then do not redownload it.
 	if f, err := os.Stat(dst); err == nil {
 		if size == f.Size() {
+			os.Remove(tmp)
 			return nil
 		}
 	}
 
-	w, err := os.Create(dst)
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if partial {
+		log.Infof("Resuming download of %s from %d bytes", src, offset)
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	} else {
+		if offset > 0 {
+			log.Infof("Content of %s changed, restarting the download", src)
+		}
+		offset = 0
+	}
+	w, err := os.OpenFile(tmp, flags, 0644)
 	if err != nil {
-		return errors.Wrapf(err, "error creating %s", dst)
+		return errors.Wrapf(err, "error creating %s", tmp)
 	}
 	defer w.Close()
 
-	if _, err := io.Copy(w, r); err != nil {
-		return errors.Wrapf(err, "error copying %s to %s", src, dst)
+	counter := p.newCounter(src, offset, size)
+	if _, err := io.Copy(w, io.TeeReader(resp.Body, counter)); err != nil {
+		return errors.Wrapf(err, "error copying %s to %s", src, tmp)
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrapf(err, "error writing %s", tmp)
 	}
 
-	return nil
+	return os.Rename(tmp, dst)
 }
 
 type fileNameMutator struct {
@@ -645,7 +1014,7 @@ func ResolveLabel(src string) (version string, err error) {
 		return "", errors.Errorf("source %s did not resolve to a valid label", src)
 	}
 
-	v, err := resolveLabel(repository, src)
+	v, err := resolveLabel(repository, src, nil)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,326 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestExtractWithChecksum(t *testing.T) {
+	// do not retry failed downloads
+	defer func(b wait.Backoff) { httpGetBackoff = b }(httpGetBackoff)
+	httpGetBackoff = wait.Backoff{Steps: 1}
+
+	kubeadm := []byte("kubeadm binary")
+	sha256Sum := sha256.Sum256(kubeadm)
+	sha512Sum := sha512.Sum512(kubeadm)
+	kubeadmSHA256 := hex.EncodeToString(sha256Sum[:])
+	kubeadmSHA512 := hex.EncodeToString(sha512Sum[:])
+
+	tests := []struct {
+		name             string
+		checksum         ChecksumAlgorithm
+		published        map[string]string
+		expectedError    string
+		expectedSumsFile string
+		expectedSums     string
+	}{
+		{
+			name:             "sha256 checksum verified",
+			checksum:         ChecksumSHA256,
+			published:        map[string]string{"/kubeadm.sha256": kubeadmSHA256},
+			expectedSumsFile: "SHA256SUMS",
+			expectedSums:     kubeadmSHA256 + "  kubeadm\n",
+		},
+		{
+			name:             "sha512 checksum verified, with file name in the checksum file",
+			checksum:         ChecksumSHA512,
+			published:        map[string]string{"/kubeadm.sha512": kubeadmSHA512 + "  kubeadm"},
+			expectedSumsFile: "SHA512SUMS",
+			expectedSums:     kubeadmSHA512 + "  kubeadm\n",
+		},
+		{
+			name:          "sha256 checksum mismatch",
+			checksum:      ChecksumSHA256,
+			published:     map[string]string{"/kubeadm.sha256": strings.Repeat("0", 64)},
+			expectedError: "sha256 checksum mismatch",
+		},
+		{
+			name:          "sha256 checksum missing",
+			checksum:      ChecksumSHA256,
+			expectedError: "failed to get the sha256 checksum",
+		},
+		{
+			name:     "checksum not verified by default for remote repositories",
+			checksum: ChecksumAuto,
+		},
+		{
+			name:     "checksum verification disabled",
+			checksum: ChecksumNone,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/kubeadm" {
+					w.Write(kubeadm)
+					return
+				}
+				if c, ok := test.published[r.URL.Path]; ok {
+					w.Write([]byte(c + "\n"))
+					return
+				}
+				http.NotFound(w, r)
+			}))
+			defer server.Close()
+
+			dst := t.TempDir()
+			e := NewExtractor(server.URL, dst,
+				WithCacheDir(""),
+				OnlyKubeadm(true),
+				WithChecksum(test.checksum),
+			)
+			_, err := e.Extract()
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedError, err)
+				}
+				if _, err := os.Stat(filepath.Join(dst, "kubeadm")); !os.IsNotExist(err) {
+					t.Errorf("expected kubeadm to be removed after a failed verification")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, f := range []string{"SHA256SUMS", "SHA512SUMS"} {
+				sums, err := os.ReadFile(filepath.Join(dst, f))
+				if f != test.expectedSumsFile {
+					if err == nil {
+						t.Errorf("expected %s not to be created", f)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("expected %s to be created: %v", f, err)
+				}
+				if string(sums) != test.expectedSums {
+					t.Errorf("expected %s to be %q, got %q", f, test.expectedSums, string(sums))
+				}
+			}
+		})
+	}
+}
+
+func TestCopyFromURI(t *testing.T) {
+	defer func(b wait.Backoff) { httpGetBackoff = b }(httpGetBackoff)
+	httpGetBackoff = wait.Backoff{Steps: 3}
+
+	content := []byte(strings.Repeat("kubeadm binary ", 1000))
+	changed := []byte(strings.Repeat("kubeadm binary v2 ", 1000))
+
+	// interrupt writes half of the content and then aborts the transfer
+	interrupt := func(w http.ResponseWriter) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write(content[:len(content)/2])
+		panic(http.ErrAbortHandler)
+	}
+
+	tests := []struct {
+		name             string
+		handler          func(w http.ResponseWriter, r *http.Request, request int)
+		expectedRequests []string
+		expectedContent  []byte
+		expectedError    bool
+	}{
+		{
+			name: "resumes interrupted downloads",
+			handler: func(w http.ResponseWriter, r *http.Request, request int) {
+				if request == 1 {
+					interrupt(w)
+				}
+				w.Header().Set("ETag", `"v1"`)
+				http.ServeContent(w, r, "kubeadm", time.Time{}, bytes.NewReader(content))
+			},
+			expectedRequests: []string{"", fmt.Sprintf(`bytes=%d- if-range "v1"`, len(content)/2)},
+			expectedContent:  content,
+		},
+		{
+			name: "restarts from zero if the content changed",
+			handler: func(w http.ResponseWriter, r *http.Request, request int) {
+				if request == 1 {
+					interrupt(w)
+				}
+				w.Header().Set("ETag", `"v2"`)
+				http.ServeContent(w, r, "kubeadm", time.Time{}, bytes.NewReader(changed))
+			},
+			expectedRequests: []string{"", fmt.Sprintf(`bytes=%d- if-range "v1"`, len(content)/2)},
+			expectedContent:  changed,
+		},
+		{
+			name: "restarts from zero without a validator",
+			handler: func(w http.ResponseWriter, r *http.Request, request int) {
+				if request == 1 {
+					w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+					w.Write(content[:len(content)/2])
+					panic(http.ErrAbortHandler)
+				}
+				http.ServeContent(w, r, "kubeadm", time.Time{}, bytes.NewReader(content))
+			},
+			expectedRequests: []string{"", ""},
+			expectedContent:  content,
+		},
+		{
+			name: "client errors are not retried",
+			handler: func(w http.ResponseWriter, r *http.Request, request int) {
+				http.NotFound(w, r)
+			},
+			expectedRequests: []string{""},
+			expectedError:    true,
+		},
+		{
+			name: "partial download is removed on failure",
+			handler: func(w http.ResponseWriter, r *http.Request, request int) {
+				if request == 1 {
+					interrupt(w)
+				}
+				http.Error(w, "forbidden", http.StatusForbidden)
+			},
+			expectedRequests: []string{"", fmt.Sprintf(`bytes=%d- if-range "v1"`, len(content)/2)},
+			expectedError:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request := r.Header.Get("Range")
+				if ifRange := r.Header.Get("If-Range"); ifRange != "" {
+					request += " if-range " + ifRange
+				}
+				requests = append(requests, request)
+				test.handler(w, r, len(requests))
+			}))
+			defer server.Close()
+
+			dst := filepath.Join(t.TempDir(), "kubeadm")
+			err := copyFromURI(server.URL+"/kubeadm", dst, newProgress(1))
+			if (err != nil) != test.expectedError {
+				t.Fatalf("expected error %t, got %v", test.expectedError, err)
+			}
+
+			if !reflect.DeepEqual(requests, test.expectedRequests) {
+				t.Errorf("expected requests %q, got %q", test.expectedRequests, requests)
+			}
+			if test.expectedContent != nil {
+				if got, err := os.ReadFile(dst); err != nil || !bytes.Equal(got, test.expectedContent) {
+					t.Errorf("expected the downloaded file to match the content, got %d bytes, %v", len(got), err)
+				}
+			}
+			if _, err := os.Stat(dst + ".download"); !os.IsNotExist(err) {
+				t.Errorf("expected the temporary file to be removed")
+			}
+		})
+	}
+}
+
+func TestExtractFromHTTPWithParallelism(t *testing.T) {
+	var running, maxRunning int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	dst := t.TempDir()
+	e := NewExtractor(server.URL, dst,
+		WithCacheDir(""),
+		WithParallelism(2),
+		WithVersionFile(false),
+	)
+	paths, err := e.Extract()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(paths) != len(allKubernetesBinaries)+len(AllKubernetesImages) {
+		t.Errorf("expected all the files to be downloaded, got %v", paths)
+	}
+	for f, p := range paths {
+		if got, err := os.ReadFile(p); err != nil || string(got) != "/"+f {
+			t.Errorf("unexpected content for %s: %q, %v", f, got, err)
+		}
+	}
+	if maxRunning != 2 {
+		t.Errorf("expected 2 downloads running in parallel, got %d", maxRunning)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	var out bytes.Buffer
+	p := &progress{
+		out:       &out,
+		tty:       true,
+		downloads: map[string]*writeCounter{},
+		files:     2,
+	}
+
+	wc1 := p.newCounter("kubeadm", 0, 1024)
+	wc2 := p.newCounter("kubelet", 512, 3072)
+	if _, err := wc1.Write(make([]byte, 1024)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.fileCompleted()
+	if _, err := wc2.Write(make([]byte, 2560)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.fileCompleted()
+
+	lines := strings.Split(out.String(), "\r")
+	expected := "* progress... 4.0 KiB of 4.0 KiB (100%), 2 of 2 files completed \n"
+	if last := lines[len(lines)-1]; last != expected {
+		t.Errorf("expected final progress %q, got %q", expected, last)
+	}
+}
@@ -0,0 +1,510 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+	kindfs "sigs.k8s.io/kind/pkg/fs"
+)
+
+const (
+	// imageSourcePrefix is the prefix for sources pointing to images in a registry
+	imageSourcePrefix = "image://"
+
+	// kubernetesImageRepository is the default image repository used by kubeadm; Kubernetes images for node image
+	// sources are pulled from this repository, and Kubernetes images pulled from a registry are always tagged with it
+	kubernetesImageRepository = "registry.k8s.io"
+
+	// nodeImageVersionFile is the version file in kindest/node images
+	nodeImageVersionFile = "/kind/version"
+
+	// nodeImageBinDir is the folder where Kubernetes binaries are installed in kindest/node images
+	nodeImageBinDir = "/usr/bin"
+)
+
+// extractFromImage extracts artifacts from images in a registry, with src in one of the following formats:
+//   - image://REGISTRY/REPOSITORY:TAG, that is expected to be a kindest/node image; Kubernetes binaries and the version
+//     file are extracted from the node image, while Kubernetes images are pulled from registry.k8s.io/<component>:VERSION
+//   - image://REGISTRY:TAG, for a registry hosting Kubernetes images; Kubernetes images are pulled from
+//     REGISTRY/<component>:TAG, and binaries can't be extracted
+//
+// Kubernetes images are saved as docker-archive tarballs.
+func extractFromImage(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool, o downloadOptions) (paths map[string]string, err error) {
+	ref, err := parseImageReference(strings.TrimPrefix(src, imageSourcePrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	dst, _ = filepath.Abs(dst)
+	if _, err := os.Stat(dst); os.IsNotExist(err) {
+		return nil, errors.Errorf("destination path %s does not exists", dst)
+	}
+
+	// split files between binaries, to be read from the node image, and images
+	var binaries, images []string
+	for _, f := range files {
+		switch {
+		case f == kubeadmBinary || f == kubeletBinary || f == kubectlBinary:
+			binaries = append(binaries, f)
+		case strings.HasSuffix(f, ".tar") && !strings.Contains(f, "*"):
+			images = append(images, f)
+		default:
+			return nil, errors.Errorf("%s can't be extracted from image sources", f)
+		}
+	}
+
+	// references without a repository point to a registry hosting Kubernetes images, not to a node image
+	isNodeImage := ref.repository != ""
+	if !isNodeImage && len(binaries) > 0 {
+		return nil, errors.Errorf("%s can be extracted only from node images, e.g. image://kindest/node:%s", strings.Join(binaries, ", "), ref.tag)
+	}
+
+	tmpDir, err := kindfs.TempDir("", "kinder-image-source")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	c := newRegistryClient()
+	if o.insecureRegistry {
+		c.insecureRegistry = ref.registry
+	}
+	paths = map[string]string{}
+
+	// read binaries and the version from the node image;
+	// otherwise the version is read from the image tag
+	var version *K8sVersion.Version
+	var extracted map[string]string
+	if isNodeImage {
+		wanted := []string{nodeImageVersionFile}
+		for _, b := range binaries {
+			wanted = append(wanted, path.Join(nodeImageBinDir, b))
+		}
+
+		log.Infof("Extracting files from %s", ref)
+		if extracted, err = extractImageFiles(c, ref, o, tmpDir, wanted); err != nil {
+			return nil, err
+		}
+
+		f, err := os.Open(extracted[nodeImageVersionFile])
+		if err != nil {
+			return nil, err
+		}
+		version, err = readVersion(f)
+		f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading version from %s in %s", nodeImageVersionFile, ref)
+		}
+	} else if addVersionFileToDst || m.prependVersionFolder {
+		if version, err = K8sVersion.ParseSemantic(ref.tag); err != nil {
+			return nil, errors.Wrapf(err, "error reading version from the tag of %s", ref)
+		}
+	}
+
+	// pass the version to the file name mutator
+	// nb. this will allow to save extracted files into a version folder
+	if version != nil {
+		m.SetPrependVersionFolder(version)
+	}
+	if err := m.EnsureFolder(dst); err != nil {
+		return nil, err
+	}
+
+	for _, b := range binaries {
+		dstFilePath := filepath.Join(dst, m.Mutate(b))
+		if err := kindfs.CopyFile(extracted[path.Join(nodeImageBinDir, b)], dstFilePath); err != nil {
+			return nil, errors.Wrapf(err, "failed to copy %s to %s", b, dstFilePath)
+		}
+		os.Chmod(dstFilePath, 0755)
+		paths[b] = dstFilePath
+	}
+
+	// pull Kubernetes images into docker-archive tarballs
+	for _, f := range images {
+		component := strings.TrimSuffix(f, ".tar")
+		imageRef := componentImageReference(ref, component, version)
+		repoTag := fmt.Sprintf("%s/%s:%s", kubernetesImageRepository, component, imageRef.tag)
+
+		log.Infof("Pulling %s", imageRef)
+		dstFilePath := filepath.Join(dst, m.Mutate(f))
+		if err := pullImageArchive(c, imageRef, o, tmpDir, dstFilePath, repoTag); err != nil {
+			return nil, err
+		}
+		paths[f] = dstFilePath
+	}
+
+	if err := saveVersionFile(addVersionFileToDst, dst, version, m); err != nil {
+		return nil, err
+	}
+	if addVersionFileToDst {
+		paths["version"] = filepath.Join(dst, m.Mutate("version"))
+	}
+
+	log.Infof("Extracted files saved into %s", dst)
+	return paths, nil
+}
+
+// componentImageReference returns the reference for the image of a Kubernetes component; images are pulled from
+// registry.k8s.io when the source is a node image, otherwise from the registry in the source
+func componentImageReference(ref imageReference, component string, version *K8sVersion.Version) imageReference {
+	if ref.repository != "" {
+		// image tags can't contain +, so kubernetes build metadata are tagged with _
+		tag := strings.ReplaceAll(fmt.Sprintf("v%s", version), "+", "_")
+		return imageReference{registry: kubernetesImageRepository, repository: component, tag: tag}
+	}
+	return imageReference{registry: ref.registry, repository: component, tag: ref.tag}
+}
+
+// pullLayers downloads the config and the layers of an image into tmpDir, and returns their paths
+func pullLayers(c *registryClient, ref imageReference, o downloadOptions, tmpDir string) (config string, layers []string, mediaTypes []string, err error) {
+	m, err := c.manifest(ref, o.os, o.arch)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	config = filepath.Join(tmpDir, strings.ReplaceAll(m.Config.Digest, ":", "-"))
+	if err := c.blob(ref, m.Config, config); err != nil {
+		return "", nil, nil, err
+	}
+	for _, l := range m.Layers {
+		layer := filepath.Join(tmpDir, strings.ReplaceAll(l.Digest, ":", "-"))
+		if err := c.blob(ref, l, layer); err != nil {
+			return "", nil, nil, err
+		}
+		layers = append(layers, layer)
+		mediaTypes = append(mediaTypes, l.MediaType)
+	}
+	return config, layers, mediaTypes, nil
+}
+
+// openLayer returns a reader for the uncompressed content of a layer
+func openLayer(layer, mediaType string) (io.ReadCloser, error) {
+	f, err := os.Open(layer)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.Contains(mediaType, "zstd"):
+		f.Close()
+		return nil, errors.Errorf("unsupported layer media type %s", mediaType)
+	case strings.Contains(mediaType, "gzip"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, errors.Wrapf(err, "invalid gzip layer %s", layer)
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{gz, f}, nil
+	}
+	return f, nil
+}
+
+// pullImageArchive pulls an image and saves it as a docker-archive tarball tagged with repoTag,
+// that is the format used by docker save and by Kubernetes release image tarballs
+func pullImageArchive(c *registryClient, ref imageReference, o downloadOptions, tmpDir, dst, repoTag string) error {
+	config, layers, mediaTypes, err := pullLayers(c, ref, o, tmpDir)
+	if err != nil {
+		return err
+	}
+
+	// uncompress layers, computing their digest
+	var layerFiles, diffIDs []string
+	for i, l := range layers {
+		r, err := openLayer(l, mediaTypes[i])
+		if err != nil {
+			return err
+		}
+		uncompressed := l + ".tar"
+		w, err := os.Create(uncompressed)
+		if err != nil {
+			r.Close()
+			return err
+		}
+		h := sha256.New()
+		_, err = io.Copy(io.MultiWriter(w, h), r)
+		r.Close()
+		w.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to uncompress layer %s of %s", filepath.Base(l), ref)
+		}
+		layerFiles = append(layerFiles, uncompressed)
+		diffIDs = append(diffIDs, hex.EncodeToString(h.Sum(nil)))
+	}
+
+	configContent, err := os.ReadFile(config)
+	if err != nil {
+		return err
+	}
+	configSum := sha256.Sum256(configContent)
+	configName := hex.EncodeToString(configSum[:]) + ".json"
+
+	// write the docker-archive
+	tmp := dst + ".download"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return errors.Wrapf(err, "error creating %s", tmp)
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	addFile := func(name string, content []byte) error {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		_, err := tw.Write(content)
+		return err
+	}
+
+	if err := addFile(configName, configContent); err != nil {
+		return err
+	}
+	var layerNames []string
+	for i, l := range layerFiles {
+		info, err := os.Stat(l)
+		if err != nil {
+			return err
+		}
+		name := path.Join(diffIDs[i], "layer.tar")
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size()}); err != nil {
+			return err
+		}
+		r, err := os.Open(l)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		layerNames = append(layerNames, name)
+	}
+
+	repository, tag, _ := strings.Cut(repoTag, ":")
+	manifest, err := json.Marshal([]map[string]interface{}{{
+		"Config":   configName,
+		"RepoTags": []string{repoTag},
+		"Layers":   layerNames,
+	}})
+	if err != nil {
+		return err
+	}
+	if err := addFile("manifest.json", manifest); err != nil {
+		return err
+	}
+	// the repositories file maps tags to the image ID, that is the digest of the config
+	repositories, err := json.Marshal(map[string]map[string]string{
+		repository: {tag: hex.EncodeToString(configSum[:])},
+	})
+	if err != nil {
+		return err
+	}
+	if err := addFile("repositories", repositories); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// imageFileEntry defines a file in the image filesystem
+type imageFileEntry struct {
+	layer    int
+	typeflag byte
+	linkname string
+}
+
+// extractImageFiles extracts files from the filesystem of an image into tmpDir, following symlinks,
+// and returns the path of the extracted files
+func extractImageFiles(c *registryClient, ref imageReference, o downloadOptions, tmpDir string, wanted []string) (map[string]string, error) {
+	_, layers, mediaTypes, err := pullLayers(c, ref, o, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// build an index of the image filesystem, applying whiteouts from upper layers
+	index := map[string]imageFileEntry{}
+	for i, l := range layers {
+		entries := map[string]imageFileEntry{}
+		var whiteouts, opaques []string
+		err := walkLayer(l, mediaTypes[i], func(hdr *tar.Header, _ io.Reader) error {
+			name := cleanImagePath(hdr.Name)
+			dir, base := path.Split(name)
+			switch {
+			case base == ".wh..wh..opq":
+				opaques = append(opaques, strings.TrimSuffix(dir, "/"))
+			case strings.HasPrefix(base, ".wh."):
+				whiteouts = append(whiteouts, path.Join(dir, strings.TrimPrefix(base, ".wh.")))
+			default:
+				entries[name] = imageFileEntry{layer: i, typeflag: hdr.Typeflag, linkname: hdr.Linkname}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read layer %d of %s", i, ref)
+		}
+		for p := range index {
+			for _, w := range whiteouts {
+				if p == w || strings.HasPrefix(p, w+"/") {
+					delete(index, p)
+				}
+			}
+			for _, d := range opaques {
+				if strings.HasPrefix(p, d+"/") {
+					delete(index, p)
+				}
+			}
+		}
+		for p, e := range entries {
+			index[p] = e
+		}
+	}
+
+	// resolve the wanted files, following symlinks and hard links
+	resolved := map[string]string{}
+	byLayer := map[int]map[string]string{}
+	for _, w := range wanted {
+		p, err := resolveImagePath(index, cleanImagePath(w))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find %s in %s", w, ref)
+		}
+		resolved[w] = p
+		if byLayer[index[p].layer] == nil {
+			byLayer[index[p].layer] = map[string]string{}
+		}
+		byLayer[index[p].layer][p] = filepath.Join(tmpDir, "files", p)
+	}
+
+	// extract the files
+	for i, files := range byLayer {
+		err := walkLayer(layers[i], mediaTypes[i], func(hdr *tar.Header, r io.Reader) error {
+			dst, ok := files[cleanImagePath(hdr.Name)]
+			if !ok {
+				return nil
+			}
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return err
+			}
+			w, err := os.Create(dst)
+			if err != nil {
+				return err
+			}
+			defer w.Close()
+			_, err = io.Copy(w, r)
+			return err
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to extract files from layer %d of %s", i, ref)
+		}
+	}
+
+	extracted := map[string]string{}
+	for w, p := range resolved {
+		extracted[w] = byLayer[index[p].layer][p]
+	}
+	return extracted, nil
+}
+
+// walkLayer calls fn for each entry in a layer
+func walkLayer(layer, mediaType string, fn func(*tar.Header, io.Reader) error) error {
+	r, err := openLayer(layer, mediaType)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// cleanImagePath returns the path of a file in the image filesystem, without the leading /
+func cleanImagePath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// resolveImagePath resolves a path in the image filesystem, following symlinks and hard links
+// in every element of the path
+func resolveImagePath(index map[string]imageFileEntry, p string) (string, error) {
+	const maxLinks = 40
+
+	links := 0
+	resolved := ""
+	remaining := strings.Split(p, "/")
+	for len(remaining) > 0 {
+		current := path.Join(resolved, remaining[0])
+		remaining = remaining[1:]
+
+		e, ok := index[current]
+		switch {
+		case ok && e.typeflag == tar.TypeSymlink:
+			links++
+			if links > maxLinks {
+				return "", errors.Errorf("too many links resolving %s", p)
+			}
+			target := e.linkname
+			if !path.IsAbs(target) {
+				target = path.Join(path.Dir(current), target)
+			}
+			remaining = append(strings.Split(cleanImagePath(target), "/"), remaining...)
+			resolved = ""
+		case ok && e.typeflag == tar.TypeLink && len(remaining) == 0:
+			resolved = cleanImagePath(e.linkname)
+		default:
+			resolved = current
+		}
+	}
+
+	e, ok := index[resolved]
+	if !ok || (e.typeflag != tar.TypeReg && e.typeflag != tar.TypeRegA) {
+		return "", errors.Errorf("%s is not a regular file", p)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,409 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	K8sVersion "k8s.io/apimachinery/pkg/util/version"
+)
+
+// fakeRegistry implements the subset of the OCI distribution API used for pulling images,
+// as a stand-in for a registry:2 container
+type fakeRegistry struct {
+	server    *httptest.Server
+	manifests map[string][]byte
+	blobs     map[string][]byte
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	r := &fakeRegistry{
+		manifests: map[string][]byte{},
+		blobs:     map[string][]byte{},
+	}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// paths are in the /v2/<name>/manifests/<reference> or /v2/<name>/blobs/<digest> format
+		p := strings.TrimPrefix(req.URL.Path, "/v2/")
+		if i := strings.LastIndex(p, "/manifests/"); i >= 0 {
+			if m, ok := r.manifests[p[:i]+":"+p[i+len("/manifests/"):]]; ok {
+				w.Write(m)
+				return
+			}
+		}
+		if i := strings.LastIndex(p, "/blobs/"); i >= 0 {
+			if b, ok := r.blobs[p[i+len("/blobs/"):]]; ok {
+				w.Write(b)
+				return
+			}
+		}
+		http.NotFound(w, req)
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// host returns the host of the fake registry, to be used in image references
+func (r *fakeRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+func (r *fakeRegistry) addBlob(mediaType string, content []byte) descriptor {
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	r.blobs[digest] = content
+	return descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(content))}
+}
+
+func (r *fakeRegistry) addManifest(t *testing.T, repository, reference string, m imageManifest) descriptor {
+	content, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	d := descriptor{MediaType: m.MediaType, Digest: "sha256:" + hex.EncodeToString(sum[:]), Size: int64(len(content))}
+	r.manifests[repository+":"+reference] = content
+	r.manifests[repository+":"+d.Digest] = content
+	return d
+}
+
+// layerFile defines a file in an image layer
+type layerFile struct {
+	hdr     *tar.Header
+	content string
+}
+
+// addImage adds an image with the given layers, and returns the layers uncompressed
+func (r *fakeRegistry) addImage(t *testing.T, repository, reference string, layers ...[]layerFile) (descriptor, [][]byte) {
+	m := imageManifest{
+		MediaType: mediaTypeOCIManifest,
+		Config:    r.addBlob("application/vnd.oci.image.config.v1+json", []byte(`{"architecture":"amd64","os":"linux"}`)),
+	}
+	var uncompressed [][]byte
+	for _, l := range layers {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, f := range l {
+			f.hdr.Size = int64(len(f.content))
+			if err := tw.WriteHeader(f.hdr); err != nil {
+				t.Fatal(err)
+			}
+			tw.Write([]byte(f.content))
+		}
+		tw.Close()
+		uncompressed = append(uncompressed, buf.Bytes())
+
+		var gz bytes.Buffer
+		zw := gzip.NewWriter(&gz)
+		zw.Write(buf.Bytes())
+		zw.Close()
+		m.Layers = append(m.Layers, r.addBlob("application/vnd.oci.image.layer.v1.tar+gzip", gz.Bytes()))
+	}
+	return r.addManifest(t, repository, reference, m), uncompressed
+}
+
+func file(name, content string) layerFile {
+	return layerFile{hdr: &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644}, content: content}
+}
+
+func symlink(name, target string) layerFile {
+	return layerFile{hdr: &tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target, Mode: 0777}}
+}
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		ref           string
+		expected      imageReference
+		expectedError bool
+	}{
+		{ref: "kindest/node:v1.31.0", expected: imageReference{registry: "docker.io", repository: "kindest/node", tag: "v1.31.0"}},
+		{ref: "registry.k8s.io/kube-apiserver:v1.31.0", expected: imageReference{registry: "registry.k8s.io", repository: "kube-apiserver", tag: "v1.31.0"}},
+		{ref: "localhost:5000/kindest/node:v1.31.0", expected: imageReference{registry: "localhost:5000", repository: "kindest/node", tag: "v1.31.0"}},
+		{ref: "registry.k8s.io:v1.31.0", expected: imageReference{registry: "registry.k8s.io", tag: "v1.31.0"}},
+		{ref: "localhost:5000/kindest/node", expectedError: true},
+		{ref: "kindest/node", expectedError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ref, func(t *testing.T) {
+			r, err := parseImageReference(test.ref)
+			if test.expectedError {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r != test.expected {
+				t.Errorf("expected %#v, got %#v", test.expected, r)
+			}
+		})
+	}
+}
+
+func TestExtractFromImage(t *testing.T) {
+	registry := newFakeRegistry(t)
+
+	// a node image for a different platform, that should be ignored
+	otherImage, _ := registry.addImage(t, "kindest/node", "linux-amd64",
+		[]layerFile{file("kind/version", "v1.30.0"), file("usr/bin/kubeadm", "amd64")},
+	)
+	otherImage.Platform = &platform{Architecture: "amd64", OS: "linux"}
+
+	// a node image, with kubeadm and kubelet symlinked from /kind/bin, and kubectl deleted in an upper layer
+	nodeImage, _ := registry.addImage(t, "kindest/node", "linux-arm64",
+		[]layerFile{
+			file("kind/version", "v1.31.0"),
+			file("kind/bin/kubeadm", "old kubeadm"),
+			file("kind/bin/kubelet", "old kubelet"),
+			file("usr/bin/kubectl", "kubectl"),
+			symlink("usr/bin/kubeadm", "/kind/bin/kubeadm"),
+			symlink("usr/bin/kubelet", "../../kind/bin/kubelet"),
+		},
+		[]layerFile{
+			file("kind/bin/.wh..wh..opq", ""),
+			file("kind/bin/kubeadm", "kubeadm"),
+			file("kind/bin/kubelet", "kubelet"),
+			file("usr/bin/.wh.kubectl", ""),
+		},
+	)
+	nodeImage.Platform = &platform{Architecture: "arm64", OS: "linux"}
+
+	registry.addManifest(t, "kindest/node", "v1.31.0", imageManifest{
+		MediaType: mediaTypeOCIIndex,
+		Manifests: []descriptor{otherImage, nodeImage},
+	})
+
+	// a component image
+	apiserverImage, apiserverLayers := registry.addImage(t, "kube-apiserver", "v1.31.0",
+		[]layerFile{file("usr/local/bin/kube-apiserver", "kube-apiserver")},
+	)
+
+	t.Run("binaries from a node image", func(t *testing.T) {
+		dst := t.TempDir()
+		e := NewExtractor("image://"+registry.host()+"/kindest/node:v1.31.0", dst,
+			WithArch("arm64"),
+			WithVersionFolder(true),
+		)
+		e.SetFiles([]string{kubeadmBinary, kubeletBinary})
+		paths, err := e.Extract()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := map[string]string{
+			kubeadmBinary: filepath.Join(dst, "v1.31.0", kubeadmBinary),
+			kubeletBinary: filepath.Join(dst, "v1.31.0", kubeletBinary),
+			"version":     filepath.Join(dst, "version"),
+		}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Fatalf("expected paths %v, got %v", expected, paths)
+		}
+		for name, content := range map[string]string{
+			kubeadmBinary: "kubeadm",
+			kubeletBinary: "kubelet",
+			"version":     "v1.31.0",
+		} {
+			b, err := os.ReadFile(paths[name])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != content {
+				t.Errorf("expected %s to be %q, got %q", name, content, string(b))
+			}
+		}
+	})
+
+	t.Run("binaries deleted in a node image", func(t *testing.T) {
+		e := NewExtractor("image://"+registry.host()+"/kindest/node:v1.31.0", t.TempDir(), WithArch("arm64"))
+		e.SetFiles([]string{kubectlBinary})
+		if _, err := e.Extract(); err == nil || !strings.Contains(err.Error(), "failed to find /usr/bin/kubectl") {
+			t.Fatalf("expected an error for kubectl, got %v", err)
+		}
+	})
+
+	t.Run("node image not available for the platform", func(t *testing.T) {
+		e := NewExtractor("image://"+registry.host()+"/kindest/node:v1.31.0", t.TempDir(), WithArch("s390x"), OnlyKubeadm(true))
+		if _, err := e.Extract(); err == nil || !strings.Contains(err.Error(), "not available for linux/s390x") {
+			t.Fatalf("expected an error for the platform, got %v", err)
+		}
+	})
+
+	t.Run("binaries from a registry", func(t *testing.T) {
+		e := NewExtractor("image://"+registry.host()+":v1.31.0", t.TempDir(), OnlyKubeadm(true))
+		if _, err := e.Extract(); err == nil || !strings.Contains(err.Error(), "only from node images") {
+			t.Fatalf("expected an error for kubeadm, got %v", err)
+		}
+	})
+
+	t.Run("images from a registry", func(t *testing.T) {
+		dst := t.TempDir()
+		e := NewExtractor("image://"+registry.host()+":v1.31.0", dst)
+		e.SetFiles([]string{"kube-apiserver.tar"})
+		paths, err := e.Extract()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// read the docker-archive
+		f, err := os.Open(paths["kube-apiserver.tar"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		archive := map[string][]byte{}
+		tr := tar.NewReader(f)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			archive[hdr.Name], _ = io.ReadAll(tr)
+		}
+
+		var manifest []struct {
+			Config   string
+			RepoTags []string
+			Layers   []string
+		}
+		if err := json.Unmarshal(archive["manifest.json"], &manifest); err != nil {
+			t.Fatalf("invalid manifest.json: %v", err)
+		}
+		if len(manifest) != 1 || len(manifest[0].Layers) != 1 {
+			t.Fatalf("unexpected manifest.json %s", archive["manifest.json"])
+		}
+		if expected := []string{"registry.k8s.io/kube-apiserver:v1.31.0"}; !reflect.DeepEqual(manifest[0].RepoTags, expected) {
+			t.Errorf("expected RepoTags %v, got %v", expected, manifest[0].RepoTags)
+		}
+		if _, ok := archive[manifest[0].Config]; !ok {
+			t.Errorf("expected config %s in the archive", manifest[0].Config)
+		}
+		if !bytes.Equal(archive[manifest[0].Layers[0]], apiserverLayers[0]) {
+			t.Errorf("expected layer %s to be the uncompressed layer", manifest[0].Layers[0])
+		}
+
+		// the repositories file should point to the image ID, that is the digest of the config
+		var m imageManifest
+		if err := json.Unmarshal(registry.manifests["kube-apiserver:"+apiserverImage.Digest], &m); err != nil {
+			t.Fatal(err)
+		}
+		imageID := strings.TrimPrefix(m.Config.Digest, "sha256:")
+		if expected := fmt.Sprintf(`{"registry.k8s.io/kube-apiserver":{"v1.31.0":%q}}`, imageID); string(archive["repositories"]) != expected {
+			t.Errorf("expected repositories %s, got %s", expected, archive["repositories"])
+		}
+		if manifest[0].Config != imageID+".json" {
+			t.Errorf("expected config %s.json, got %s", imageID, manifest[0].Config)
+		}
+	})
+}
+
+func TestComponentImageReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		version  string
+		expected string
+	}{
+		{name: "node image", ref: "localhost:5000/kindest/node:latest", version: "v1.31.0", expected: "registry.k8s.io/kube-apiserver:v1.31.0"},
+		{name: "node image with build metadata", ref: "kindest/node:v1.32.0", version: "v1.32.0-alpha.1.52+4c1ebd3d1b3bd0", expected: "registry.k8s.io/kube-apiserver:v1.32.0-alpha.1.52_4c1ebd3d1b3bd0"},
+		{name: "registry", ref: "localhost:5000:v1.31.0", version: "v1.31.0", expected: "localhost:5000/kube-apiserver:v1.31.0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ref, err := parseImageReference(test.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := componentImageReference(ref, "kube-apiserver", K8sVersion.MustParseSemantic(test.version)).String(); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMatchPlatform(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform platform
+		goarch   string
+		expected bool
+	}{
+		{name: "same arch", platform: platform{OS: "linux", Architecture: "amd64"}, goarch: "amd64", expected: true},
+		{name: "different arch", platform: platform{OS: "linux", Architecture: "amd64"}, goarch: "arm64", expected: false},
+		{name: "different os", platform: platform{OS: "windows", Architecture: "amd64"}, goarch: "amd64", expected: false},
+		{name: "default variant", platform: platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, goarch: "arm64", expected: true},
+		{name: "missing default variant", platform: platform{OS: "linux", Architecture: "arm64"}, goarch: "arm64/v8", expected: true},
+		{name: "non default variant", platform: platform{OS: "linux", Architecture: "arm", Variant: "v6"}, goarch: "arm", expected: false},
+		{name: "explicit variant", platform: platform{OS: "linux", Architecture: "arm", Variant: "v6"}, goarch: "arm/v6", expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := matchPlatform(test.platform, "linux", test.goarch); actual != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRegistryBaseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		insecure string
+		expected string
+	}{
+		{name: "default registry", ref: "kindest/node:v1.31.0", expected: "https://registry-1.docker.io/v2"},
+		{name: "remote registry", ref: "registry.example.com/kindest/node:v1.31.0", expected: "https://registry.example.com/v2"},
+		{name: "local registry", ref: "localhost:5000/kindest/node:v1.31.0", expected: "http://localhost:5000/v2"},
+		{name: "insecure registry", ref: "registry.example.com:5000/kindest/node:v1.31.0", insecure: "registry.example.com:5000", expected: "http://registry.example.com:5000/v2"},
+		{name: "other registry when insecure", ref: "registry.k8s.io/kube-apiserver:v1.31.0", insecure: "registry.example.com:5000", expected: "https://registry.k8s.io/v2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ref, err := parseImageReference(test.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := newRegistryClient()
+			c.insecureRegistry = test.insecure
+			if actual := c.baseURL(ref); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	kindfs "sigs.k8s.io/kind/pkg/fs"
+
+	"k8s.io/kubeadm/kinder/pkg/kuberoot"
+)
+
+// kubeRootSourcePrefix is the prefix for sources pointing to a Kubernetes source checkout
+const kubeRootSourcePrefix = "kuberoot://"
+
+// extractFromKubeRoot extracts artifacts built from a Kubernetes source checkout, with src in the kuberoot://PATH format;
+// if PATH is empty, the checkout is searched in $GOPATH/src/k8s.io/kubernetes.
+//   - Kubernetes binaries are read from the output of make quick-release (_output/dockerized/bin) or make (_output/local/bin)
+//   - Kubernetes images are read from the output of make quick-release or make quick-release-images (_output/release-images)
+//
+// If required, missing artifacts are built using make.
+func extractFromKubeRoot(src string, files []string, dst string, m fileNameMutator, addVersionFileToDst bool, o downloadOptions) (paths map[string]string, err error) {
+	root := strings.TrimPrefix(src, kubeRootSourcePrefix)
+	if root == "" {
+		if root, err = kuberoot.Find(); err != nil {
+			return nil, err
+		}
+	}
+	root, _ = filepath.Abs(root)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, errors.Errorf("Kubernetes source path %s does not exists", root)
+	}
+	log.Infof("Using Kubernetes checkout in %s", root)
+
+	// checks if target folder exists
+	dst, _ = filepath.Abs(dst)
+	if _, err := os.Stat(dst); os.IsNotExist(err) {
+		return nil, errors.Errorf("destination path %s does not exists", dst)
+	}
+
+	// locate build outputs, eventually building missing artifacts
+	imagesDir := kuberoot.ImagesDir(root, o.arch)
+	srcPaths, missingBinaries, missingImages, err := findKubeRootArtifacts(root, imagesDir, files, o)
+	if err != nil {
+		return nil, err
+	}
+	if (len(missingBinaries) > 0 || missingImages) && o.build {
+		if len(missingBinaries) > 0 {
+			var targets []string
+			for _, b := range missingBinaries {
+				targets = append(targets, "cmd/"+b)
+			}
+			if err := kuberoot.BuildBinaries(root, o.os, o.arch, targets...); err != nil {
+				return nil, err
+			}
+		}
+		if missingImages {
+			if err := kuberoot.BuildImages(root, o.arch); err != nil {
+				return nil, err
+			}
+		}
+		if srcPaths, missingBinaries, missingImages, err = findKubeRootArtifacts(root, imagesDir, files, o); err != nil {
+			return nil, err
+		}
+	}
+	if len(missingBinaries) > 0 {
+		return nil, errors.Errorf("unable to find build output for %s in %s; please build it, e.g. with make quick-release, or use --build", strings.Join(missingBinaries, ", "), root)
+	}
+	if missingImages {
+		return nil, errors.Errorf("unable to find images in %s; please build them, e.g. with make quick-release-images, or use --build", imagesDir)
+	}
+
+	// read the version from the build metadata
+	v, err := kuberoot.Version(root, o.os, o.arch)
+	if err != nil {
+		return nil, err
+	}
+	version, err := readVersion(strings.NewReader(v))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid version for the Kubernetes checkout in %s", root)
+	}
+
+	// pass the version to the file name mutator
+	// nb. this will allow to save extracted files into a version folder
+	m.SetPrependVersionFolder(version)
+	if err := m.EnsureFolder(dst); err != nil {
+		return nil, err
+	}
+
+	// copy files from build outputs to target
+	paths = map[string]string{}
+	for f, srcFilePath := range srcPaths {
+		log.Infof("Copying %s", srcFilePath)
+
+		dstFilePath := filepath.Join(dst, m.Mutate(f))
+		if err := kindfs.Copy(srcFilePath, dstFilePath); err != nil {
+			return nil, errors.Wrapf(err, "failed to copy %s", srcFilePath)
+		}
+		if f == kubeadmBinary || f == kubeletBinary || f == kubectlBinary {
+			os.Chmod(dstFilePath, 0755)
+		}
+		paths[f] = dstFilePath
+	}
+
+	if err := saveVersionFile(addVersionFileToDst, dst, version, m); err != nil {
+		return nil, err
+	}
+	if addVersionFileToDst {
+		paths["version"] = filepath.Join(dst, m.Mutate("version"))
+	}
+
+	log.Infof("Copied files saved into %s", dst)
+	return paths, nil
+}
+
+// findKubeRootArtifacts returns the path of the build outputs for the given files, as well as the list of
+// missing binaries and if images are missing
+func findKubeRootArtifacts(root, imagesDir string, files []string, o downloadOptions) (paths map[string]string, missingBinaries []string, missingImages bool, err error) {
+	paths = map[string]string{}
+	var images []string
+	for _, f := range files {
+		if f != kubeadmBinary && f != kubeletBinary && f != kubectlBinary {
+			images = append(images, f)
+			continue
+		}
+		p, err := kuberoot.FindPlatformBinary(root, f, o.os, o.arch)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if p == "" {
+			missingBinaries = append(missingBinaries, f)
+			continue
+		}
+		paths[f] = p
+	}
+
+	if len(images) == 0 {
+		return paths, missingBinaries, false, nil
+	}
+	if _, err := os.Stat(imagesDir); os.IsNotExist(err) {
+		return paths, missingBinaries, true, nil
+	}
+	if images, err = expandWildcards(imagesDir, images); err != nil {
+		return nil, nil, false, err
+	}
+	for _, f := range images {
+		p := filepath.Join(imagesDir, f)
+		if _, err := os.Stat(p); err != nil {
+			return paths, missingBinaries, true, nil
+		}
+		paths[f] = p
+	}
+	return paths, missingBinaries, false, nil
+}
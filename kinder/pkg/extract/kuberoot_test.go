@@ -0,0 +1,135 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractFromKubeRoot(t *testing.T) {
+	const version = "v1.32.0-alpha.1.23+0123456789abcd"
+
+	// fakeKubeRoot returns a Kubernetes checkout with the output of make quick-release for linux/s390x
+	fakeKubeRoot := func(t *testing.T, files ...string) string {
+		root := t.TempDir()
+		for _, f := range append(files, "_output/release-stage/server/linux-s390x/kubernetes/version") {
+			p := filepath.Join(root, f)
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				t.Fatal(err)
+			}
+			content := filepath.Base(f)
+			if content == "version" {
+				content = version + "\n"
+			}
+			if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return root
+	}
+	binaries := []string{
+		"_output/dockerized/bin/linux/s390x/kubeadm",
+		"_output/dockerized/bin/linux/s390x/kubelet",
+		"_output/dockerized/bin/linux/s390x/kubectl",
+	}
+	images := []string{
+		"_output/release-images/s390x/kube-apiserver.tar",
+		"_output/release-images/s390x/kube-controller-manager.tar",
+		"_output/release-images/s390x/kube-scheduler.tar",
+		"_output/release-images/s390x/kube-proxy.tar",
+	}
+
+	tests := []struct {
+		name          string
+		files         []string
+		options       []Option
+		expectedFiles []string
+		expectedError string
+	}{
+		{
+			name:          "binaries and images",
+			files:         append(binaries, images...),
+			expectedFiles: []string{"kubeadm", "kubelet", "kubectl", "kube-apiserver.tar", "kube-controller-manager.tar", "kube-scheduler.tar", "kube-proxy.tar"},
+		},
+		{
+			name:          "only kubeadm",
+			files:         binaries[:1],
+			options:       []Option{OnlyKubeadm(true)},
+			expectedFiles: []string{"kubeadm"},
+		},
+		{
+			name:          "missing binaries",
+			files:         binaries[:1],
+			options:       []Option{OnlyKubernetesBinaries(true)},
+			expectedError: "unable to find build output for kubelet, kubectl",
+		},
+		{
+			name:          "missing images",
+			files:         binaries,
+			options:       []Option{OnlyKubernetesImages(true)},
+			expectedError: "unable to find images",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := fakeKubeRoot(t, test.files...)
+			dst := t.TempDir()
+
+			e := NewExtractor("kuberoot://"+root, dst,
+				append([]Option{WithArch("s390x"), WithVersionFolder(true)}, test.options...)...,
+			)
+			paths, err := e.Extract()
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := map[string]string{}
+			for _, f := range test.expectedFiles {
+				expected[f] = filepath.Join(dst, version, f)
+			}
+			if len(test.options) == 0 {
+				expected["version"] = filepath.Join(dst, "version")
+			}
+			if !reflect.DeepEqual(paths, expected) {
+				t.Fatalf("expected paths %v, got %v", expected, paths)
+			}
+			for f, p := range paths {
+				b, err := os.ReadFile(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if f == "version" {
+					f = version
+				}
+				if string(b) != f {
+					t.Errorf("expected %s to be %q, got %q", p, f, string(b))
+				}
+			}
+		})
+	}
+}
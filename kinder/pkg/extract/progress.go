@@ -0,0 +1,177 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ttyProgressInterval is the interval between progress updates on a terminal
+	ttyProgressInterval = 200 * time.Millisecond
+
+	// logProgressInterval is the interval between progress log lines when not running on a terminal, e.g. in CI
+	logProgressInterval = 10 * time.Second
+)
+
+// progress reports the progress of the downloads of an extraction.
+// On a terminal, progress is printed on a single line updated in place; otherwise, e.g. in CI logs,
+// progress is periodically reported with log lines.
+type progress struct {
+	mu        sync.Mutex
+	out       io.Writer
+	tty       bool
+	interval  time.Duration
+	last      time.Time
+	downloads map[string]*writeCounter
+	files     int
+	completed int
+}
+
+// newProgress returns a progress reporter for the downloads of the given number of files
+func newProgress(files int) *progress {
+	tty := isTerminal(os.Stderr)
+	interval := logProgressInterval
+	if tty {
+		interval = ttyProgressInterval
+	}
+	return &progress{
+		out:       os.Stderr,
+		tty:       tty,
+		interval:  interval,
+		last:      time.Now(),
+		downloads: map[string]*writeCounter{},
+		files:     files,
+	}
+}
+
+// isTerminal returns true if f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// newCounter returns a writeCounter for tracking the download of src, starting from offset;
+// size is the expected size of src, or -1 if unknown. If the download of src was already
+// tracked, e.g. before an interruption, the new counter replaces the previous one.
+func (p *progress) newCounter(src string, offset, size int64) *writeCounter {
+	wc := &writeCounter{p: p, total: offset, totalLength: size}
+	if p != nil {
+		p.mu.Lock()
+		p.downloads[src] = wc
+		p.mu.Unlock()
+	}
+	return wc
+}
+
+// update eventually prints the progress, if the update interval is elapsed
+func (p *progress) update() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if time.Since(p.last) < p.interval {
+		return
+	}
+	p.last = time.Now()
+	p.print()
+}
+
+// fileCompleted tracks the completion of a file; when all the files are completed the final progress is printed
+func (p *progress) fileCompleted() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.completed++
+	if p.completed == p.files && len(p.downloads) > 0 {
+		p.print()
+		if p.tty {
+			fmt.Fprintln(p.out)
+		}
+	}
+}
+
+// print prints the progress; it must be called holding the lock
+func (p *progress) print() {
+	var total, totalLength int64
+	for _, wc := range p.downloads {
+		total += wc.written()
+		if wc.totalLength > 0 {
+			totalLength += wc.totalLength
+		}
+	}
+
+	msg := formatBytes(total)
+	if totalLength > 0 {
+		msg = fmt.Sprintf("%s of %s (%d%%)", msg, formatBytes(totalLength), int64(float64(total)/float64(totalLength)*100.0))
+	}
+	msg = fmt.Sprintf("%s, %d of %d files completed", msg, p.completed, p.files)
+
+	if p.tty {
+		fmt.Fprintf(p.out, "\r* progress... %s ", msg)
+		return
+	}
+	log.Infof("Download progress: %s", msg)
+}
+
+// formatBytes returns a human readable representation of n bytes
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// writeCounter counts the bytes written while downloading a file, and reports them to progress
+type writeCounter struct {
+	p           *progress
+	total       int64
+	totalLength int64
+}
+
+func (wc *writeCounter) Write(b []byte) (int, error) {
+	n := len(b)
+	atomic.AddInt64(&wc.total, int64(n))
+	if wc.p != nil {
+		wc.p.update()
+	}
+	return n, nil
+}
+
+// written returns the number of bytes written so far
+func (wc *writeCounter) written() int64 {
+	return atomic.LoadInt64(&wc.total)
+}
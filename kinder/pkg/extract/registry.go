@@ -0,0 +1,330 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultRegistry is the registry used for image references without a registry host, e.g. kindest/node:v1.31.0
+	defaultRegistry = "docker.io"
+
+	// defaultRegistryAPIHost is the host serving the registry API for the default registry
+	defaultRegistryAPIHost = "registry-1.docker.io"
+
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// imageReference defines a reference to an image in a registry, e.g. registry.k8s.io/kube-apiserver:v1.31.0
+type imageReference struct {
+	// registry host, eventually including the port
+	registry string
+	// repository in the registry; it can be empty for references used as a prefix, e.g. registry.k8s.io:v1.31.0
+	repository string
+	// tag of the image
+	tag string
+}
+
+// parseImageReference parses an image reference in the REGISTRY[/REPOSITORY]:TAG format;
+// if the registry is missing, docker.io is assumed.
+func parseImageReference(ref string) (imageReference, error) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || i < strings.LastIndex(ref, "/") || i == len(ref)-1 {
+		return imageReference{}, errors.Errorf("invalid image reference %q, a tag is required", ref)
+	}
+	name, tag := ref[:i], ref[i+1:]
+
+	// the first path element is a registry host if it looks like an host name
+	registry, repository := name, ""
+	if j := strings.Index(name, "/"); j >= 0 {
+		registry, repository = name[:j], name[j+1:]
+	}
+	if !strings.ContainsAny(registry, ".:") && registry != "localhost" {
+		registry, repository = defaultRegistry, name
+	}
+
+	return imageReference{registry: registry, repository: repository, tag: tag}, nil
+}
+
+func (r imageReference) String() string {
+	if r.repository == "" {
+		return fmt.Sprintf("%s:%s", r.registry, r.tag)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.registry, r.repository, r.tag)
+}
+
+// descriptor describes a content in a registry
+type descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *platform `json:"platform,omitempty"`
+}
+
+// platform describes the platform of an image in a manifest list
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// imageManifest defines an image manifest, or a manifest list/image index
+type imageManifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// registryClient implements a minimal client for the OCI distribution API, allowing to pull images
+// anonymously from public registries or from local registries, e.g. a registry:2 container.
+type registryClient struct {
+	client *http.Client
+	// tokens are the bearer tokens by repository, obtained from the registry auth service
+	tokens map[string]string
+	// insecureRegistry is a registry host to be accessed via http instead of https
+	insecureRegistry string
+}
+
+func newRegistryClient() *registryClient {
+	return &registryClient{
+		client: &http.Client{},
+		tokens: map[string]string{},
+	}
+}
+
+// baseURL returns the url for the registry API
+func (c *registryClient) baseURL(r imageReference) string {
+	host := r.registry
+	if host == defaultRegistry {
+		host = defaultRegistryAPIHost
+	}
+
+	// local registries are accessed via http, as the docker CLI does, as well as registries explicitly marked as insecure
+	scheme := "https"
+	if hostname := strings.Split(host, ":")[0]; hostname == "localhost" || hostname == "127.0.0.1" || host == c.insecureRegistry {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2", scheme, host)
+}
+
+// get executes a GET for a path in a repository, taking care of getting an anonymous bearer token if required
+func (c *registryClient) get(r imageReference, path string, accept ...string) (*http.Response, error) {
+	uri := fmt.Sprintf("%s/%s/%s", c.baseURL(r), r.repository, path)
+
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range accept {
+			req.Header.Add("Accept", a)
+		}
+		if token, ok := c.tokens[r.repository]; ok {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return c.client.Do(req)
+	}
+
+	resp, err := do()
+	if err != nil {
+		return nil, errors.Wrapf(err, "HTTP GET %s failed", uri)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.authenticate(r, challenge); err != nil {
+			return nil, errors.Wrapf(err, "failed to authenticate to %s", r.registry)
+		}
+		if resp, err = do(); err != nil {
+			return nil, errors.Wrapf(err, "HTTP GET %s failed", uri)
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("HTTP GET %s failed: %s", uri, resp.Status)
+	}
+	return resp, nil
+}
+
+// challengeParamRE matches the parameters of a WWW-Authenticate challenge, e.g. realm="https://auth.docker.io/token"
+var challengeParamRE = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate gets an anonymous bearer token for pulling from the repository
+func (c *registryClient) authenticate(r imageReference, challenge string) error {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return errors.Errorf("unsupported authentication challenge %q", challenge)
+	}
+	params := map[string]string{}
+	for _, m := range challengeParamRE.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["realm"] == "" {
+		return errors.Errorf("missing realm in authentication challenge %q", challenge)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, params["realm"], nil)
+	if err != nil {
+		return err
+	}
+	q := req.URL.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	q.Set("scope", fmt.Sprintf("repository:%s:pull", r.repository))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("HTTP GET %s failed: %s", req.URL, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return errors.Wrap(err, "invalid token")
+	}
+	c.tokens[r.repository] = token.Token
+	if token.Token == "" {
+		c.tokens[r.repository] = token.AccessToken
+	}
+	return nil
+}
+
+// manifest returns the image manifest for the given platform; if the reference points to a manifest list,
+// the manifest for the platform is selected, taking care of the variant of the architecture.
+func (c *registryClient) manifest(r imageReference, goos, goarch string) (*imageManifest, error) {
+	m, err := c.getManifest(r, r.tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.MediaType == mediaTypeDockerManifestList || m.MediaType == mediaTypeOCIIndex || len(m.Manifests) > 0 {
+		var platformDigest string
+		for _, d := range m.Manifests {
+			if d.Platform != nil && matchPlatform(*d.Platform, goos, goarch) {
+				platformDigest = d.Digest
+				break
+			}
+		}
+		if platformDigest == "" {
+			return nil, errors.Errorf("image %s is not available for %s/%s", r, goos, goarch)
+		}
+		if m, err = c.getManifest(r, platformDigest); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.Config.Digest == "" {
+		return nil, errors.Errorf("unsupported manifest for image %s", r)
+	}
+	return m, nil
+}
+
+// matchPlatform returns true if the platform of an image matches the given os and arch; arch can include a variant,
+// e.g. arm/v6, and if the variant is missing the default variant for the arch is assumed, e.g. v8 for arm64,
+// like the container runtimes do
+func matchPlatform(p platform, goos, goarch string) bool {
+	arch, variant, _ := strings.Cut(goarch, "/")
+	return p.OS == goos && p.Architecture == arch &&
+		normalizeVariant(p.Architecture, p.Variant) == normalizeVariant(arch, variant)
+}
+
+// normalizeVariant returns the variant of an arch, or the default variant if empty
+func normalizeVariant(arch, variant string) string {
+	if variant != "" {
+		return variant
+	}
+	switch arch {
+	case "arm64":
+		return "v8"
+	case "arm":
+		return "v7"
+	}
+	return ""
+}
+
+func (c *registryClient) getManifest(r imageReference, reference string) (*imageManifest, error) {
+	resp, err := c.get(r, "manifests/"+reference,
+		mediaTypeDockerManifestList, mediaTypeOCIIndex, mediaTypeDockerManifest, mediaTypeOCIManifest,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the manifest for %s", r)
+	}
+	defer resp.Body.Close()
+
+	m := &imageManifest{}
+	if err := json.NewDecoder(resp.Body).Decode(m); err != nil {
+		return nil, errors.Wrapf(err, "invalid manifest for %s", r)
+	}
+	if m.MediaType == "" {
+		m.MediaType = resp.Header.Get("Content-Type")
+	}
+	return m, nil
+}
+
+// blob downloads a blob into a file, verifying its digest
+func (c *registryClient) blob(r imageReference, d descriptor, dst string) error {
+	algorithm, expected, ok := strings.Cut(d.Digest, ":")
+	if !ok || algorithm != "sha256" {
+		return errors.Errorf("unsupported digest %q", d.Digest)
+	}
+
+	resp, err := c.get(r, "blobs/"+d.Digest)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get blob %s for %s", d.Digest, r)
+	}
+	defer resp.Body.Close()
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), resp.Body); err != nil {
+		return errors.Wrapf(err, "failed to download blob %s for %s", d.Digest, r)
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return errors.Errorf("digest mismatch for blob %s of %s, got sha256:%s", d.Digest, r, actual)
+	}
+
+	log.Debugf("Downloaded blob %s for %s", d.Digest, r)
+	return w.Close()
+}
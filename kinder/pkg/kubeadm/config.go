@@ -87,6 +87,8 @@ type ConfigData struct {
 	ControlPlane bool
 	// The main IP address of the node
 	NodeAddress string
+	// The secondary IP address of the node, if any; this is used for dual-stack clusters
+	NodeAddressSecondary string
 	// The Token for TLS bootstrap
 	Token string
 	// The subnet used for pods
@@ -150,10 +152,8 @@ scheduler:
   extraArgs:
   # configure ipv6 default addresses for IPv6 clusters
   {{ if .IPv6 -}}
-  - name: address
-    value: "::"
   - name: bind-address
-    vaue: "::1"
+    value: "::"
   {{- end }}
 networking:
   podSubnet: "{{ .PodSubnet }}"
@@ -177,7 +177,7 @@ nodeRegistration:
   criSocket: "/run/containerd/containerd.sock"
   kubeletExtraArgs:
   - name: node-ip
-    value: "{{ .NodeAddress }}"
+    value: "{{ .NodeAddress }}{{ if .NodeAddressSecondary }},{{ .NodeAddressSecondary }}{{ end }}"
   ignorePreflightErrors:
   {{range .IgnorePreflightErrors }}  - {{.}}
   {{end}}
@@ -195,7 +195,7 @@ nodeRegistration:
   criSocket: "/run/containerd/containerd.sock"
   kubeletExtraArgs:
   - name: node-ip
-    value: "{{ .NodeAddress }}"
+    value: "{{ .NodeAddress }}{{ if .NodeAddressSecondary }},{{ .NodeAddressSecondary }}{{ end }}"
   ignorePreflightErrors:
   {{range .IgnorePreflightErrors }}  - {{.}}
   {{end}}
@@ -288,8 +288,7 @@ scheduler:
   extraArgs:
     # configure ipv6 default addresses for IPv6 clusters
     {{ if .IPv6 -}}
-    address: "::"
-    bind-address: "::1"
+    bind-address: "::"
     {{- end }}
 networking:
   podSubnet: "{{ .PodSubnet }}"
@@ -312,7 +311,7 @@ localAPIEndpoint:
 nodeRegistration:
   criSocket: "/run/containerd/containerd.sock"
   kubeletExtraArgs:
-    node-ip: "{{ .NodeAddress }}"
+    node-ip: "{{ .NodeAddress }}{{ if .NodeAddressSecondary }},{{ .NodeAddressSecondary }}{{ end }}"
   ignorePreflightErrors:
   {{range .IgnorePreflightErrors }}  - {{.}}
   {{end}}
@@ -330,7 +329,7 @@ controlPlane:
 nodeRegistration:
   criSocket: "/run/containerd/containerd.sock"
   kubeletExtraArgs:
-    node-ip: "{{ .NodeAddress }}"
+    node-ip: "{{ .NodeAddress }}{{ if .NodeAddressSecondary }},{{ .NodeAddressSecondary }}{{ end }}"
   ignorePreflightErrors:
   {{range .IgnorePreflightErrors }}  - {{.}}
   {{end}}
@@ -0,0 +1,70 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CRIOSocket is the CRI socket for the CRI-O container runtime
+const CRIOSocket = "unix:///var/run/crio/crio.sock"
+
+// GetCRIOPatch returns the kubeadm config patch that will instruct kubeadm
+// to use the CRI-O socket for init, join and, if supported by the config version, reset.
+func GetCRIOPatch(kubeadmConfigVersion string) ([]string, error) {
+	// select the patches for the kubeadm config version
+	log.Debugf("Preparing crioPatch for kubeadm config %s", kubeadmConfigVersion)
+
+	var basePatch string
+	switch kubeadmConfigVersion {
+	case "v1beta3":
+		basePatch = crioPatchv1beta3
+	case "v1beta4":
+		basePatch = crioPatchv1beta4
+	default:
+		return nil, errors.Errorf("unknown kubeadm config version: %s", kubeadmConfigVersion)
+	}
+
+	patches := []string{
+		fmt.Sprintf(basePatch, "InitConfiguration", CRIOSocket),
+		fmt.Sprintf(basePatch, "JoinConfiguration", CRIOSocket),
+	}
+
+	// ResetConfiguration exists only in v1beta4
+	if kubeadmConfigVersion == "v1beta4" {
+		patches = append(patches, fmt.Sprintf(crioResetPatchv1beta4, CRIOSocket))
+	}
+
+	return patches, nil
+}
+
+const crioPatchv1beta3 = `apiVersion: kubeadm.k8s.io/v1beta3
+kind: %s
+nodeRegistration:
+  criSocket: %s`
+
+const crioPatchv1beta4 = `apiVersion: kubeadm.k8s.io/v1beta4
+kind: %s
+nodeRegistration:
+  criSocket: %s`
+
+const crioResetPatchv1beta4 = `apiVersion: kubeadm.k8s.io/v1beta4
+kind: ResetConfiguration
+criSocket: %s`
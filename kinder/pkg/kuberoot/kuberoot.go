@@ -0,0 +1,210 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package kuberoot implements support for using artifacts from a Kubernetes source checkout,
+eventually building them if necessary.
+*/
+package kuberoot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"k8s.io/kubeadm/kinder/pkg/exec"
+)
+
+// GetOrBuildBinary tries to find a binary, and if not exist it builds it
+func GetOrBuildBinary(kubeRoot, name, target string) (string, error) {
+	path, err := FindBinary(kubeRoot, name)
+	if err != nil {
+		return "", errors.Errorf("error finding %s binary", name)
+	}
+
+	if path == "" {
+		log.Debugf("%s binary not found, triggering build", name)
+
+		cmd := exec.NewHostCmd("make", "-C", kubeRoot, fmt.Sprintf("WHAT=%s", target))
+		if err = cmd.RunWithEcho(); err != nil {
+			return "", errors.Errorf("error building %s binary - %s target", name, target)
+		}
+	}
+
+	path, err = FindBinary(kubeRoot, name)
+	if err != nil {
+		return "", errors.Errorf("error finding build output for %s binary", name)
+	}
+
+	if path != "" {
+		log.Infof("using %s binary: %s", name, path)
+		return path, nil
+	}
+
+	return "", errors.Errorf("unable to find build output for %s binary", name)
+}
+
+// Find attempts to locate a kubernetes checkout
+func Find() (root string, err error) {
+	goroot := os.Getenv("GOPATH")
+	if goroot == "" {
+		cmd := exec.NewHostCmd("go", "env", "GOPATH")
+		lines, err := cmd.RunAndCapture()
+		if err != nil {
+			return "", err
+		}
+		goroot = lines[0]
+		if goroot == "" {
+			return "", errors.New("unable to get GOPATH env variable. Please provide Kubernetes path source using the --kube-root flag")
+		}
+	}
+
+	kubeRoot := filepath.Join(goroot, "src", "k8s.io", "kubernetes")
+	if _, err := os.Stat(kubeRoot); os.IsNotExist(err) {
+		return "", errors.New("$GOPATH/src/k8s.io/kubernetes does not exists. Please provide Kubernetes path source using the --kube-root flag")
+	}
+
+	if !maybeKubeRoot(kubeRoot) {
+		return "", errors.New("$GOPATH/src/k8s.io/kubernetes does not seems a valid Kubernetes source folder. Please provide Kubernetes source path using the --kube-root flag")
+	}
+	return kubeRoot, nil
+}
+
+// maybeKubeRoot returns true if the dir looks plausibly like a kubernetes source directory
+func maybeKubeRoot(dir string) bool {
+	// TODO: consider adding other sanity checks
+	return dir != ""
+}
+
+// FindBinary finds a file by name, from a list of well-known output locations
+// When multiple matches are found, the most recent will be returned
+// Based on kube::util::find-binary from kubernetes/kubernetes
+func FindBinary(kubeRoot string, name string) (string, error) {
+	return findBinary(name, []string{
+		filepath.Join(kubeRoot, "_output", "bin", name),
+		filepath.Join(kubeRoot, "_output", "dockerized", "bin", name),
+		filepath.Join(kubeRoot, "_output", "dockerized", "go", "bin", name),
+		filepath.Join(kubeRoot, "_output", "local", "bin", name),
+		filepath.Join(kubeRoot, "_output", "local", "bin", "go", name),
+		filepath.Join(kubeRoot, "platforms", runtime.GOOS, runtime.GOARCH, name),
+	})
+}
+
+// FindPlatformBinary finds a file by name for the given platform, from a list of well-known output locations
+// of cross builds, e.g. make quick-release; for the host platform, also locations of FindBinary are considered.
+// When multiple matches are found, the most recent will be returned
+func FindPlatformBinary(kubeRoot, name, goos, goarch string) (string, error) {
+	locations := []string{
+		filepath.Join(kubeRoot, "_output", "dockerized", "bin", goos, goarch, name),
+		filepath.Join(kubeRoot, "_output", "local", "bin", goos, goarch, name),
+	}
+	if goos == runtime.GOOS && goarch == runtime.GOARCH {
+		path, err := FindBinary(kubeRoot, name)
+		if err != nil {
+			return "", err
+		}
+		if path != "" {
+			locations = append(locations, path)
+		}
+	}
+	return findBinary(name, locations)
+}
+
+// findBinary returns the most recent file from a list of locations
+func findBinary(name string, locations []string) (string, error) {
+	newestLocation := ""
+	var newestModTime time.Time
+	for _, loc := range locations {
+		stat, err := os.Stat(loc)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", errors.Wrapf(err, "error accessing %s location", loc)
+		}
+		if newestLocation == "" || stat.ModTime().After(newestModTime) {
+			newestModTime = stat.ModTime()
+			newestLocation = loc
+		}
+	}
+
+	if newestLocation == "" {
+		log.Debugf("could not find %s binary, looked in %s", name, locations)
+	}
+
+	return newestLocation, nil
+}
+
+// ImagesDir returns the folder where make quick-release saves image tarballs for the given architecture
+func ImagesDir(kubeRoot, goarch string) string {
+	return filepath.Join(kubeRoot, "_output", "release-images", goarch)
+}
+
+// BuildBinaries builds binaries for the given platform, e.g. cmd/kubeadm
+func BuildBinaries(kubeRoot, goos, goarch string, targets ...string) error {
+	log.Infof("Building %s for %s/%s", strings.Join(targets, ", "), goos, goarch)
+
+	cmd := exec.NewHostCmd("make", "-C", kubeRoot,
+		fmt.Sprintf("WHAT=%s", strings.Join(targets, " ")),
+		fmt.Sprintf("KUBE_BUILD_PLATFORMS=%s/%s", goos, goarch),
+	)
+	if err := cmd.RunWithEcho(); err != nil {
+		return errors.Wrapf(err, "error building %s", strings.Join(targets, ", "))
+	}
+	return nil
+}
+
+// BuildImages builds image tarballs for the given architecture, using the quick-release-images target
+func BuildImages(kubeRoot, goarch string) error {
+	log.Infof("Building images for linux/%s", goarch)
+
+	cmd := exec.NewHostCmd("make", "-C", kubeRoot, "quick-release-images",
+		fmt.Sprintf("KUBE_BUILD_PLATFORMS=linux/%s", goarch),
+		"KUBE_BUILD_CONFORMANCE=n",
+	)
+	if err := cmd.RunWithEcho(); err != nil {
+		return errors.Wrap(err, "error building images")
+	}
+	return nil
+}
+
+// Version returns the version of the artifacts built from a Kubernetes checkout, reading it from the
+// version file in the release stage folder, if any, or from the git version of the checkout
+func Version(kubeRoot, goos, goarch string) (string, error) {
+	versionFile := filepath.Join(kubeRoot, "_output", "release-stage", "server", fmt.Sprintf("%s-%s", goos, goarch), "kubernetes", "version")
+	if b, err := os.ReadFile(versionFile); err == nil {
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	// the workspace status contains the version computed from git, like the one used by the build
+	cmd := exec.NewHostCmd(filepath.Join(kubeRoot, "hack", "print-workspace-status.sh"))
+	lines, err := cmd.RunAndCapture()
+	if err != nil {
+		return "", errors.Wrapf(err, "error reading the version of the Kubernetes checkout in %s", kubeRoot)
+	}
+	for _, l := range lines {
+		if k, v, ok := strings.Cut(l, " "); ok && k == "gitVersion" {
+			return strings.TrimSpace(v), nil
+		}
+	}
+	return "", errors.Errorf("unable to find gitVersion in the workspace status of %s", kubeRoot)
+}
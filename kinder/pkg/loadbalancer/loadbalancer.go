@@ -27,7 +27,8 @@ import (
 type ConfigData struct {
 	ControlPlanePort int
 	BackendServers   map[string]string
-	IPv6             bool
+	// IPv6 instructs the load balancer to listen on IPv6 addresses too; this is required for IPv6 and dual-stack clusters
+	IPv6 bool
 }
 
 // DefaultConfigTemplate is the loadbalancer config template
@@ -49,7 +50,7 @@ defaults
 frontend control-plane
   bind *:{{ .ControlPlanePort }}
   {{ if .IPv6 -}}
-  bind :::{{ .ControlPlanePort }};
+  bind :::{{ .ControlPlanePort }} v6only
   {{- end }}
   default_backend kube-apiservers
 
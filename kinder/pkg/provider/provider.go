@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package provider implements the selection of the container engine that hosts kinder nodes.
+
+Docker is used by default, while podman and nerdctl are experimental alternatives that can be
+selected using the KINDER_EXPERIMENTAL_PROVIDER environment variable or the kinder --provider flag.
+
+All the supported providers implement a docker compatible CLI for the operations used by kinder,
+like node listing, create, exec, cp and inspect; this package takes care of the few differences.
+*/
+package provider
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Provider defines a container engine that hosts kinder nodes
+type Provider string
+
+const (
+	// Docker refers to the docker container engine
+	Docker Provider = "docker"
+	// Podman refers to the podman container engine
+	Podman Provider = "podman"
+	// Nerdctl refers to the nerdctl CLI for the containerd container engine
+	Nerdctl Provider = "nerdctl"
+
+	// EnvVar is the environment variable that can be used for selecting the provider
+	EnvVar = "KINDER_EXPERIMENTAL_PROVIDER"
+)
+
+// selected holds the provider explicitly selected with Set, if any
+var selected Provider
+
+// Known returns the list of known providers
+func Known() []string {
+	return []string{
+		string(Docker),
+		string(Podman),
+		string(Nerdctl),
+	}
+}
+
+// Validate validates a Provider
+func Validate(p Provider) error {
+	switch p {
+	case Docker, Podman, Nerdctl:
+	default:
+		return errors.Errorf("invalid provider %q. Use one of %s", p, Known())
+	}
+	return nil
+}
+
+// Set selects the provider to be used, overriding the provider selected with the EnvVar
+func Set(p Provider) error {
+	if err := Validate(p); err != nil {
+		return err
+	}
+	selected = p
+	return nil
+}
+
+// Get returns the provider to be used; this is the provider selected with Set if any,
+// otherwise the provider selected with the EnvVar, otherwise Docker
+func Get() Provider {
+	if selected != "" {
+		return selected
+	}
+	if p := Provider(strings.ToLower(os.Getenv(EnvVar))); p != "" && Validate(p) == nil {
+		return p
+	}
+	return Docker
+}
+
+// Command returns the CLI command for the provider to be used
+func Command() string {
+	return string(Get())
+}
+
+// NetworkSubnetsFormat returns the format for printing the subnets of a network with the network inspect command
+func (p Provider) NetworkSubnetsFormat() string {
+	if p == Podman {
+		return `{{range .Subnets}}{{.Subnet}} {{end}}`
+	}
+	return `{{range (index (index . "IPAM") "Config")}}{{index . "Subnet"}} {{end}}`
+}
+
+// ImageFormat returns the format for printing the image of a container with the inspect command;
+// nerdctl reports the image name in .Image, while docker and podman use .Image for the image ID
+func (p Provider) ImageFormat() string {
+	if p == Nerdctl {
+		return `{{.Image}}`
+	}
+	return `{{.Config.Image}}`
+}
+
+// CommitArgs returns the args for the commit command, applying the given Dockerfile instructions
+// to the new image; nerdctl supports only CMD and ENTRYPOINT instructions, so other instructions
+// are dropped. This is fine for kinder node images, because node containers are always created
+// with an anonymous volume for /var
+func (p Provider) CommitArgs(containerID, targetImage string, changes ...string) []string {
+	args := []string{"commit"}
+	for _, c := range changes {
+		if p == Nerdctl && !strings.HasPrefix(c, "CMD ") && !strings.HasPrefix(c, "ENTRYPOINT ") {
+			continue
+		}
+		args = append(args, "--change", c)
+	}
+	return append(args, containerID, targetImage)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name             string
+		env              string
+		set              Provider
+		expectedProvider Provider
+		expectedError    bool
+	}{
+		{
+			name:             "docker by default",
+			expectedProvider: Docker,
+		},
+		{
+			name:             "provider from env",
+			env:              "podman",
+			expectedProvider: Podman,
+		},
+		{
+			name:             "provider from env is case insensitive",
+			env:              "NERDCTL",
+			expectedProvider: Nerdctl,
+		},
+		{
+			name:             "invalid provider from env is ignored",
+			env:              "foo",
+			expectedProvider: Docker,
+		},
+		{
+			name:             "provider set takes precedence on env",
+			env:              "podman",
+			set:              Nerdctl,
+			expectedProvider: Nerdctl,
+		},
+		{
+			name:             "invalid provider set",
+			set:              "foo",
+			expectedProvider: Docker,
+			expectedError:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected = ""
+			defer func() { selected = "" }()
+			t.Setenv(EnvVar, test.env)
+
+			if test.set != "" {
+				err := Set(test.set)
+				if (err != nil) != test.expectedError {
+					t.Fatalf("expected error: %v, got: %v", test.expectedError, err)
+				}
+			}
+
+			if p := Get(); p != test.expectedProvider {
+				t.Errorf("expected provider %s, got %s", test.expectedProvider, p)
+			}
+		})
+	}
+}
+
+func TestCommitArgs(t *testing.T) {
+	changes := []string{
+		`VOLUME [ "/var/lib/containerd" ]`,
+		`ENTRYPOINT [ "/usr/local/bin/entrypoint", "/sbin/init" ]`,
+	}
+
+	tests := []struct {
+		name         string
+		provider     Provider
+		changes      []string
+		expectedArgs []string
+	}{
+		{
+			name:     "docker",
+			provider: Docker,
+			changes:  changes,
+			expectedArgs: []string{
+				"commit",
+				"--change", `VOLUME [ "/var/lib/containerd" ]`,
+				"--change", `ENTRYPOINT [ "/usr/local/bin/entrypoint", "/sbin/init" ]`,
+				"abc", "kindest/node:test",
+			},
+		},
+		{
+			name:     "podman",
+			provider: Podman,
+			changes:  changes,
+			expectedArgs: []string{
+				"commit",
+				"--change", `VOLUME [ "/var/lib/containerd" ]`,
+				"--change", `ENTRYPOINT [ "/usr/local/bin/entrypoint", "/sbin/init" ]`,
+				"abc", "kindest/node:test",
+			},
+		},
+		{
+			name:     "nerdctl supports only CMD and ENTRYPOINT",
+			provider: Nerdctl,
+			changes:  changes,
+			expectedArgs: []string{
+				"commit",
+				"--change", `ENTRYPOINT [ "/usr/local/bin/entrypoint", "/sbin/init" ]`,
+				"abc", "kindest/node:test",
+			},
+		},
+		{
+			name:         "no changes",
+			provider:     Nerdctl,
+			expectedArgs: []string{"commit", "abc", "kindest/node:test"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := test.provider.CommitArgs("abc", "kindest/node:test", test.changes...)
+			if !reflect.DeepEqual(args, test.expectedArgs) {
+				t.Errorf("expected args %q, got %q", test.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestImageFormat(t *testing.T) {
+	tests := []struct {
+		provider       Provider
+		expectedFormat string
+	}{
+		{provider: Docker, expectedFormat: `{{.Config.Image}}`},
+		{provider: Podman, expectedFormat: `{{.Config.Image}}`},
+		{provider: Nerdctl, expectedFormat: `{{.Image}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.provider), func(t *testing.T) {
+			if format := test.provider.ImageFormat(); format != test.expectedFormat {
+				t.Errorf("expected format %q, got %q", test.expectedFormat, format)
+			}
+		})
+	}
+}
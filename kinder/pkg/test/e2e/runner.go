@@ -28,6 +28,7 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/kubeadm/kinder/pkg/exec"
+	"k8s.io/kubeadm/kinder/pkg/kuberoot"
 )
 
 // Option is an Runner configuration option supplied to NewRunner
@@ -87,7 +88,7 @@ func newTestRunner(testBinary, makeBinaryGoal string, options ...Option) (runner
 
 	// sets kubeRoot if not provided by the user
 	if runner.kubeRoot == "" {
-		runner.kubeRoot, err = findKubeRoot()
+		runner.kubeRoot, err = kuberoot.Find()
 		if err != nil {
 			return nil, errors.Wrap(err, "")
 		}
@@ -101,13 +102,13 @@ func newTestRunner(testBinary, makeBinaryGoal string, options ...Option) (runner
 // it takes care of building ginkgo and upstream test suites if necessary,
 func (r *Runner) Run() error {
 	// find a ginkgo binary or build it if it not exists
-	ginkgoBinary, err := getOrBuildBinary(r.kubeRoot, "ginkgo", "vendor/github.com/onsi/ginkgo/ginkgo")
+	ginkgoBinary, err := kuberoot.GetOrBuildBinary(r.kubeRoot, "ginkgo", "vendor/github.com/onsi/ginkgo/ginkgo")
 	if err != nil {
 		return err
 	}
 
 	// find the binary with the test suites to be executes or build it if it not exists
-	testBinary, err := getOrBuildBinary(r.kubeRoot, r.testBinary, r.makeBinaryGoal)
+	testBinary, err := kuberoot.GetOrBuildBinary(r.kubeRoot, r.testBinary, r.makeBinaryGoal)
 	if err != nil {
 		return err
 	}